@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/queue"
 	"github.com/nyaruka/gocommon/urns"
 )
 
@@ -29,6 +30,9 @@ type Backend interface {
 	// GetChannelByAddress returns the channel with the passed in type and address
 	GetChannelByAddress(context.Context, ChannelType, ChannelAddress) (Channel, error)
 
+	// GetChannelsByType returns all the active channels with the passed in type
+	GetChannelsByType(context.Context, ChannelType) ([]Channel, error)
+
 	// GetContact returns (or creates) the contact for the passed in channel and URN
 	GetContact(context context.Context, channel Channel, urn urns.URN, auth string, name string) (Contact, error)
 
@@ -47,6 +51,10 @@ type Backend interface {
 	// WriteMsg writes the passed in message to our backend
 	WriteMsg(context.Context, Msg) error
 
+	// QueueOutgoingReply writes a new high priority outgoing message which courier sends itself, such as a keyword
+	// confirmation, and queues it to be sent like the messages queued by RapidPro
+	QueueOutgoingReply(ctx context.Context, channel Channel, urn urns.URN, text string) (Msg, error)
+
 	// NewMsgStatusForID creates a new Status object for the given message id
 	NewMsgStatusForID(Channel, MsgID, MsgStatusValue) MsgStatus
 
@@ -77,6 +85,15 @@ type Backend interface {
 	// a message is being forced in being resent by a user
 	ClearMsgSent(context.Context, MsgID) error
 
+	// GetDeadLetters returns the outgoing queue entries which couldn't be sent, newest first
+	GetDeadLetters(context.Context) ([]*queue.DeadLetter, error)
+
+	// ReplayDeadLetter puts the dead letter with the passed in UUID back onto its queue, returning nil if it doesn't exist
+	ReplayDeadLetter(context.Context, string) (*queue.DeadLetter, error)
+
+	// DiscardDeadLetter removes the dead letter with the passed in UUID, returning nil if it doesn't exist
+	DiscardDeadLetter(context.Context, string) (*queue.DeadLetter, error)
+
 	// Gets a list of all active purges
 	GetActivePurges(context.Context) ([]string, error)
 
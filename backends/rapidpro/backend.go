@@ -32,9 +32,19 @@ const msgQueueName = "msgs"
 // the name of our set for tracking sends
 const sentSetName = "msgs_sent_%s"
 
+// the name of our set for tracking which messages have had their cost tallied
+const costedSetName = "msgs_costed_%s"
+
 // our timeout for backend operations
 const backendTimeout = time.Second * 20
 
+// how long the lease of the instance running our dethrottler lasts if not renewed, throttled queues stall for up to
+// this long if that instance dies
+const dethrottlerLeaderTTL = time.Second * 15
+
+// how long we wait before popping a message again whose channel couldn't be loaded, e.g. because the DB is down
+const channelRetryDelay = time.Second * 30
+
 func init() {
 	courier.RegisterBackend("rapidpro", newBackend)
 }
@@ -44,7 +54,7 @@ func (b *backend) GetChannel(ctx context.Context, ct courier.ChannelType, uuid c
 	timeout, cancel := context.WithTimeout(ctx, backendTimeout)
 	defer cancel()
 
-	return getChannel(timeout, b.db, ct, uuid)
+	return getChannel(timeout, b.reader, ct, uuid)
 }
 
 // GetChannelByAddress returns the channel with the passed in type and address
@@ -52,7 +62,24 @@ func (b *backend) GetChannelByAddress(ctx context.Context, ct courier.ChannelTyp
 	timeout, cancel := context.WithTimeout(ctx, backendTimeout)
 	defer cancel()
 
-	return getChannelByAddress(timeout, b.db, ct, address)
+	return getChannelByAddress(timeout, b.reader, ct, address)
+}
+
+// GetChannelsByType returns all the active channels with the passed in type
+func (b *backend) GetChannelsByType(ctx context.Context, ct courier.ChannelType) ([]courier.Channel, error) {
+	timeout, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	dbChannels, err := loadChannelsByTypeFromDB(timeout, b.reader, ct)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]courier.Channel, len(dbChannels))
+	for i := range dbChannels {
+		channels[i] = dbChannels[i]
+	}
+	return channels, nil
 }
 
 // GetContact returns the contact for the passed in channel and URN
@@ -149,50 +176,133 @@ func (b *backend) NewOutgoingMsg(channel courier.Channel, urn urns.URN, text str
 	return newMsg(MsgOutgoing, channel, urn, text)
 }
 
+// QueueOutgoingReply writes and queues a new high priority outgoing message which courier sends itself
+func (b *backend) QueueOutgoingReply(ctx context.Context, channel courier.Channel, urn urns.URN, text string) (courier.Msg, error) {
+	msg := newMsg(MsgOutgoing, channel, urn, text)
+	msg.HighPriority_ = true
+
+	timeout, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	return msg, writeOutgoingMsg(timeout, b, msg)
+}
+
 // PopNextOutgoingMsg pops the next message that needs to be sent
 func (b *backend) PopNextOutgoingMsg(ctx context.Context) (courier.Msg, error) {
 	// pop the next message off our queue
 	rc := b.redisPool.Get()
 	defer rc.Close()
 
-	token, msgJSON, err := queue.PopFromQueue(rc, msgQueueName)
-	for token == queue.Retry {
-		token, msgJSON, err = queue.PopFromQueue(rc, msgQueueName)
-	}
+	for {
+		token, msgJSON, err := queue.PopFromQueue(rc, msgQueueName)
+		for token == queue.Retry {
+			token, msgJSON, err = queue.PopFromQueue(rc, msgQueueName)
+		}
+
+		if msgJSON == "" {
+			return nil, nil
+		}
 
-	if msgJSON != "" {
 		dbMsg := &DBMsg{}
 		err = json.Unmarshal([]byte(msgJSON), dbMsg)
 		if err != nil {
 			queue.MarkComplete(rc, msgQueueName, token)
+			b.deadLetterMsg(ctx, rc, token, msgJSON, fmt.Sprintf("unable to unmarshal message: %s", err))
 			return nil, fmt.Errorf("unable to unmarshal message '%s': %s", msgJSON, err)
 		}
 		// populate the channel on our db msg
 		channel, err := b.GetChannel(ctx, courier.AnyChannelType, dbMsg.ChannelUUID_)
 		if err != nil {
-			queue.MarkComplete(rc, msgQueueName, token)
+			// only a channel which no longer exists makes the message undeliverable, otherwise we try it again later
+			if errors.Cause(err) == courier.ErrChannelNotFound {
+				queue.MarkComplete(rc, msgQueueName, token)
+				b.deadLetterMsg(ctx, rc, token, msgJSON, fmt.Sprintf("unable to load channel: %s", err))
+			} else {
+				b.retryMsg(rc, dbMsg, token, msgJSON)
+			}
 			return nil, err
 		}
 		dbMsg.channel = channel.(*DBChannel)
 		dbMsg.workerToken = token
 
+		// make sure we know the org of this queue when scheduling between orgs
+		if queue.Scheduling.OrgSample > 1 {
+			recordQueueOrg(rc, token, dbMsg.channel)
+		}
+
+		// messages with a send window are held until it opens, and failed if it closes before they're sent
+		window := courier.ParseSendWindow(dbMsg.Metadata_)
+		now := time.Now()
+		if window.IsEarly(now) {
+			err := b.deferMsg(rc, dbMsg, msgJSON, window.NotBefore)
+			if err == nil {
+				continue
+			}
+
+			// better to send it early than to lose it
+			logrus.WithError(err).WithField("msg_id", dbMsg.ID_).Error("error deferring message until its send time")
+		}
+		if window.IsExpired(now) {
+			queue.MarkComplete(rc, msgQueueName, token)
+			b.expireMsg(ctx, dbMsg, window.NotAfter)
+			continue
+		}
+
 		// clear out our seen incoming messages
 		clearMsgSeen(rc, dbMsg)
 
 		return dbMsg, nil
 	}
+}
+
+// deferMsg pushes a message which was popped before its send time back onto its queue to be popped at that time
+func (b *backend) deferMsg(rc redis.Conn, msg *DBMsg, msgJSON string, sendAt time.Time) error {
+	priority := queue.Priority(queue.LowPriority)
+	if msg.HighPriority_ {
+		priority = queue.HighPriority
+	}
+
+	err := queue.PushBack(rc, msgQueueName, msg.workerToken, msgJSON, priority, sendAt)
+	if err != nil {
+		return err
+	}
+	return queue.MarkComplete(rc, msgQueueName, msg.workerToken)
+}
+
+// retryMsg pushes a message whose channel couldn't be loaded back onto its queue to be popped again shortly. If that
+// fails too, its lease is left to expire so that it's reclaimed by the lease janitor.
+func (b *backend) retryMsg(rc redis.Conn, msg *DBMsg, token queue.WorkerToken, msgJSON string) {
+	msg.workerToken = token
+	err := b.deferMsg(rc, msg, msgJSON, time.Now().Add(channelRetryDelay))
+	if err != nil {
+		logrus.WithError(err).WithField("msg_id", msg.ID_).Error("error pushing back message whose channel couldn't be loaded")
+	}
+}
 
-	return nil, nil
+// expireMsg fails a message which wasn't sent before the end of its send window rather than sending it late
+func (b *backend) expireMsg(ctx context.Context, msg *DBMsg, notAfter time.Time) {
+	log := logrus.WithField("msg_id", msg.ID_).WithField("not_after", notAfter)
+
+	status := b.NewMsgStatusForID(msg.channel, msg.ID_, courier.MsgFailed)
+	status.AddLog(courier.NewChannelLogFromError("Message Expired", msg.channel, msg.ID_, 0,
+		fmt.Errorf("message not sent before it expired at %s", notAfter.In(time.UTC).Format(time.RFC3339))))
+
+	err := b.WriteMsgStatus(ctx, status)
+	if err != nil {
+		log.WithError(err).Error("error failing expired message")
+	} else {
+		log.Warn("failed message which expired before it could be sent")
+	}
 }
 
-var luaSent = redis.NewScript(3,
-	`-- KEYS: [TodayKey, YesterdayKey, MsgID]
-     local found = redis.call("sismember", KEYS[1], KEYS[3])
+var luaSent = redis.NewScript(2,
+	`-- KEYS: [TodayKey, YesterdayKey] ARGV: [MsgID]
+     local found = redis.call("sismember", KEYS[1], ARGV[1])
      if found == 1 then
 	   return 1
      end
 
-     return redis.call("sismember", KEYS[2], KEYS[3])
+     return redis.call("sismember", KEYS[2], ARGV[1])
 `)
 
 // WasMsgSent returns whether the passed in message has already been sent
@@ -200,23 +310,23 @@ func (b *backend) WasMsgSent(ctx context.Context, id courier.MsgID) (bool, error
 	rc := b.redisPool.Get()
 	defer rc.Close()
 
-	todayKey := fmt.Sprintf(sentSetName, time.Now().UTC().Format("2006_01_02"))
-	yesterdayKey := fmt.Sprintf(sentSetName, time.Now().Add(time.Hour*-24).UTC().Format("2006_01_02"))
+	todayKey := utils.RedisKey(sentSetName, time.Now().UTC().Format("2006_01_02"))
+	yesterdayKey := utils.RedisKey(sentSetName, time.Now().Add(time.Hour*-24).UTC().Format("2006_01_02"))
 	return redis.Bool(luaSent.Do(rc, todayKey, yesterdayKey, id.String()))
 }
 
-var luaClearSent = redis.NewScript(3,
-	`-- KEYS: [TodayKey, YesterdayKey, MsgID]
-	 redis.call("srem", KEYS[1], KEYS[3])
-     redis.call("srem", KEYS[2], KEYS[3])
+var luaClearSent = redis.NewScript(2,
+	`-- KEYS: [TodayKey, YesterdayKey] ARGV: [MsgID]
+	 redis.call("srem", KEYS[1], ARGV[1])
+     redis.call("srem", KEYS[2], ARGV[1])
 `)
 
 func (b *backend) ClearMsgSent(ctx context.Context, id courier.MsgID) error {
 	rc := b.redisPool.Get()
 	defer rc.Close()
 
-	todayKey := fmt.Sprintf(sentSetName, time.Now().UTC().Format("2006_01_02"))
-	yesterdayKey := fmt.Sprintf(sentSetName, time.Now().Add(time.Hour*-24).UTC().Format("2006_01_02"))
+	todayKey := utils.RedisKey(sentSetName, time.Now().UTC().Format("2006_01_02"))
+	yesterdayKey := utils.RedisKey(sentSetName, time.Now().Add(time.Hour*-24).UTC().Format("2006_01_02"))
 	_, err := luaClearSent.Do(rc, todayKey, yesterdayKey, id.String())
 	return err
 }
@@ -232,7 +342,7 @@ func (b *backend) MarkOutgoingMsgComplete(ctx context.Context, msg courier.Msg,
 
 	// mark as sent in redis as well if this was actually wired or sent
 	if status != nil && (status.Status() == courier.MsgSent || status.Status() == courier.MsgWired) {
-		dateKey := fmt.Sprintf(sentSetName, time.Now().UTC().Format("2006_01_02"))
+		dateKey := utils.RedisKey(sentSetName, time.Now().UTC().Format("2006_01_02"))
 		rc.Send("sadd", dateKey, msg.ID().String())
 		rc.Send("expire", dateKey, 60*60*24*2)
 		_, err := rc.Do("")
@@ -295,6 +405,13 @@ func (b *backend) WriteMsgStatus(ctx context.Context, status courier.MsgStatus)
 			return errors.Wrap(err, "error updating contact URN")
 		}
 	}
+
+	// messages sent in several parts have their parts recorded, and statuses for those parts routed to the message
+	err := b.writeMsgPartStatus(ctx, status.(*DBMsgStatus))
+	if err != nil {
+		logrus.WithError(err).WithField("channel_uuid", status.ChannelUUID()).Error("error handling msg parts")
+	}
+
 	// if we have an ID, we can have our batch commit for us
 	if status.ID() != courier.NilMsgID {
 		b.statusCommitter.Queue(status.(*DBMsgStatus))
@@ -306,6 +423,15 @@ func (b *backend) WriteMsgStatus(ctx context.Context, status courier.MsgStatus)
 		}
 	}
 
+	// tally any cost reported by the provider for our analytics, providers can report it in several statuses for the
+	// same message so it's only tallied the first time
+	if status.Cost() != nil && b.isFirstCost(status) {
+		channel, err := b.GetChannel(ctx, courier.AnyChannelType, status.ChannelUUID())
+		if err == nil {
+			b.costs.add(channel.(*DBChannel), status.Cost())
+		}
+	}
+
 	// if we have an id and are marking an outgoing msg as errored, then clear our sent flag
 	if status.ID() != courier.NilMsgID && status.Status() == courier.MsgErrored {
 		err := b.ClearMsgSent(ctx, status.ID())
@@ -317,6 +443,67 @@ func (b *backend) WriteMsgStatus(ctx context.Context, status courier.MsgStatus)
 	return nil
 }
 
+var luaCosted = redis.NewScript(2,
+	`-- KEYS: [TodayKey, YesterdayKey] ARGV: [Member]
+	if redis.call("sismember", KEYS[1], ARGV[1]) == 1 or redis.call("sismember", KEYS[2], ARGV[1]) == 1 then
+		return 0
+	end
+
+	redis.call("sadd", KEYS[1], ARGV[1])
+	redis.call("expire", KEYS[1], 60*60*24*2)
+	return 1
+`)
+
+// isFirstCost returns whether the passed in status is the first to report a cost for its message
+func (b *backend) isFirstCost(status courier.MsgStatus) bool {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	// statuses for messages we only know the external id of are tracked by that instead
+	member := status.ID().String()
+	if status.ID() == courier.NilMsgID {
+		member = fmt.Sprintf("%s|%s", status.ChannelUUID(), status.ExternalID())
+	}
+
+	todayKey := utils.RedisKey(costedSetName, time.Now().UTC().Format("2006_01_02"))
+	yesterdayKey := utils.RedisKey(costedSetName, time.Now().Add(time.Hour*-24).UTC().Format("2006_01_02"))
+	first, err := redis.Bool(luaCosted.Do(rc, todayKey, yesterdayKey, member))
+	if err != nil {
+		logrus.WithError(err).WithField("channel_uuid", status.ChannelUUID()).Error("error checking whether message cost was already tallied")
+		return false
+	}
+	return first
+}
+
+// writeMsgPartStatus records the parts of a multipart message as it is sent, or if the passed in status is for one of
+// those parts, updates it to be for the message with a status aggregated across its parts
+func (b *backend) writeMsgPartStatus(ctx context.Context, status *DBMsgStatus) error {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	if status.ID_ != courier.NilMsgID {
+		if len(status.PartIDs_) > 1 {
+			return writeMsgParts(rc, status)
+		}
+		return nil
+	}
+
+	if status.ExternalID_ == "" {
+		return nil
+	}
+
+	parts, err := routeMsgPartStatus(rc, status)
+	if err != nil || parts == nil {
+		return err
+	}
+
+	channel, err := b.GetChannel(ctx, courier.AnyChannelType, status.ChannelUUID_)
+	if err != nil {
+		return err
+	}
+	return b.WriteChannelLogs(ctx, []*courier.ChannelLog{newMsgPartsLog(channel, status, parts)})
+}
+
 // updateContactURN updates contact URN according to the old/new URNs from status
 func (b *backend) updateContactURN(ctx context.Context, status courier.MsgStatus) error {
 	old, new := status.UpdatedURN()
@@ -447,11 +634,11 @@ func (b *backend) Heartbeat() error {
 	rc := b.redisPool.Get()
 	defer rc.Close()
 
-	active, err := redis.Strings(rc.Do("ZRANGE", fmt.Sprintf("%s:active", msgQueueName), "0", "-1"))
+	active, err := redis.Strings(rc.Do("ZRANGE", utils.RedisKey("%s:active", msgQueueName), "0", "-1"))
 	if err != nil {
 		return errors.Wrapf(err, "error getting active queues")
 	}
-	throttled, err := redis.Strings(rc.Do("ZRANGE", fmt.Sprintf("%s:throttled", msgQueueName), "0", "-1"))
+	throttled, err := redis.Strings(rc.Do("ZRANGE", utils.RedisKey("%s:throttled", msgQueueName), "0", "-1"))
 	if err != nil {
 		return errors.Wrapf(err, "error getting throttled queues")
 	}
@@ -475,39 +662,45 @@ func (b *backend) Heartbeat() error {
 		bulkSize += count
 	}
 
-	// get our DB and redis stats
-	dbStats := b.db.Stats()
-	redisStats := b.redisPool.Stats()
+	// get our DB and redis stats, reporting each of our DB pools separately
+	fields := b.dbReporter.report(b.db)
+	if b.reader.replica != nil {
+		for k, v := range b.replicaReporter.report(b.reader.replica) {
+			fields[k] = v
+		}
+
+		fallbacks := b.reader.takeFallbacks()
+		analytics.Gauge("courier.db_replica_fallbacks", float64(fallbacks))
+		fields["db_replica_fallbacks"] = fallbacks
+	}
 
-	dbWaitDurationInPeriod := dbStats.WaitDuration - b.dbWaitDuration
-	dbWaitCountInPeriod := dbStats.WaitCount - b.dbWaitCount
+	redisStats := b.redisPool.Stats()
 	redisWaitDurationInPeriod := redisStats.WaitDuration - b.redisWaitDuration
 	redisWaitCountInPeriod := redisStats.WaitCount - b.redisWaitCount
 
-	b.dbWaitDuration = dbStats.WaitDuration
-	b.dbWaitCount = dbStats.WaitCount
 	b.redisWaitDuration = redisStats.WaitDuration
 	b.redisWaitCount = redisStats.WaitCount
 
-	analytics.Gauge("courier.db_busy", float64(dbStats.InUse))
-	analytics.Gauge("courier.db_idle", float64(dbStats.Idle))
-	analytics.Gauge("courier.db_wait_ms", float64(dbWaitDurationInPeriod/time.Millisecond))
-	analytics.Gauge("courier.db_wait_count", float64(dbWaitCountInPeriod))
 	analytics.Gauge("courier.redis_wait_ms", float64(redisWaitDurationInPeriod/time.Millisecond))
 	analytics.Gauge("courier.redis_wait_count", float64(redisWaitCountInPeriod))
 	analytics.Gauge("courier.bulk_queue", float64(bulkSize))
 	analytics.Gauge("courier.priority_queue", float64(prioritySize))
+	b.costs.report()
 
-	logrus.WithFields(logrus.Fields{
-		"db_busy":          dbStats.InUse,
-		"db_idle":          dbStats.Idle,
-		"db_wait_time":     dbWaitDurationInPeriod,
-		"db_wait_count":    dbWaitCountInPeriod,
-		"redis_wait_time":  dbWaitDurationInPeriod,
-		"redis_wait_count": dbWaitCountInPeriod,
-		"priority_size":    prioritySize,
-		"bulk_size":        bulkSize,
-	}).Info("current analytics")
+	// and the stats of our committers
+	for k, v := range b.statusCommitterReporter.report(b.statusCommitter) {
+		fields[k] = v
+	}
+	for k, v := range b.logCommitterReporter.report(b.logCommitter) {
+		fields[k] = v
+	}
+
+	fields["redis_wait_time"] = redisWaitDurationInPeriod
+	fields["redis_wait_count"] = redisWaitCountInPeriod
+	fields["priority_size"] = prioritySize
+	fields["bulk_size"] = bulkSize
+
+	logrus.WithFields(fields).Info("current analytics")
 
 	return nil
 }
@@ -517,17 +710,25 @@ func (b *backend) Status() string {
 	rc := b.redisPool.Get()
 	defer rc.Close()
 
+	// get the live worker leases for each queue, which should match their worker counts
+	leases, err := queue.GetLeaseCounts(rc, msgQueueName)
+	if err != nil {
+		return fmt.Sprintf("unable to read worker leases: %v", err)
+	}
+	anomalies := bytes.Buffer{}
+	holds := bytes.Buffer{}
+
 	status := bytes.Buffer{}
-	status.WriteString("------------------------------------------------------------------------------------\n")
-	status.WriteString("     Size | Bulk Size | Workers | TPS | Type | Channel              \n")
-	status.WriteString("------------------------------------------------------------------------------------\n")
+	status.WriteString("----------------------------------------------------------------------------------------------\n")
+	status.WriteString("     Size | Bulk Size | Workers |  Leases | TPS | Type | Channel              \n")
+	status.WriteString("----------------------------------------------------------------------------------------------\n")
 
 	var queue string
 	var workers float64
 
 	// get all our queues
-	rc.Send("zrevrangebyscore", fmt.Sprintf("%s:active", msgQueueName), "+inf", "-inf", "withscores")
-	rc.Send("zrevrangebyscore", fmt.Sprintf("%s:throttled", msgQueueName), "+inf", "-inf", "withscores")
+	rc.Send("zrevrangebyscore", utils.RedisKey("%s:active", msgQueueName), "+inf", "-inf", "withscores")
+	rc.Send("zrevrangebyscore", utils.RedisKey("%s:throttled", msgQueueName), "+inf", "-inf", "withscores")
 	rc.Flush()
 
 	active, err := redis.Values(rc.Receive())
@@ -545,9 +746,10 @@ func (b *backend) Status() string {
 		if err != nil {
 			return fmt.Sprintf("error reading active queues: %v", err)
 		}
+		queueLeases := leases[queue]
 
 		// our queue name is in the format msgs:uuid|tps, break it apart
-		queue = strings.TrimPrefix(queue, "msgs:")
+		queue = strings.TrimPrefix(queue, utils.RedisKey("%s:", msgQueueName))
 		parts := strings.Split(queue, "|")
 		if len(parts) != 2 {
 			return fmt.Sprintf("error parsing queue name '%s'", queue)
@@ -557,25 +759,64 @@ func (b *backend) Status() string {
 
 		// try to look up our channel
 		channelUUID, _ := courier.NewChannelUUID(uuid)
-		channel, err := getChannel(context.Background(), b.db, courier.AnyChannelType, channelUUID)
+		channel, err := getChannel(context.Background(), b.reader, courier.AnyChannelType, channelUUID)
 		channelType := "!!"
 		if err == nil {
 			channelType = channel.ChannelType().String()
 		}
 
+		// check whether our bulk queue is being held
+		hold, err := redis.String(rc.Do("GET", utils.RedisKey("rate_limit_bulk:%s", uuid)))
+		if err == nil {
+			holds.WriteString(fmt.Sprintf("%s bulk held: %s\n", uuid, describeBulkHold(channel, hold, time.Now())))
+		}
+
 		// get # of items in our normal queue
-		size, err := redis.Int64(rc.Do("ZCARD", fmt.Sprintf("%s:%s/1", msgQueueName, queue)))
+		size, err := redis.Int64(rc.Do("ZCARD", utils.RedisKey("%s:%s/1", msgQueueName, queue)))
 		if err != nil {
 			return fmt.Sprintf("error reading queue size: %v", err)
 		}
 
 		// get # of items in the bulk queue
-		bulkSize, err := redis.Int64(rc.Do("ZCARD", fmt.Sprintf("%s:%s/0", msgQueueName, queue)))
+		bulkSize, err := redis.Int64(rc.Do("ZCARD", utils.RedisKey("%s:%s/0", msgQueueName, queue)))
 		if err != nil {
 			return fmt.Sprintf("error reading bulk queue size: %v", err)
 		}
 
-		status.WriteString(fmt.Sprintf("% 9d   % 9d   % 7d   % 3s   % 4s   %s\n", size, bulkSize, int(workers), tps, channelType, uuid))
+		status.WriteString(fmt.Sprintf("% 9d   % 9d   % 7d   % 7d   % 3s   % 4s   %s\n", size, bulkSize, int(workers), queueLeases, tps, channelType, uuid))
+
+		// workers without leases have leaked and will be corrected by the lease janitor
+		if int(workers) != queueLeases {
+			anomalies.WriteString(fmt.Sprintf("%s has %d workers but %d live leases\n", uuid, int(workers), queueLeases))
+		}
+	}
+
+	if b.leaseJanitor != nil {
+		status.WriteString(fmt.Sprintf("\nLeases reclaimed: %d, queues corrected: %d\n", b.leaseJanitor.Reclaimed(), b.leaseJanitor.Corrected()))
+	}
+	if holds.Len() > 0 {
+		status.WriteString("\nBulk holds:\n")
+		status.WriteString(holds.String())
+	}
+	schedulingStatus, err := getSchedulingStatus(rc)
+	if err != nil {
+		status.WriteString(fmt.Sprintf("\nunable to read org loads: %v\n", err))
+	} else {
+		status.WriteString(schedulingStatus)
+	}
+	if anomalies.Len() > 0 {
+		status.WriteString("\nLease anomalies:\n")
+		status.WriteString(anomalies.String())
+	}
+
+	if b.reconciler != nil {
+		if report := b.reconciler.lastReport(); report != nil {
+			mode := ""
+			if report.DryRun {
+				mode = " (dry run)"
+			}
+			status.WriteString(fmt.Sprintf("\nLast reconciliation%s: %s, checked: %d, missing: %d, requeued: %d\n", mode, report.RanOn.Format(time.RFC3339), report.Checked, report.Missing, report.Requeued))
+		}
 	}
 
 	return status.String()
@@ -601,17 +842,11 @@ func (b *backend) Start() error {
 	}
 
 	// build our db
-	db, err := sqlx.Open("postgres", b.config.DB)
+	b.db, err = openDB(b.config.DB, b.config.DBMaxConns)
 	if err != nil {
-		return fmt.Errorf("unable to open DB with config: '%s': %s", b.config.DB, err)
+		return err
 	}
-
-	// configure our pool
-	b.db = db
-	b.db.SetMaxIdleConns(4)
-	b.db.SetMaxOpenConns(16)
-	b.db.SetConnMaxIdleTime(time.Minute * 10)
-	b.db.SetConnMaxLifetime(time.Minute * 60)
+	b.reader = &replicaRouter{primary: b.db}
 
 	// try connecting
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -623,39 +858,43 @@ func (b *backend) Start() error {
 		log.Info("db ok")
 	}
 
+	// build our read replica if we have one, read only lookups go to it first
+	if b.config.DBReplica != "" {
+		replicaURL, err := url.Parse(b.config.DBReplica)
+		if err != nil || replicaURL.Scheme != "postgres" {
+			return fmt.Errorf("invalid DB replica URL: '%s', only postgres is supported", b.config.DBReplica)
+		}
+
+		b.reader.replica, err = openDB(b.config.DBReplica, b.config.DBReplicaMaxConns)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err = b.reader.replica.PingContext(ctx)
+		cancel()
+		if err != nil {
+			log.WithError(err).Error("db replica not reachable")
+		} else {
+			log.Info("db replica ok")
+		}
+	}
+
 	// parse and test our redis config
-	redisURL, err := url.Parse(b.config.Redis)
+	redisURL, err := utils.ParseRedisURL(b.config.Redis)
 	if err != nil {
 		return fmt.Errorf("unable to parse Redis URL '%s': %s", b.config.Redis, err)
 	}
 
-	// create our pool
-	redisPool := &redis.Pool{
-		Wait:        true,              // makes callers wait for a connection
-		MaxActive:   36,                // only open this many concurrent connections at once
-		MaxIdle:     4,                 // only keep up to this many idle
-		IdleTimeout: 240 * time.Second, // how long to wait before reaping a connection
-		Dial: func() (redis.Conn, error) {
-			conn, err := redis.Dial("tcp", redisURL.Host)
-			if err != nil {
-				return nil, err
-			}
-
-			// send auth if required
-			if redisURL.User != nil {
-				pass, authRequired := redisURL.User.Password()
-				if authRequired {
-					if _, err := conn.Do("AUTH", pass); err != nil {
-						conn.Close()
-						return nil, err
-					}
-				}
-			}
+	// keys must be prefixed before we connect, as in a cluster the prefix decides which node we connect to
+	if err := utils.SetRedisKeyPrefix(b.config.RedisPrefix, redisURL.Cluster); err != nil {
+		return err
+	}
 
-			// switch to the right DB
-			_, err = conn.Do("SELECT", strings.TrimLeft(redisURL.Path, "/"))
-			return conn, err
-		},
+	// create our pool, which makes callers wait for one of up to 36 concurrent connections and keeps up to 4 idle
+	redisPool, err := utils.NewRedisPool(b.config.Redis, 36, 4)
+	if err != nil {
+		return fmt.Errorf("unable to create Redis pool: %s", err)
 	}
 	b.redisPool = redisPool
 
@@ -669,9 +908,36 @@ func (b *backend) Start() error {
 		log.Info("redis ok")
 	}
 
-	// start our dethrottler if we are going to be doing some sending
+	// start our dethrottler if we are going to be doing some sending, only one sending instance at a time needs to run it
 	if b.config.MaxWorkers > 0 {
-		queue.StartDethrottler(redisPool, b.stopChan, b.waitGroup, msgQueueName)
+		var dethrottlerStop chan bool
+		startDethrottler := func() {
+			dethrottlerStop = make(chan bool)
+			queue.StartDethrottler(redisPool, dethrottlerStop, b.waitGroup, msgQueueName)
+		}
+		stopDethrottler := func() { close(dethrottlerStop) }
+
+		election := courier.NewLeaderElection(redisPool, "dethrottler", dethrottlerLeaderTTL, startDethrottler, stopDethrottler)
+		election.Start(b.stopChan, b.waitGroup)
+
+		b.leaseJanitor = queue.StartLeaseJanitor(redisPool, b.stopChan, b.waitGroup, msgQueueName)
+
+		// configure how we share senders between orgs and priorities
+		queue.Scheduling = queue.Scheduler{
+			OrgSample:          b.config.SchedulingOrgSample,
+			HighPriorityWeight: b.config.HighPriorityWeight,
+			BulkWeight:         b.config.BulkPriorityWeight,
+			BulkMaxWait:        time.Second * time.Duration(b.config.BulkMaxWait),
+		}
+
+		// and hold bulk queues outside the sending hours of their channels
+		b.startSendingHours(b.stopChan, b.waitGroup)
+	}
+
+	// start looking for queued messages lost from redis if enabled
+	if b.config.ReconcileQueuedAfter > 0 {
+		b.reconciler = newReconciler(b, time.Minute*time.Duration(b.config.ReconcileQueuedAfter), b.config.ReconcileDryRun)
+		b.reconciler.start(b.stopChan, b.waitGroup)
 	}
 
 	// create our storage (S3 or file system)
@@ -711,6 +977,9 @@ func (b *backend) Start() error {
 	if err == nil {
 		err = courier.EnsureSpoolDirPresent(b.config.SpoolDir, "events")
 	}
+	if err == nil {
+		err = courier.EnsureSpoolDirPresent(b.config.SpoolDir, "logs")
+	}
 	if err != nil {
 		log.WithError(err).Error("spool directories not writable")
 	} else {
@@ -729,6 +998,11 @@ func (b *backend) Start() error {
 
 			log.WithError(err).Error("error writing status")
 
+			// statuses the DB refused would only be refused again, so only spool those it never got to see
+			if batch.IsRejected(err) {
+				return
+			}
+
 			err = courier.WriteToSpool(b.config.SpoolDir, "statuses", value)
 			if err != nil {
 				logrus.WithField("comp", "status committer").WithError(err).Error("error writing status to spool")
@@ -747,6 +1021,16 @@ func (b *backend) Start() error {
 			}
 
 			log.WithError(err).Error("error writing channel log")
+
+			// logs the DB refused would only be refused again, so only spool those it never got to see
+			if batch.IsRejected(err) {
+				return
+			}
+
+			err = courier.WriteToSpool(b.config.SpoolDir, "logs", value)
+			if err != nil {
+				logrus.WithField("comp", "log committer").WithError(err).Error("error writing channel log to spool")
+			}
 		})
 	b.logCommitter.Start()
 
@@ -754,6 +1038,7 @@ func (b *backend) Start() error {
 	courier.RegisterFlusher(path.Join(b.config.SpoolDir, "msgs"), b.flushMsgFile)
 	courier.RegisterFlusher(path.Join(b.config.SpoolDir, "statuses"), b.flushStatusFile)
 	courier.RegisterFlusher(path.Join(b.config.SpoolDir, "events"), b.flushChannelEventFile)
+	courier.RegisterFlusher(path.Join(b.config.SpoolDir, "logs"), b.flushChannelLogFile)
 
 	logrus.WithFields(logrus.Fields{
 		"comp":  "backend",
@@ -791,9 +1076,36 @@ func (b *backend) Cleanup() error {
 	if b.db != nil {
 		b.db.Close()
 	}
+	if b.reader != nil && b.reader.replica != nil {
+		b.reader.replica.Close()
+	}
 	return b.redisPool.Close()
 }
 
+// GetDeadLetters returns the outgoing queue entries which couldn't be sent, newest first
+func (b *backend) GetDeadLetters(ctx context.Context) ([]*queue.DeadLetter, error) {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	return queue.GetDeadLetters(rc, msgQueueName)
+}
+
+// ReplayDeadLetter puts the dead letter with the passed in UUID back onto its queue
+func (b *backend) ReplayDeadLetter(ctx context.Context, uuid string) (*queue.DeadLetter, error) {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	return queue.ReplayDeadLetter(rc, msgQueueName, uuid)
+}
+
+// DiscardDeadLetter removes the dead letter with the passed in UUID
+func (b *backend) DiscardDeadLetter(ctx context.Context, uuid string) (*queue.DeadLetter, error) {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	return queue.RemoveDeadLetter(rc, msgQueueName, uuid)
+}
+
 func (b *backend) GetActivePurges(ctx context.Context) ([]string, error) {
 	rc := b.RedisPool().Get()
 	defer rc.Close()
@@ -889,6 +1201,13 @@ func newBackend(config *courier.Config) courier.Backend {
 		waitGroup: &sync.WaitGroup{},
 
 		committerWG: &sync.WaitGroup{},
+
+		costs: newCostTotals(),
+
+		dbReporter:              &dbPoolReporter{name: "db"},
+		replicaReporter:         &dbPoolReporter{name: "db_replica"},
+		statusCommitterReporter: &committerReporter{name: "status_committer"},
+		logCommitterReporter:    &committerReporter{name: "log_committer"},
 	}
 }
 
@@ -900,15 +1219,29 @@ type backend struct {
 	committerWG     *sync.WaitGroup
 
 	db        *sqlx.DB
+	reader    *replicaRouter
 	redisPool *redis.Pool
 	storage   storage.Storage
 
 	stopChan  chan bool
 	waitGroup *sync.WaitGroup
 
+	// reclaims the workers of leases which have expired, only started if we are sending
+	leaseJanitor *queue.LeaseJanitor
+
+	// looks for queued messages lost from redis, only started if enabled
+	reconciler *reconciler
+
 	// both sqlx and redis provide wait stats which are cummulative that we need to convert into increments
-	dbWaitDuration    time.Duration
-	dbWaitCount       int64
+	dbReporter        *dbPoolReporter
+	replicaReporter   *dbPoolReporter
 	redisWaitDuration time.Duration
 	redisWaitCount    int64
+
+	// our committers provide cumulative counts which we also convert into increments
+	statusCommitterReporter *committerReporter
+	logCommitterReporter    *committerReporter
+
+	// the costs reported by providers since our last heartbeat
+	costs *costTotals
 }
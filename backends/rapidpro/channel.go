@@ -3,21 +3,20 @@ package rapidpro
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/utils"
+	"github.com/pkg/errors"
 )
 
 // getChannel will look up the channel with the passed in UUID and channel type.
 // It will return an error if the channel does not exist or is not active.
-func getChannel(ctx context.Context, db *sqlx.DB, channelType courier.ChannelType, channelUUID courier.ChannelUUID) (*DBChannel, error) {
+func getChannel(ctx context.Context, db dbReader, channelType courier.ChannelType, channelUUID courier.ChannelUUID) (*DBChannel, error) {
 	// look for the channel locally
 	cachedChannel, localErr := getCachedChannel(channelType, channelUUID)
 
@@ -32,7 +31,7 @@ func getChannel(ctx context.Context, db *sqlx.DB, channelType courier.ChannelTyp
 	// if it wasn't found in the DB, clear our cache and return that it wasn't found
 	if dbErr == courier.ErrChannelNotFound {
 		clearLocalChannel(channelUUID)
-		return cachedChannel, fmt.Errorf("unable to find channel with type: %s and uuid: %s", channelType.String(), channelUUID.String())
+		return cachedChannel, errors.Wrapf(courier.ErrChannelNotFound, "unable to find channel with type: %s and uuid: %s", channelType.String(), channelUUID.String())
 	}
 
 	// if we had some other db error, return it if our cached channel was only just expired
@@ -71,7 +70,7 @@ WHERE
 	ch.org_id IS NOT NULL`
 
 // ChannelForUUID attempts to look up the channel with the passed in UUID, returning it
-func loadChannelFromDB(ctx context.Context, db *sqlx.DB, channelType courier.ChannelType, uuid courier.ChannelUUID) (*DBChannel, error) {
+func loadChannelFromDB(ctx context.Context, db dbReader, channelType courier.ChannelType, uuid courier.ChannelUUID) (*DBChannel, error) {
 	channel := &DBChannel{UUID_: uuid}
 
 	// select just the fields we need
@@ -96,6 +95,38 @@ func loadChannelFromDB(ctx context.Context, db *sqlx.DB, channelType courier.Cha
 	return channel, nil
 }
 
+const lookupChannelsFromTypeSQL = `
+SELECT 
+	org_id, 
+	ch.id as id, 
+	ch.uuid as uuid, 
+	ch.name as name, 
+	channel_type, schemes, 
+	address, role,
+	ch.country as country, 
+	ch.config as config, 
+	org.config as org_config, 
+	org.is_anon as org_is_anon
+FROM 
+	channels_channel ch
+	JOIN orgs_org org on ch.org_id = org.id
+WHERE 
+	ch.channel_type = $1 AND 
+	ch.is_active = true AND 
+	ch.org_id IS NOT NULL
+ORDER BY
+	ch.id`
+
+// loadChannelsByTypeFromDB loads all the active channels with the passed in type
+func loadChannelsByTypeFromDB(ctx context.Context, db dbReader, channelType courier.ChannelType) ([]*DBChannel, error) {
+	channels := make([]*DBChannel, 0)
+	err := db.SelectContext(ctx, &channels, lookupChannelsFromTypeSQL, channelType)
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
 // getCachedChannel returns a Channel object for the passed in type and UUID.
 func getCachedChannel(channelType courier.ChannelType, uuid courier.ChannelUUID) (*DBChannel, error) {
 	// first see if the channel exists in our local cache
@@ -142,7 +173,7 @@ var channelCache = make(map[courier.ChannelUUID]*DBChannel)
 
 // getChannelByAddress will look up the channel with the passed in address and channel type.
 // It will return an error if the channel does not exist or is not active.
-func getChannelByAddress(ctx context.Context, db *sqlx.DB, channelType courier.ChannelType, address courier.ChannelAddress) (*DBChannel, error) {
+func getChannelByAddress(ctx context.Context, db dbReader, channelType courier.ChannelType, address courier.ChannelAddress) (*DBChannel, error) {
 	// look for the channel locally
 	cachedChannel, localErr := getCachedChannelByAddress(channelType, address)
 
@@ -157,7 +188,7 @@ func getChannelByAddress(ctx context.Context, db *sqlx.DB, channelType courier.C
 	// if it wasn't found in the DB, clear our cache and return that it wasn't found
 	if dbErr == courier.ErrChannelNotFound {
 		clearLocalChannelByAddress(address)
-		return cachedChannel, fmt.Errorf("unable to find channel with type: %s and address: %s", channelType.String(), address.String())
+		return cachedChannel, errors.Wrapf(courier.ErrChannelNotFound, "unable to find channel with type: %s and address: %s", channelType.String(), address.String())
 	}
 
 	// if we had some other db error, return it if our cached channel was only just expired
@@ -196,7 +227,7 @@ WHERE
        ch.org_id IS NOT NULL`
 
 // loadChannelByAddressFromDB get the channel with the passed in channel type and address from the DB, returning it
-func loadChannelByAddressFromDB(ctx context.Context, db *sqlx.DB, channelType courier.ChannelType, address courier.ChannelAddress) (*DBChannel, error) {
+func loadChannelByAddressFromDB(ctx context.Context, db dbReader, channelType courier.ChannelType, address courier.ChannelAddress) (*DBChannel, error) {
 	channel := &DBChannel{Address_: sql.NullString{String: address.String(), Valid: address == courier.NilChannelAddress}}
 
 	// select just the fields we need
@@ -365,6 +396,9 @@ func (c *DBChannel) ConfigForKey(key string, defaultValue interface{}) interface
 	return value
 }
 
+// Config returns the whole config of this channel
+func (c *DBChannel) Config() map[string]interface{} { return c.Config_.Map }
+
 // OrgConfigForKey returns the org config value for the passed in key, or defaultValue if it isn't found
 func (c *DBChannel) OrgConfigForKey(key string, defaultValue interface{}) interface{} {
 	// no value, return our default value
@@ -0,0 +1,141 @@
+package rapidpro
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/nyaruka/courier/batch"
+	"github.com/nyaruka/gocommon/analytics"
+	"github.com/sirupsen/logrus"
+)
+
+// dbReader is what we run read only lookups against, either a DB or our replica router
+type dbReader interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// replicaRouter sends read only lookups to our replica if we have one. Lookups fall back to the primary if the replica
+// errors, or if it doesn't find a row as that may just be because the replica is lagging behind the primary.
+type replicaRouter struct {
+	primary   *sqlx.DB
+	replica   *sqlx.DB
+	fallbacks int64
+}
+
+// GetContext gets a single row, from our replica if possible
+func (r *replicaRouter) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if r.replica != nil {
+		err := r.replica.GetContext(ctx, dest, query, args...)
+		if err == nil {
+			return nil
+		}
+		r.fallback(err)
+	}
+	return r.primary.GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext selects multiple rows, from our replica if possible
+func (r *replicaRouter) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if r.replica != nil {
+		err := r.replica.SelectContext(ctx, dest, query, args...)
+		if err == nil {
+			return nil
+		}
+		r.fallback(err)
+	}
+	return r.primary.SelectContext(ctx, dest, query, args...)
+}
+
+func (r *replicaRouter) fallback(err error) {
+	atomic.AddInt64(&r.fallbacks, 1)
+
+	if err != sql.ErrNoRows {
+		logrus.WithError(err).Warn("error reading from DB replica, falling back to primary")
+	}
+}
+
+// takeFallbacks returns the number of lookups which have fallen back to the primary since it was last called
+func (r *replicaRouter) takeFallbacks() int64 {
+	return atomic.SwapInt64(&r.fallbacks, 0)
+}
+
+// openDB opens and configures a DB pool with the passed in number of max open connections
+func openDB(dsn string, maxOpenConns int) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open DB with config: '%s': %s", dsn, err)
+	}
+
+	db.SetMaxIdleConns(4)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetConnMaxIdleTime(time.Minute * 10)
+	db.SetConnMaxLifetime(time.Minute * 60)
+	return db, nil
+}
+
+// dbPoolReporter reports the stats of a DB pool, with wait times and counts being for the period since the last report
+type dbPoolReporter struct {
+	name         string
+	waitDuration time.Duration
+	waitCount    int64
+}
+
+func (r *dbPoolReporter) report(db *sqlx.DB) logrus.Fields {
+	stats := db.Stats()
+
+	waitDurationInPeriod := stats.WaitDuration - r.waitDuration
+	waitCountInPeriod := stats.WaitCount - r.waitCount
+
+	r.waitDuration = stats.WaitDuration
+	r.waitCount = stats.WaitCount
+
+	analytics.Gauge(fmt.Sprintf("courier.%s_busy", r.name), float64(stats.InUse))
+	analytics.Gauge(fmt.Sprintf("courier.%s_idle", r.name), float64(stats.Idle))
+	analytics.Gauge(fmt.Sprintf("courier.%s_wait_ms", r.name), float64(waitDurationInPeriod/time.Millisecond))
+	analytics.Gauge(fmt.Sprintf("courier.%s_wait_count", r.name), float64(waitCountInPeriod))
+
+	return logrus.Fields{
+		r.name + "_busy":       stats.InUse,
+		r.name + "_idle":       stats.Idle,
+		r.name + "_wait_time":  waitDurationInPeriod,
+		r.name + "_wait_count": waitCountInPeriod,
+	}
+}
+
+// committerReporter reports the stats of a batch committer, with counts being for the period since the last report
+type committerReporter struct {
+	name string
+	last batch.Stats
+}
+
+func (r *committerReporter) report(committer batch.Committer) logrus.Fields {
+	stats := committer.Stats()
+
+	committedInPeriod := stats.Committed - r.last.Committed
+	failedInPeriod := stats.Failed - r.last.Failed
+	overflowedInPeriod := stats.Overflowed - r.last.Overflowed
+	retriesInPeriod := stats.Retries - r.last.Retries
+
+	r.last = stats
+
+	analytics.Gauge(fmt.Sprintf("courier.%s_buffered", r.name), float64(stats.Buffered))
+	analytics.Gauge(fmt.Sprintf("courier.%s_flush_ms", r.name), float64(stats.FlushLatency/time.Millisecond))
+	analytics.Gauge(fmt.Sprintf("courier.%s_committed", r.name), float64(committedInPeriod))
+	analytics.Gauge(fmt.Sprintf("courier.%s_failed", r.name), float64(failedInPeriod))
+	analytics.Gauge(fmt.Sprintf("courier.%s_overflowed", r.name), float64(overflowedInPeriod))
+	analytics.Gauge(fmt.Sprintf("courier.%s_retries", r.name), float64(retriesInPeriod))
+
+	return logrus.Fields{
+		r.name + "_buffered":   stats.Buffered,
+		r.name + "_flush_time": stats.FlushLatency,
+		r.name + "_committed":  committedInPeriod,
+		r.name + "_failed":     failedInPeriod,
+		r.name + "_overflowed": overflowedInPeriod,
+		r.name + "_retries":    retriesInPeriod,
+	}
+}
@@ -0,0 +1,64 @@
+package rapidpro
+
+import (
+	"context"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/queue"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/sirupsen/logrus"
+)
+
+// deadLetterMsg moves an outgoing queue entry we can't send to our dead letters, and if we can tell which message it is,
+// fails that message so that it doesn't stay queued forever
+func (b *backend) deadLetterMsg(ctx context.Context, rc redis.Conn, token queue.WorkerToken, msgJSON string, reason string) {
+	value := []byte(msgJSON)
+
+	priority := queue.Priority(queue.LowPriority)
+	if highPriority, _ := jsonparser.GetBoolean(value, "high_priority"); highPriority {
+		priority = queue.HighPriority
+	}
+
+	letter := queue.NewDeadLetter(msgQueueName, token, priority, msgJSON, reason)
+	log := logrus.WithFields(logrus.Fields{"comp": "backend", "dead_letter": letter.UUID, "reason": reason})
+
+	err := queue.PushDeadLetter(rc, msgQueueName, letter)
+	if err != nil {
+		log.WithError(err).Error("error writing dead letter")
+	} else {
+		log.Error("moved undeliverable outgoing message to dead letters")
+	}
+
+	// we can only fail the message if we can extract its ID and channel
+	id, err := jsonparser.GetInt(value, "id")
+	if err != nil {
+		return
+	}
+	channelUUIDStr, err := jsonparser.GetString(value, "channel_uuid")
+	if err != nil {
+		return
+	}
+	channelUUID, err := courier.NewChannelUUID(channelUUIDStr)
+	if err != nil {
+		return
+	}
+	channelID, _ := jsonparser.GetInt(value, "channel_id")
+
+	status := &DBMsgStatus{
+		ChannelUUID_: channelUUID,
+		ChannelID_:   courier.NewChannelID(channelID),
+		ID_:          courier.NewMsgID(id),
+		OldURN_:      urns.NilURN,
+		NewURN_:      urns.NilURN,
+		Status_:      courier.MsgFailed,
+		ModifiedOn_:  time.Now().In(time.UTC),
+	}
+
+	err = writeMsgStatus(ctx, b, status)
+	if err != nil {
+		log.WithError(err).WithField("msg_id", id).Error("error failing dead lettered message")
+	}
+}
@@ -2,7 +2,10 @@ package rapidpro
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
 
 	"time"
 
@@ -49,6 +52,11 @@ func writeChannelLog(ctx context.Context, b *backend, log *courier.ChannelLog) e
 		log.Response += "\n\nError: " + log.Error
 	}
 
+	// if we know how many SMS segments were sent, append that to our request
+	if log.Segments > 0 {
+		log.Request += fmt.Sprintf("\n\nSegments: %d", log.Segments)
+	}
+
 	// strip null chars from request and response, postgres doesn't like that
 	log.Request = utils.CleanString(log.Request)
 	log.Response = utils.CleanString(log.Response)
@@ -72,3 +80,24 @@ func writeChannelLog(ctx context.Context, b *backend, log *courier.ChannelLog) e
 	b.logCommitter.Queue(v)
 	return nil
 }
+
+// flushChannelLogFile writes a channel log which couldn't be committed and was spooled to the database
+func (b *backend) flushChannelLogFile(filename string, contents []byte) error {
+	channelLog := &ChannelLog{}
+	err := json.Unmarshal(contents, channelLog)
+	if err != nil {
+		log.Printf("ERROR unmarshalling spool file '%s', renaming: %s\n", filename, err)
+		os.Rename(filename, fmt.Sprintf("%s.error", filename))
+		return nil
+	}
+
+	_, err = b.db.NamedExecContext(context.Background(), insertLogSQL, channelLog)
+
+	// if the DB is reachable then it refused our log and will keep doing so, so stop retrying it
+	if err != nil && b.db.PingContext(context.Background()) == nil {
+		log.Printf("ERROR writing spooled channel log '%s', renaming: %s\n", filename, err)
+		os.Rename(filename, fmt.Sprintf("%s.error", filename))
+		return nil
+	}
+	return err
+}
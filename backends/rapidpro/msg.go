@@ -61,6 +61,11 @@ func writeMsg(ctx context.Context, b *backend, msg courier.Msg) error {
 
 	channel := m.Channel()
 
+	// check this msg against our flood limits
+	if b.checkInboundFlood(m) {
+		return courier.ErrMsgDropped
+	}
+
 	// if we have media, go download it to S3
 	for i, attachment := range m.Attachments_ {
 		if strings.HasPrefix(attachment, "http") {
@@ -89,6 +94,40 @@ func writeMsg(ctx context.Context, b *backend, msg courier.Msg) error {
 	return err
 }
 
+// checkInboundFlood checks the passed in msg against our flood limits, flagging it if appropriate and returning
+// whether it should be dropped
+func (b *backend) checkInboundFlood(m *DBMsg) bool {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	check, err := courier.CheckInboundFlood(rc, b.config, m)
+	if err != nil {
+		logrus.WithError(err).WithField("msg", m.UUID().String()).Error("error checking flood limits")
+		return false
+	}
+	if check == nil {
+		return false
+	}
+
+	log := logrus.WithField("channel_uuid", m.Channel().UUID()).WithField("urn", m.URN().Identity()).WithField("msg", m.UUID().String()).WithField("reason", check.Reason)
+
+	if check.Action == courier.FloodActionFlag {
+		metadata := m.Metadata_
+		if metadata == nil {
+			metadata = json.RawMessage(`{}`)
+		}
+		flagged, err := jsonparser.Set(metadata, []byte(`true`), courier.FloodFlaggedKey)
+		if err == nil {
+			m.Metadata_ = flagged
+		}
+		log.Info("flagging incoming message over flood limits")
+		return false
+	}
+
+	log.WithField("action", check.Action).Warn("dropping incoming message over flood limits")
+	return true
+}
+
 // newMsg creates a new DBMsg object with the passed in parameters
 func newMsg(direction MsgDirection, channel courier.Channel, urn urns.URN, text string) *DBMsg {
 	now := time.Now()
@@ -123,9 +162,9 @@ func newMsg(direction MsgDirection, channel courier.Channel, urn urns.URN, text
 const insertMsgSQL = `
 INSERT INTO
 	msgs_msg(org_id, uuid, direction, text, attachments, msg_count, error_count, high_priority, status,
-             visibility, external_id, channel_id, contact_id, contact_urn_id, created_on, modified_on, next_attempt, queued_on, sent_on)
+             visibility, external_id, channel_id, contact_id, contact_urn_id, created_on, modified_on, next_attempt, queued_on, sent_on, metadata)
     VALUES(:org_id, :uuid, :direction, :text, :attachments, :msg_count, :error_count, :high_priority, :status,
-           :visibility, :external_id, :channel_id, :contact_id, :contact_urn_id, :created_on, :modified_on, :next_attempt, :queued_on, :sent_on)
+           :visibility, :external_id, :channel_id, :contact_id, :contact_urn_id, :created_on, :modified_on, :next_attempt, :queued_on, :sent_on, :metadata)
 RETURNING id
 `
 
@@ -168,6 +207,67 @@ func writeMsgToDB(ctx context.Context, b *backend, m *DBMsg) error {
 	return nil
 }
 
+// writeOutgoingMsg writes the passed in outgoing message to the database as queued and pushes it onto the queue of its
+// channel, so that it's sent in the same way as the messages RapidPro queues
+func writeOutgoingMsg(ctx context.Context, b *backend, m *DBMsg) error {
+	contact, err := contactForURN(ctx, b, m.OrgID_, m.channel, m.URN_, m.URNAuth_, m.ContactName_)
+	if err != nil {
+		return errors.Wrap(err, "error getting contact for message")
+	}
+
+	m.ContactID_ = contact.ID_
+	m.ContactURNID_ = contact.URNID_
+	m.Status_ = courier.MsgQueued
+
+	rows, err := b.db.NamedQueryContext(ctx, insertMsgSQL, m)
+	if err != nil {
+		return errors.Wrap(err, "error inserting message")
+	}
+	defer rows.Close()
+
+	rows.Next()
+	err = rows.Scan(&m.ID_)
+	if err != nil {
+		return errors.Wrap(err, "error scanning for inserted message id")
+	}
+
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	queues, err := queue.GetAllChannelQueues(rc, m.ChannelUUID_.String())
+	if err != nil {
+		return errors.Wrap(err, "error getting channel queues")
+	}
+
+	return errors.Wrap(pushOutgoingMsg(rc, b, m, queues), "error queueing message")
+}
+
+// pushOutgoingMsg pushes the passed in outgoing message onto the queue for its channel. Our queues are named uuid|tps,
+// so the tps of the channel's existing queue is used if it has one.
+func pushOutgoingMsg(rc redis.Conn, b *backend, m *DBMsg, queues []string) error {
+	tps := defaultQueueTPS
+	if len(queues) > 0 {
+		tps, _ = strconv.Atoi(queues[0][strings.LastIndex(queues[0], "|")+1:])
+	} else {
+		channel, err := b.GetChannel(context.Background(), courier.AnyChannelType, m.ChannelUUID_)
+		if err == nil {
+			tps = channel.IntConfigForKey(courier.ConfigMaxTPS, defaultQueueTPS)
+		}
+	}
+
+	priority := queue.Priority(queue.LowPriority)
+	if m.HighPriority_ {
+		priority = queue.HighPriority
+	}
+
+	msgJSON, err := json.Marshal([]interface{}{m})
+	if err != nil {
+		return err
+	}
+
+	return queue.PushOntoQueue(rc, msgQueueName, m.ChannelUUID_.String(), tps, string(msgJSON), priority)
+}
+
 const selectMsgSQL = `
 SELECT
 	org_id,
@@ -348,13 +448,13 @@ func (b *backend) flushMsgFile(filename string, contents []byte) error {
 // Deduping utility methods
 //-----------------------------------------------------------------------------
 
-var luaMsgSeen = redis.NewScript(3, `-- KEYS: [Window, PrevWindow, URNFingerprint]
+var luaMsgSeen = redis.NewScript(2, `-- KEYS: [Window, PrevWindow] ARGV: [URNFingerprint]
 	-- try to look up in window
-	local found = redis.call("hget", KEYS[1], KEYS[3])
+	local found = redis.call("hget", KEYS[1], ARGV[1])
 
 	-- didn't find it, try in our previous window
 	if not found then
-		found = redis.call("hget", KEYS[2], KEYS[3])
+		found = redis.call("hget", KEYS[2], ARGV[1])
 	end
 
 	-- return the fingerprint found
@@ -371,8 +471,8 @@ func checkMsgSeen(b *backend, msg *DBMsg) courier.MsgUUID {
 
 	now := time.Now().In(time.UTC)
 	prev := now.Add(time.Second * -2)
-	windowKey := fmt.Sprintf("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
-	prevWindowKey := fmt.Sprintf("seen:msgs:%s:%02d", prev.Format("2006-01-02-15:04"), prev.Second()/2*2)
+	windowKey := utils.RedisKey("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
+	prevWindowKey := utils.RedisKey("seen:msgs:%s:%02d", prev.Format("2006-01-02-15:04"), prev.Second()/2*2)
 
 	// see if there were any messages received in the past 4 seconds
 	found, _ := redis.String(luaMsgSeen.Do(r, windowKey, prevWindowKey, urnFingerprint))
@@ -389,8 +489,8 @@ func checkMsgSeen(b *backend, msg *DBMsg) courier.MsgUUID {
 	return courier.NilMsgUUID
 }
 
-var luaWriteMsgSeen = redis.NewScript(3, `-- KEYS: [Window, URNFingerprint, UUIDText]
-	redis.call("hset", KEYS[1], KEYS[2], KEYS[3])
+var luaWriteMsgSeen = redis.NewScript(1, `-- KEYS: [Window] ARGV: [URNFingerprint, UUIDText]
+	redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
 	redis.call("expire", KEYS[1], 5)
 `)
 
@@ -403,7 +503,7 @@ func writeMsgSeen(b *backend, msg *DBMsg) {
 	urnFingerprint := msg.urnFingerprint()
 	uuidText := fmt.Sprintf("%s|%s", msg.UUID().String(), msg.Text_)
 	now := time.Now().In(time.UTC)
-	windowKey := fmt.Sprintf("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
+	windowKey := utils.RedisKey("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
 
 	luaWriteMsgSeen.Do(r, windowKey, urnFingerprint, uuidText)
 }
@@ -414,20 +514,20 @@ func clearMsgSeen(rc redis.Conn, msg *DBMsg) {
 
 	now := time.Now().In(time.UTC)
 	prev := now.Add(time.Second * -2)
-	windowKey := fmt.Sprintf("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
-	prevWindowKey := fmt.Sprintf("seen:msgs:%s:%02d", prev.Format("2006-01-02-15:04"), prev.Second()/2*2)
+	windowKey := utils.RedisKey("seen:msgs:%s:%02d", now.Format("2006-01-02-15:04"), now.Second()/2*2)
+	prevWindowKey := utils.RedisKey("seen:msgs:%s:%02d", prev.Format("2006-01-02-15:04"), prev.Second()/2*2)
 
 	rc.Send("hdel", windowKey, urnFingerprint)
 	rc.Do("hdel", prevWindowKey, urnFingerprint)
 }
 
-var luaExternalIDSeen = redis.NewScript(3, `-- KEYS: [Window, PrevWindow, ExternalID]
+var luaExternalIDSeen = redis.NewScript(2, `-- KEYS: [Window, PrevWindow] ARGV: [ExternalID]
 	-- try to look up in window
-	local found = redis.call("hget", KEYS[1], KEYS[3])
+	local found = redis.call("hget", KEYS[1], ARGV[1])
 
 	-- didn't find it, try in our previous window
 	if not found then
-		found = redis.call("hget", KEYS[2], KEYS[3])
+		found = redis.call("hget", KEYS[2], ARGV[1])
 	end
 
 	-- return the fingerprint found
@@ -442,8 +542,8 @@ func checkExternalIDSeen(b *backend, msg courier.Msg) courier.MsgUUID {
 
 	now := time.Now().In(time.UTC)
 	prev := now.Add(time.Hour * -24)
-	windowKey := fmt.Sprintf("seen:externalid:%s", now.Format("2006-01-02"))
-	prevWindowKey := fmt.Sprintf("seen:externalid:%s", prev.Format("2006-01-02"))
+	windowKey := utils.RedisKey("seen:externalid:%s", now.Format("2006-01-02"))
+	prevWindowKey := utils.RedisKey("seen:externalid:%s", prev.Format("2006-01-02"))
 
 	// see if there were any messages received in the past 24 hours
 	found, _ := redis.String(luaExternalIDSeen.Do(r, windowKey, prevWindowKey, urnFingerprint))
@@ -460,8 +560,8 @@ func checkExternalIDSeen(b *backend, msg courier.Msg) courier.MsgUUID {
 	return courier.NilMsgUUID
 }
 
-var luaWriteExternalIDSeen = redis.NewScript(3, `-- KEYS: [Window, ExternalID, Seen]
-	redis.call("hset", KEYS[1], KEYS[2], KEYS[3])
+var luaWriteExternalIDSeen = redis.NewScript(1, `-- KEYS: [Window] ARGV: [ExternalID, Seen]
+	redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
 	redis.call("expire", KEYS[1], 86400)
 `)
 
@@ -473,7 +573,7 @@ func writeExternalIDSeen(b *backend, msg courier.Msg) {
 	uuidText := fmt.Sprintf("%s|%s", msg.UUID().String(), msg.Text())
 
 	now := time.Now().In(time.UTC)
-	windowKey := fmt.Sprintf("seen:externalid:%s", now.Format("2006-01-02"))
+	windowKey := utils.RedisKey("seen:externalid:%s", now.Format("2006-01-02"))
 
 	luaWriteExternalIDSeen.Do(r, windowKey, urnFingerprint, uuidText)
 }
@@ -584,6 +684,11 @@ func (m *DBMsg) QuickReplies() []string {
 	return m.quickReplies
 }
 
+// Interactive returns the structured interactive content for this message, if any
+func (m *DBMsg) Interactive() *courier.Interactive {
+	return courier.ParseInteractive(m.Metadata_)
+}
+
 func (m *DBMsg) Topic() string {
 	if m.Metadata_ == nil {
 		return ""
@@ -602,6 +707,9 @@ func (m *DBMsg) urnFingerprint() string {
 	return fmt.Sprintf("%s:%s", m.ChannelUUID_, m.URN_.Identity())
 }
 
+// WithText can be used to set the text of a msg in a chained call
+func (m *DBMsg) WithText(text string) courier.Msg { m.Text_ = text; return m }
+
 // WithContactName can be used to set the contact name on a msg
 func (m *DBMsg) WithContactName(name string) courier.Msg { m.ContactName_ = name; return m }
 
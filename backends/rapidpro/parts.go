@@ -0,0 +1,115 @@
+package rapidpro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/utils"
+)
+
+// messages sent as several requests have the external ID of each part mapped to the message and the status of each
+// part recorded, so that delivery reports for any part can be aggregated into a status for the message
+const (
+	msgPartKey  = "msg_part:%s:%s" // channel UUID and external ID of a part to the ID of its message
+	msgPartsKey = "msg_parts:%d"   // message ID to a hash of the external IDs of its parts and their statuses
+	msgPartsTTL = 60 * 60 * 24 * 7
+)
+
+// how far along the way to being delivered each status is, a message has the status of its least progressed part
+var partStatusProgress = map[courier.MsgStatusValue]int{
+	courier.MsgPending:   0,
+	courier.MsgQueued:    0,
+	courier.MsgErrored:   1,
+	courier.MsgWired:     2,
+	courier.MsgSent:      3,
+	courier.MsgDelivered: 4,
+}
+
+// writeMsgParts records the external IDs of the parts the message of the passed in status was sent as
+func writeMsgParts(rc redis.Conn, status *DBMsgStatus) error {
+	partsKey := utils.RedisKey(msgPartsKey, status.ID_)
+
+	rc.Send("MULTI")
+	for _, externalID := range status.PartIDs_ {
+		rc.Send("SET", utils.RedisKey(msgPartKey, status.ChannelUUID_, externalID), status.ID_.String(), "EX", msgPartsTTL)
+		rc.Send("HSET", partsKey, externalID, string(status.Status_))
+	}
+	rc.Send("EXPIRE", partsKey, msgPartsTTL)
+	_, err := rc.Do("EXEC")
+	return err
+}
+
+// routeMsgPartStatus checks whether the passed in status is for a part of a multipart message, and if so records the
+// status of that part and turns the status into one for the message, aggregated across all of its parts. It returns
+// the statuses of all the parts or nil if the status isn't for a part.
+func routeMsgPartStatus(rc redis.Conn, status *DBMsgStatus) (map[string]courier.MsgStatusValue, error) {
+	msgID, err := redis.Int64(rc.Do("GET", utils.RedisKey(msgPartKey, status.ChannelUUID_, status.ExternalID_)))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	partsKey := utils.RedisKey(msgPartsKey, msgID)
+
+	rc.Send("MULTI")
+	rc.Send("HSET", partsKey, status.ExternalID_, string(status.Status_))
+	rc.Send("HGETALL", partsKey)
+	replies, err := redis.Values(rc.Do("EXEC"))
+	if err != nil {
+		return nil, err
+	}
+	values, err := redis.StringMap(replies[1], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make(map[string]courier.MsgStatusValue, len(values))
+	for externalID, value := range values {
+		parts[externalID] = courier.MsgStatusValue(value)
+	}
+
+	// update our status to be for the message, leaving its external ID as that of the first part
+	status.ID_ = courier.NewMsgID(msgID)
+	status.ExternalID_ = ""
+	status.Status_ = aggregateMsgPartStatuses(parts)
+
+	return parts, nil
+}
+
+// aggregateMsgPartStatuses returns the status of a message from the statuses of its parts. It is failed as soon as any
+// part fails and otherwise has the status of its least progressed part, so is only delivered once all parts are.
+func aggregateMsgPartStatuses(parts map[string]courier.MsgStatusValue) courier.MsgStatusValue {
+	aggregate := courier.MsgDelivered
+	for _, status := range parts {
+		if status == courier.MsgFailed {
+			return courier.MsgFailed
+		}
+		if partStatusProgress[status] < partStatusProgress[aggregate] {
+			aggregate = status
+		}
+	}
+	return aggregate
+}
+
+// newMsgPartsLog creates a channel log describing the status of each part of a message and the status they add up to
+func newMsgPartsLog(channel courier.Channel, status *DBMsgStatus, parts map[string]courier.MsgStatusValue) *courier.ChannelLog {
+	externalIDs := make([]string, 0, len(parts))
+	for externalID := range parts {
+		externalIDs = append(externalIDs, externalID)
+	}
+	sort.Strings(externalIDs)
+
+	lines := make([]string, 0, len(parts)+1)
+	for _, externalID := range externalIDs {
+		lines = append(lines, fmt.Sprintf("part %s: %s", externalID, parts[externalID]))
+	}
+	lines = append(lines, fmt.Sprintf("message: %s", status.Status_))
+
+	return courier.NewChannelLog("Multipart Status", channel, status.ID_, "", "", courier.NilStatusCode, "", strings.Join(lines, "\n"), time.Duration(0), nil)
+}
@@ -0,0 +1,346 @@
+package rapidpro
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/gomodule/redigo/redis"
+	"github.com/lib/pq"
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/queue"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/nyaruka/null"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// how often we look for queued messages which have been lost from Redis
+	reconcileInterval = 5 * time.Minute
+
+	// how long the lease of the instance reconciling lasts if not renewed
+	reconcileLeaderTTL = time.Minute
+
+	// the maximum number of messages checked in a single run
+	reconcileBatchSize = 1000
+
+	// messages which have been queued for longer than this are left alone
+	reconcileMaxAge = 7 * 24 * time.Hour
+
+	// the tps messages are queued with if their channel doesn't have a queue or a max tps
+	defaultQueueTPS = 10
+)
+
+const selectQueuedMsgsSQL = `
+SELECT
+	m.id,
+	m.uuid,
+	m.org_id,
+	m.direction,
+	m.status,
+	m.visibility,
+	COALESCE(m.high_priority, FALSE) AS high_priority,
+	m.text,
+	m.attachments,
+	m.external_id,
+	m.metadata,
+	m.channel_id,
+	m.contact_id,
+	m.contact_urn_id,
+	m.msg_count,
+	m.error_count,
+	m.failed_reason,
+	m.next_attempt,
+	m.created_on,
+	COALESCE(m.modified_on, m.created_on) AS modified_on,
+	COALESCE(m.queued_on, m.created_on) AS queued_on,
+	m.sent_on,
+	ch.uuid AS channel_uuid,
+	u.identity AS urn,
+	COALESCE(u.auth, '') AS urn_auth,
+	COALESCE(c.name, '') AS contact_name
+FROM
+	msgs_msg m
+	JOIN channels_channel ch ON ch.id = m.channel_id
+	JOIN contacts_contacturn u ON u.id = m.contact_urn_id
+	JOIN contacts_contact c ON c.id = m.contact_id
+WHERE
+	m.direction = 'O' AND
+	m.status IN ('Q', 'P') AND
+	m.modified_on < $1 AND
+	m.created_on > $2 AND
+	ch.is_active = TRUE AND
+	ch.channel_type = ANY($3) AND
+	m.id > $5
+ORDER BY
+	m.id
+LIMIT $4
+`
+
+// queuedMsg is an outgoing message read from the database along with what we need to queue it again
+type queuedMsg struct {
+	DBMsg
+
+	UUID        null.String `db:"uuid"`
+	Metadata    null.String `db:"metadata"`
+	ChannelUUID string      `db:"channel_uuid"`
+	URN         string      `db:"urn"`
+	URNAuth     string      `db:"urn_auth"`
+	ContactName string      `db:"contact_name"`
+}
+
+// ReconcileReport is the result of a single reconciliation run
+type ReconcileReport struct {
+	RanOn    time.Time `json:"ran_on"`
+	DryRun   bool      `json:"dry_run"`
+	Checked  int       `json:"checked"`
+	Missing  int       `json:"missing"`
+	Requeued int       `json:"requeued"`
+}
+
+// reconciler looks for outgoing messages which are queued in the database but no longer in Redis. Each run checks the
+// next batch of messages after the last one checked, starting over once it reaches the end. As a message can be briefly
+// absent from Redis while it is being sent, a message has to be missing each time it is checked on two consecutive
+// passes before it is considered lost.
+type reconciler struct {
+	b         *backend
+	threshold time.Duration
+	dryRun    bool
+	election  *courier.LeaderElection
+
+	cursor   courier.MsgID
+	suspects map[courier.MsgID]bool
+
+	mutex sync.RWMutex
+	last  *ReconcileReport
+}
+
+func newReconciler(b *backend, threshold time.Duration, dryRun bool) *reconciler {
+	return &reconciler{b: b, threshold: threshold, dryRun: dryRun, suspects: make(map[courier.MsgID]bool)}
+}
+
+// start starts a goroutine which reconciles every interval whilst this instance is the leader
+func (r *reconciler) start(quitter chan bool, wg *sync.WaitGroup) {
+	log := logrus.WithField("comp", "reconciler")
+
+	r.election = courier.NewLeaderElection(r.b.redisPool, "reconciler", reconcileLeaderTTL, nil, nil)
+	r.election.Start(quitter, wg)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-quitter:
+				return
+
+			case <-time.After(reconcileInterval):
+				if !r.election.IsLeader() {
+					// forget what we suspected, another instance is doing this now
+					r.cursor = courier.NilMsgID
+					r.suspects = make(map[courier.MsgID]bool)
+					continue
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				_, err := r.reconcile(ctx, courier.GetActiveChannelTypes())
+				cancel()
+
+				if err != nil {
+					log.WithError(err).Error("error reconciling queued messages")
+				}
+			}
+		}
+	}()
+}
+
+// reconcile checks the messages queued on channels of the passed in types, queueing again any that have been lost
+func (r *reconciler) reconcile(ctx context.Context, channelTypes []courier.ChannelType) (*ReconcileReport, error) {
+	log := logrus.WithField("comp", "reconciler").WithField("dry_run", r.dryRun)
+	report := &ReconcileReport{RanOn: time.Now().In(time.UTC), DryRun: r.dryRun}
+
+	types := make(pq.StringArray, len(channelTypes))
+	for i := range channelTypes {
+		types[i] = string(channelTypes[i])
+	}
+
+	now := time.Now()
+	msgs := make([]*queuedMsg, 0, reconcileBatchSize)
+	err := r.b.db.SelectContext(ctx, &msgs, selectQueuedMsgsSQL, now.Add(-r.threshold), now.Add(-reconcileMaxAge), types, reconcileBatchSize, int64(r.cursor))
+	if err != nil {
+		return nil, err
+	}
+
+	rc := r.b.redisPool.Get()
+	defer rc.Close()
+
+	// messages in queues which are being purged are about to be failed, so aren't lost either
+	purging, err := getPurgingMsgIDs(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the queues and queued message ids of each channel we've looked at
+	channelQueues := make(map[string][]string)
+	channelQueued := make(map[string]map[courier.MsgID]bool)
+
+	// the range of ids this run covers, the next run carries on after it unless we've reached the end
+	from, to := r.cursor, courier.NilMsgID
+	if len(msgs) == reconcileBatchSize {
+		to = msgs[len(msgs)-1].ID_
+	}
+
+	// forget suspects in our range which are no longer queued, we'll add back those which are still missing
+	suspects := make(map[courier.MsgID]bool, len(r.suspects))
+	for id := range r.suspects {
+		if id <= from || (to != courier.NilMsgID && id > to) {
+			suspects[id] = true
+		}
+	}
+
+	for _, msg := range msgs {
+		report.Checked++
+
+		if purging[msg.ID_] {
+			continue
+		}
+
+		queued, found := channelQueued[msg.ChannelUUID]
+		if !found {
+			channelQueues[msg.ChannelUUID], queued, err = getQueuedMsgIDs(rc, msg.ChannelUUID)
+			if err != nil {
+				return nil, err
+			}
+			channelQueued[msg.ChannelUUID] = queued
+		}
+
+		if queued[msg.ID_] {
+			continue
+		}
+
+		// messages which have been sent but whose statuses haven't been written yet aren't lost
+		sent, err := r.b.WasMsgSent(ctx, msg.ID_)
+		if err != nil {
+			return nil, err
+		}
+		if sent {
+			continue
+		}
+
+		// only consider messages lost if they were also missing on our last run
+		suspects[msg.ID_] = true
+		if !r.suspects[msg.ID_] {
+			continue
+		}
+
+		report.Missing++
+		msgLog := log.WithField("msg_id", msg.ID_).WithField("channel_uuid", msg.ChannelUUID).WithField("queued_on", msg.QueuedOn_)
+
+		if r.dryRun {
+			msgLog.Warn("queued message missing from redis")
+			continue
+		}
+
+		err = r.requeue(rc, msg, channelQueues[msg.ChannelUUID])
+		if err != nil {
+			msgLog.WithError(err).Error("error queueing message missing from redis")
+			continue
+		}
+
+		delete(suspects, msg.ID_)
+		report.Requeued++
+		msgLog.Warn("requeued message missing from redis")
+	}
+
+	r.cursor = to
+	r.suspects = suspects
+
+	r.mutex.Lock()
+	r.last = report
+	r.mutex.Unlock()
+
+	if report.Missing > 0 {
+		log.WithField("checked", report.Checked).WithField("missing", report.Missing).WithField("requeued", report.Requeued).Warn("reconciled queued messages")
+	}
+
+	return report, nil
+}
+
+// requeue pushes the passed in message onto the existing queue for its channel, or a new one if it doesn't have one
+func (r *reconciler) requeue(rc redis.Conn, msg *queuedMsg, queues []string) error {
+	dbMsg := &msg.DBMsg
+	dbMsg.UUID_ = courier.NewMsgUUIDFromString(string(msg.UUID))
+	dbMsg.ChannelUUID_, _ = courier.NewChannelUUID(msg.ChannelUUID)
+	dbMsg.URN_ = urns.URN(msg.URN)
+	dbMsg.URNAuth_ = msg.URNAuth
+	dbMsg.ContactName_ = msg.ContactName
+	if msg.Metadata != "" {
+		dbMsg.Metadata_ = json.RawMessage(msg.Metadata)
+	}
+
+	return pushOutgoingMsg(rc, r.b, dbMsg, queues)
+}
+
+// lastReport returns the report of our last run, if we've run
+func (r *reconciler) lastReport() *ReconcileReport {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.last
+}
+
+// getQueuedMsgIDs returns the queues of the passed in channel and the ids of the messages in them
+func getQueuedMsgIDs(rc redis.Conn, channelUUID string) ([]string, map[courier.MsgID]bool, error) {
+	queues, err := queue.GetAllChannelQueues(rc, channelUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make(map[courier.MsgID]bool)
+	for _, q := range queues {
+		for _, priority := range []queue.Priority{queue.HighPriority, queue.LowPriority} {
+			if err := addQueuedMsgIDs(rc, q+"/"+strconv.Itoa(int(priority)), ids); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return queues, ids, nil
+}
+
+// getPurgingMsgIDs returns the ids of the messages in queues which are waiting to be purged
+func getPurgingMsgIDs(rc redis.Conn) (map[courier.MsgID]bool, error) {
+	queues, err := redis.Strings(rc.Do("LRANGE", utils.RedisKey("msgs:active_purge"), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[courier.MsgID]bool)
+	for _, q := range queues {
+		if err := addQueuedMsgIDs(rc, q, ids); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// addQueuedMsgIDs adds the ids of the messages in the passed in queue to the passed in set
+func addQueuedMsgIDs(rc redis.Conn, queue string, ids map[courier.MsgID]bool) error {
+	values, err := redis.ByteSlices(rc.Do("ZRANGE", queue, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	// each queued value is a list of messages
+	for _, value := range values {
+		jsonparser.ArrayEach(value, func(msg []byte, dataType jsonparser.ValueType, offset int, err error) {
+			if id, err := jsonparser.GetInt(msg, "id"); err == nil {
+				ids[courier.NewMsgID(id)] = true
+			}
+		})
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package rapidpro
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/queue"
+	"github.com/sirupsen/logrus"
+)
+
+// the org and weight we last recorded for each queue, so we only write them when they change or might have been pruned
+var recordedQueueOrgs sync.Map
+
+// how long we trust a recorded queue org before recording it again, as the lease janitor prunes those of empty queues
+const queueOrgRecordTTL = time.Minute * 5
+
+type recordedQueueOrg struct {
+	value      string
+	recordedOn time.Time
+}
+
+// recordQueueOrg records the org of the queue the passed in token is for, and the send weight of that org
+func recordQueueOrg(rc redis.Conn, token queue.WorkerToken, channel *DBChannel) {
+	org := strconv.FormatInt(int64(channel.OrgID_), 10)
+	weight := orgSendWeight(channel)
+	value := fmt.Sprintf("%s|%d", org, weight)
+
+	q := token.Queue()
+	if recorded, found := recordedQueueOrgs.Load(q); found {
+		r := recorded.(recordedQueueOrg)
+		if r.value == value && time.Since(r.recordedOn) < queueOrgRecordTTL {
+			return
+		}
+	}
+
+	err := queue.SetQueueOrg(rc, msgQueueName, q, org, weight)
+	if err != nil {
+		logrus.WithError(err).WithField("queue", q).Error("error recording org of queue")
+		return
+	}
+	recordedQueueOrgs.Store(q, recordedQueueOrg{value: value, recordedOn: time.Now()})
+}
+
+// orgSendWeight returns the weight of the org of the passed in channel when sharing senders between orgs
+func orgSendWeight(channel *DBChannel) int {
+	switch weight := channel.OrgConfigForKey(courier.ConfigSendWeight, 1).(type) {
+	case float64:
+		if weight >= 1 {
+			return int(weight)
+		}
+	case int:
+		if weight >= 1 {
+			return weight
+		}
+	case string:
+		if i, err := strconv.Atoi(weight); err == nil && i >= 1 {
+			return i
+		}
+	}
+	return 1
+}
+
+// getSchedulingStatus describes how senders are shared and, when scheduling between orgs, the load of each org
+func getSchedulingStatus(rc redis.Conn) (string, error) {
+	status := bytes.Buffer{}
+	status.WriteString(fmt.Sprintf("\nScheduling: %s\n", queue.Scheduling))
+
+	if queue.Scheduling.OrgSample > 1 {
+		loads, err := queue.GetOrgLoads(rc, msgQueueName)
+		if err != nil {
+			return "", err
+		}
+		for _, load := range loads {
+			org := load.Org
+			if org == "" {
+				org = "unknown"
+			}
+			status.WriteString(fmt.Sprintf("org %s (weight %d): %d queues, %d workers\n", org, load.Weight, load.Queues, load.Workers))
+		}
+	}
+	return status.String(), nil
+}
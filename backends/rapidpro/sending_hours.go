@@ -0,0 +1,216 @@
+package rapidpro
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// how often we check whether the bulk queues of channels should be held outside their sending hours
+	sendingHoursInterval = time.Minute
+
+	// how long a hold lasts before it has to be renewed, so that changes to sending hours take effect
+	sendingHoursHoldTTL = time.Minute * 5
+
+	// how long the lease of the instance applying sending hours lasts if not renewed
+	sendingHoursLeaderTTL = time.Minute
+
+	// the value of a channel's bulk rate limit key when we are holding its bulk queue
+	sendingHoursHold = "sending_hours"
+)
+
+var luaHoldBulk = redis.NewScript(1, `-- KEYS: [Key] ARGV: [TTLMS]
+	-- hold the bulk queue unless it's already rate limited for some other reason
+	local current = redis.call("get", KEYS[1])
+	if current == false or current == "sending_hours" then
+		redis.call("set", KEYS[1], "sending_hours", "PX", ARGV[1])
+	end
+	return 1
+`)
+
+var luaReleaseBulk = redis.NewScript(1, `-- KEYS: [Key]
+	-- only release the bulk queue if we are the ones holding it
+	if redis.call("get", KEYS[1]) == "sending_hours" then
+		redis.call("del", KEYS[1])
+	end
+	return 1
+`)
+
+// startSendingHours starts a goroutine which holds and releases bulk queues according to the sending hours of their
+// channels whilst this instance is the leader
+func (b *backend) startSendingHours(quitter chan bool, wg *sync.WaitGroup) {
+	log := logrus.WithField("comp", "sending_hours")
+
+	election := courier.NewLeaderElection(b.redisPool, "sending_hours", sendingHoursLeaderTTL, nil, nil)
+	election.Start(quitter, wg)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-quitter:
+				return
+
+			case <-time.After(sendingHoursInterval):
+				if !election.IsLeader() {
+					continue
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				err := b.applySendingHours(ctx, time.Now())
+				cancel()
+
+				if err != nil {
+					log.WithError(err).Error("error applying sending hours")
+				}
+			}
+		}
+	}()
+}
+
+// applySendingHours holds the bulk queue of each channel which is outside its sending hours at the passed in time, and
+// releases those which aren't. Channels with sending hours are held whether or not they have queued messages, so that
+// messages queued on an idle channel aren't sent before the next time we apply sending hours.
+func (b *backend) applySendingHours(ctx context.Context, now time.Time) error {
+	rc := b.redisPool.Get()
+	defer rc.Close()
+
+	uuids, err := getQueuedChannelUUIDs(rc)
+	if err != nil {
+		return err
+	}
+
+	withHours, err := getSendingHoursChannelUUIDs(ctx, b.reader)
+	if err != nil {
+		return err
+	}
+	queued := make(map[string]bool, len(uuids))
+	for _, uuid := range uuids {
+		queued[uuid] = true
+	}
+	for _, uuid := range withHours {
+		if !queued[uuid] {
+			uuids = append(uuids, uuid)
+		}
+	}
+
+	for _, uuid := range uuids {
+		log := logrus.WithField("comp", "sending_hours").WithField("channel_uuid", uuid)
+
+		channelUUID, err := courier.NewChannelUUID(uuid)
+		if err != nil {
+			continue
+		}
+		channel, err := b.GetChannel(ctx, courier.AnyChannelType, channelUUID)
+		if err != nil {
+			log.WithError(err).Error("error loading channel to apply sending hours")
+			continue
+		}
+
+		// if we can't tell when a channel can send, we don't hold it
+		hours, err := courier.GetSendingHours(channel)
+		if err != nil {
+			log.WithError(err).Warn("invalid sending hours, not holding bulk queue")
+		}
+
+		if hours == nil || hours.IsOpen(now) {
+			err = releaseBulk(rc, uuid)
+		} else {
+			hold := sendingHoursHoldTTL
+			if opens := hours.NextOpen(now); !opens.IsZero() && opens.Sub(now) < hold {
+				hold = opens.Sub(now)
+			}
+			err = holdBulk(rc, uuid, hold)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holdBulk holds the bulk queue of the passed in channel for the passed in duration
+func holdBulk(rc redis.Conn, channelUUID string, hold time.Duration) error {
+	_, err := luaHoldBulk.Do(rc, utils.RedisKey("rate_limit_bulk:%s", channelUUID), int64(hold/time.Millisecond))
+	return err
+}
+
+// releaseBulk releases the bulk queue of the passed in channel if we are holding it
+func releaseBulk(rc redis.Conn, channelUUID string) error {
+	_, err := luaReleaseBulk.Do(rc, utils.RedisKey("rate_limit_bulk:%s", channelUUID))
+	return err
+}
+
+// describeBulkHold describes why the bulk queue of the passed in channel is held, the channel may be nil if it couldn't
+// be loaded
+func describeBulkHold(channel courier.Channel, hold string, now time.Time) string {
+	if hold != sendingHoursHold || channel == nil {
+		return "rate limited"
+	}
+
+	hours, err := courier.GetSendingHours(channel)
+	if err != nil || hours == nil {
+		return "outside sending hours"
+	}
+	opens := hours.NextOpen(now)
+	if opens.IsZero() {
+		return "outside sending hours, which are never open"
+	}
+	return fmt.Sprintf("outside sending hours until %s", opens.In(hours.Location).Format(time.RFC3339))
+}
+
+// getQueuedChannelUUIDs returns the UUIDs of all the channels which have queues
+func getQueuedChannelUUIDs(rc redis.Conn) ([]string, error) {
+	seen := make(map[string]bool)
+	uuids := make([]string, 0)
+
+	for _, set := range []string{"active", "throttled", "future"} {
+		queues, err := redis.Strings(rc.Do("ZRANGE", utils.RedisKey("%s:%s", msgQueueName, set), 0, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		// our queues are named msgs:uuid|tps
+		for _, q := range queues {
+			uuid := strings.Split(strings.TrimPrefix(q, utils.RedisKey("%s:", msgQueueName)), "|")[0]
+			if !seen[uuid] {
+				seen[uuid] = true
+				uuids = append(uuids, uuid)
+			}
+		}
+	}
+	return uuids, nil
+}
+
+const selectSendingHoursChannelsSQL = `
+SELECT
+	ch.uuid
+FROM
+	channels_channel ch
+	JOIN orgs_org org ON ch.org_id = org.id
+WHERE
+	ch.is_active = TRUE AND
+	(NULLIF(ch.config, '')::jsonb -> 'bulk_sending_hours' IS NOT NULL OR NULLIF(org.config, '')::jsonb -> 'bulk_sending_hours' IS NOT NULL)
+ORDER BY
+	ch.id`
+
+// getSendingHoursChannelUUIDs returns the UUIDs of all the active channels which have sending hours, either their own or
+// those of their org
+func getSendingHoursChannelUUIDs(ctx context.Context, db dbReader) ([]string, error) {
+	uuids := make([]string, 0)
+	err := db.SelectContext(ctx, &uuids, selectSendingHoursChannelsSQL)
+	if err != nil {
+		return nil, err
+	}
+	return uuids, nil
+}
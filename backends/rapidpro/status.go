@@ -9,12 +9,15 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/nyaruka/gocommon/urns"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/nyaruka/courier"
+	"github.com/nyaruka/gocommon/analytics"
 )
 
 // newMsgStatus creates a new DBMsgStatus for the passed in parameters
@@ -61,9 +64,9 @@ func checkMsgExists(b *backend, status courier.MsgStatus) (err error) {
 	var id int64
 
 	if status.ID() != courier.NilMsgID {
-		err = b.db.QueryRow(selectMsgIDForID, status.ID(), status.ChannelUUID()).Scan(&id)
+		err = b.reader.GetContext(context.Background(), &id, selectMsgIDForID, status.ID(), status.ChannelUUID())
 	} else if status.ExternalID() != "" {
-		err = b.db.QueryRow(selectMsgIDForExternalID, status.ExternalID(), status.ChannelUUID()).Scan(&id)
+		err = b.reader.GetContext(context.Background(), &id, selectMsgIDForExternalID, status.ExternalID(), status.ChannelUUID())
 	} else {
 		return fmt.Errorf("no id or external id for status update")
 	}
@@ -131,6 +134,17 @@ UPDATE msgs_msg SET
 		ELSE
 			external_id
 		END,
+	metadata = CASE
+		WHEN
+			:cost::jsonb IS NOT NULL OR (:status IN ('E', 'F') AND :error_code != '')
+		THEN
+			(COALESCE(NULLIF(metadata, ''), '{}')::jsonb || jsonb_strip_nulls(jsonb_build_object(
+				'cost', :cost::jsonb,
+				'error_code', CASE WHEN :status IN ('E', 'F') THEN NULLIF(:error_code, '') END
+			)))::text
+		ELSE
+			metadata
+		END,
 	modified_on = :modified_on
 WHERE 
 	msgs_msg.id = :msg_id AND
@@ -188,6 +202,17 @@ UPDATE msgs_msg SET
 		ELSE 
 			NULL 
 		END,
+	metadata = CASE
+		WHEN
+			:cost::jsonb IS NOT NULL OR (:status IN ('E', 'F') AND :error_code != '')
+		THEN
+			(COALESCE(NULLIF(metadata, ''), '{}')::jsonb || jsonb_strip_nulls(jsonb_build_object(
+				'cost', :cost::jsonb,
+				'error_code', CASE WHEN :status IN ('E', 'F') THEN NULLIF(:error_code, '') END
+			)))::text
+		ELSE
+			metadata
+		END,
 	modified_on = :modified_on
 WHERE 
 	msgs_msg.id = (SELECT msgs_msg.id FROM msgs_msg WHERE msgs_msg.external_id = :external_id AND msgs_msg.channel_id = :channel_id AND msgs_msg.direction = 'O' LIMIT 1)
@@ -244,6 +269,12 @@ func (b *backend) flushStatusFile(filename string, contents []byte) error {
 		return nil
 	}
 
+	// if the DB is reachable then it refused our status and will keep doing so, so stop retrying it
+	if err != nil && b.db.PingContext(context.Background()) == nil {
+		log.Printf("ERROR writing spooled status '%s', renaming: %s\n", filename, err)
+		os.Rename(filename, fmt.Sprintf("%s.error", filename))
+		return nil
+	}
 	return err
 }
 
@@ -295,17 +326,70 @@ UPDATE msgs_msg SET
 		ELSE
 			msgs_msg.external_id
 		END,
+	metadata = CASE
+		WHEN
+			s.cost IS NOT NULL OR (s.status IN ('E', 'F') AND s.error_code != '')
+		THEN
+			(COALESCE(NULLIF(msgs_msg.metadata, ''), '{}')::jsonb || jsonb_strip_nulls(jsonb_build_object(
+				'cost', s.cost::jsonb,
+				'error_code', CASE WHEN s.status IN ('E', 'F') THEN NULLIF(s.error_code, '') END
+			)))::text
+		ELSE
+			msgs_msg.metadata
+		END,
 	modified_on = NOW()
 FROM
-	(VALUES(:msg_id, :channel_id, :status, :external_id)) 
+	(VALUES(:msg_id, :channel_id, :status, :external_id, :error_code, :cost)) 
 AS 
-	s(msg_id, channel_id, status, external_id) 
+	s(msg_id, channel_id, status, external_id, error_code, cost) 
 WHERE 
 	msgs_msg.id = s.msg_id::bigint AND
 	msgs_msg.channel_id = s.channel_id::int AND 
 	msgs_msg.direction = 'O'
 `
 
+// costKey identifies an org and the currency costs were charged to it in
+type costKey struct {
+	orgID    OrgID
+	currency string
+}
+
+// costTotals tallies the costs providers report for messages between heartbeats so that they can be reported per org.
+// Gauges aren't reported per channel as there would be far too many of them.
+type costTotals struct {
+	mutex  sync.Mutex
+	totals map[costKey]float64
+}
+
+func newCostTotals() *costTotals {
+	return &costTotals{totals: make(map[costKey]float64)}
+}
+
+// add adds the passed in cost to the totals of the org of the passed in channel
+func (t *costTotals) add(channel *DBChannel, cost *courier.MsgCost) {
+	if cost.Amount == 0 || cost.Currency == "" {
+		return
+	}
+	currency := strings.ToLower(cost.Currency)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.totals[costKey{channel.OrgID(), currency}] += cost.Amount
+}
+
+// report gauges the totals since we were last called and resets them
+func (t *costTotals) report() {
+	t.mutex.Lock()
+	totals := t.totals
+	t.totals = make(map[costKey]float64)
+	t.mutex.Unlock()
+
+	for key, total := range totals {
+		analytics.Gauge(fmt.Sprintf("courier.msg_cost_org_%d_%s", key.orgID, key.currency), total)
+	}
+}
+
 //-----------------------------------------------------------------------------
 // MsgStatusUpdate implementation
 //-----------------------------------------------------------------------------
@@ -319,6 +403,9 @@ type DBMsgStatus struct {
 	NewURN_      urns.URN               `json:"new_urn"                  db:"new_urn"`
 	ExternalID_  string                 `json:"external_id,omitempty"    db:"external_id"`
 	Status_      courier.MsgStatusValue `json:"status"                   db:"status"`
+	ErrorCode_   courier.SendErrorCode  `json:"error_code,omitempty"     db:"error_code"`
+	Cost_        *courier.MsgCost       `json:"cost,omitempty"           db:"cost"`
+	PartIDs_     []string               `json:"part_ids,omitempty"       db:"-"`
 	ModifiedOn_  time.Time              `json:"modified_on"              db:"modified_on"`
 
 	logs []*courier.ChannelLog
@@ -368,8 +455,17 @@ func (s *DBMsgStatus) HasUpdatedURN() bool {
 func (s *DBMsgStatus) ExternalID() string      { return s.ExternalID_ }
 func (s *DBMsgStatus) SetExternalID(id string) { s.ExternalID_ = id }
 
+func (s *DBMsgStatus) PartExternalIDs() []string   { return s.PartIDs_ }
+func (s *DBMsgStatus) AddPartExternalID(id string) { s.PartIDs_ = append(s.PartIDs_, id) }
+
 func (s *DBMsgStatus) Logs() []*courier.ChannelLog    { return s.logs }
 func (s *DBMsgStatus) AddLog(log *courier.ChannelLog) { s.logs = append(s.logs, log) }
 
 func (s *DBMsgStatus) Status() courier.MsgStatusValue          { return s.Status_ }
 func (s *DBMsgStatus) SetStatus(status courier.MsgStatusValue) { s.Status_ = status }
+
+func (s *DBMsgStatus) ErrorCode() courier.SendErrorCode        { return s.ErrorCode_ }
+func (s *DBMsgStatus) SetErrorCode(code courier.SendErrorCode) { s.ErrorCode_ = code }
+
+func (s *DBMsgStatus) Cost() *courier.MsgCost        { return s.Cost_ }
+func (s *DBMsgStatus) SetCost(cost *courier.MsgCost) { s.Cost_ = cost }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gomodule/redigo/redis"
 	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/utils"
 )
 
 func queueMsgHandling(rc redis.Conn, c *DBContact, m *DBMsg) error {
@@ -27,6 +28,9 @@ func queueMsgHandling(rc redis.Conn, c *DBContact, m *DBMsg) error {
 		"new_contact":     c.IsNew_,
 		"created_on":      m.CreatedOn_,
 	}
+	if m.Metadata_ != nil {
+		body["metadata"] = m.Metadata_
+	}
 
 	return queueMailroomTask(rc, "msg_event", m.OrgID_, m.ContactID_, body)
 }
@@ -116,12 +120,12 @@ func queueMailroomTask(rc redis.Conn, taskType string, orgID OrgID, contactID Co
 	now := time.Now().UTC()
 	epochFloat := float64(now.UnixNano()) / float64(time.Second)
 
-	// we do all our queueing in a transaction
-	contactQueue := fmt.Sprintf("c:%d:%d", orgID, contactID)
+	// we do all our queueing in a transaction, these are mailroom's queues so our key prefix isn't applied
+	contactQueue := utils.RedisSharedKey("c:%d:%d", orgID, contactID)
 	rc.Send("multi")
 	rc.Send("rpush", contactQueue, eventJSON)
-	rc.Send("zadd", fmt.Sprintf("handler:%d", orgID), fmt.Sprintf("%.5f", epochFloat-10000000), contactJSON)
-	rc.Send("zincrby", "handler:active", 0, orgID)
+	rc.Send("zadd", utils.RedisSharedKey("handler:%d", orgID), fmt.Sprintf("%.5f", epochFloat-10000000), contactJSON)
+	rc.Send("zincrby", utils.RedisSharedKey("handler:active"), 0, orgID)
 	_, err = rc.Do("EXEC")
 
 	return err
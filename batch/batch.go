@@ -3,6 +3,7 @@ package batch
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,11 +14,56 @@ import (
 
 const batchSize = 1000
 
+const (
+	// how long we wait before retrying a batch when the DB is unreachable, doubling up to our max backoff
+	retryBackoff    = 250 * time.Millisecond
+	retryMaxBackoff = 30 * time.Second
+
+	// how long we keep flushing for once stopped, before handing what's left to the error callback
+	stopDeadline = 30 * time.Second
+)
+
+var (
+	// ErrBufferFull is passed to the error callback for values which couldn't be queued because our buffer was full
+	ErrBufferFull = errors.New("buffer full")
+
+	// ErrStopDeadline is passed to the error callback for values which weren't committed before our stop deadline
+	ErrStopDeadline = errors.New("stop deadline exceeded")
+)
+
+// rejectedError is passed to the error callback for values which the DB was reachable but refused to commit
+type rejectedError struct {
+	cause error
+}
+
+func (e *rejectedError) Error() string { return e.cause.Error() }
+func (e *rejectedError) Cause() error  { return e.cause }
+func (e *rejectedError) Unwrap() error { return e.cause }
+
+// IsRejected returns whether the passed in error from the error callback means the DB refused to commit the value, in
+// which case committing it again will fail too. Otherwise the value was never committed because our buffer was full,
+// we stopped or the DB was unreachable, and it can be retried later.
+func IsRejected(err error) bool {
+	var rejected *rejectedError
+	return errors.As(err, &rejected)
+}
+
 // Committer commits items in a background thread
 type Committer interface {
 	Start()
 	Queue(Value)
 	Stop()
+	Stats() Stats
+}
+
+// Stats are the stats of a committer, counts are since it was started
+type Stats struct {
+	Buffered     int           // values waiting to be committed
+	Committed    int64         // values committed
+	Failed       int64         // values handed to the error callback, including those which overflowed
+	Overflowed   int64         // values handed to the error callback because our buffer was full
+	Retries      int64         // batches retried because the DB was unreachable
+	FlushLatency time.Duration // how long our last flush took
 }
 
 // Value represents the interface values must satisfy. The RowID() method should return the unique row ID (if any) for the
@@ -27,7 +73,8 @@ type Value interface {
 	RowID() string
 }
 
-// ErrorCallback lets callers get a callback when a value fails to be committed
+// ErrorCallback lets callers get a callback when a value fails to be committed, which is also used for values which
+// couldn't be queued or were still queued when we stopped, so callers should persist them somewhere they can be replayed
 type ErrorCallback func(err error, value Value)
 
 // NewCommitter creates a new committer that will commit items in batches as quickly as possible.
@@ -39,60 +86,103 @@ func NewCommitter(label string, db *sqlx.DB, sql string, timeout time.Duration,
 		timeout:  timeout,
 		callback: callback,
 
-		wg:     wg,
-		stop:   make(chan bool),
-		buffer: make(chan Value, 1000),
+		wg:           wg,
+		stop:         make(chan bool),
+		buffer:       make(chan Value, 1000),
+		stopDeadline: stopDeadline,
 	}
 }
 
 // Start starts our committer
 func (c *committer) Start() {
+	c.wg.Add(1)
+
 	go func() {
-		c.wg.Add(1)
 		defer c.wg.Done()
 
 		for {
 			select {
 			case <-c.stop:
-				for len(c.buffer) > 0 {
-					c.flush(batchSize)
+				deadline := time.Now().Add(c.stopDeadline)
+				for len(c.buffer) > 0 && time.Now().Before(deadline) {
+					c.flush(batchSize, deadline)
 				}
+				c.drain(ErrStopDeadline)
 				logrus.WithField("label", c.label).Info("committer flushed and exiting")
 				return
 
 			case <-time.After(c.timeout):
 				count := len(c.buffer)
 				for i := 0; i <= count/batchSize; i++ {
-					c.flush(batchSize)
+					c.flush(batchSize, time.Time{})
 				}
 			}
 		}
 	}()
 }
 
-// Queue will queue the passed in value to committed. This will block in cases where our buffer is full
+// Queue will queue the passed in value to committed. If our buffer is full, the value is handed straight to our error
+// callback rather than blocking the caller.
 func (c *committer) Queue(value Value) {
-	// our buffer is full, log an error but continue (our channel will block)
-	if len(c.buffer) >= cap(c.buffer) {
-		logrus.WithField("label", c.label).Error("buffer full, you may want to decrease your timeout")
-		time.Sleep(250 * time.Millisecond)
-	} else {
-		// we are approaching our max size, start slowing down queueing so we can catch up
-		if len(c.buffer) > int(float64(cap(c.buffer))*.90) {
-			time.Sleep(100 * time.Millisecond)
+	select {
+	case c.buffer <- value:
+		if atomic.LoadInt32(&c.overflowing) == 1 {
+			atomic.StoreInt32(&c.overflowing, 0)
 		}
-	}
+	default:
+		atomic.AddInt64(&c.overflowed, 1)
 
-	c.buffer <- value
+		// only log the start of a run of overflows
+		if atomic.CompareAndSwapInt32(&c.overflowing, 0, 1) {
+			logrus.WithField("label", c.label).Error("buffer full, handing values to error callback")
+		}
+
+		c.fail(ErrBufferFull, value)
+	}
 }
 
-// Stop stops our committer, callers can use the WaitGroup used during initialization to block for stop
+// Stop stops our committer, callers can use the WaitGroup used during initialization to block for stop. Values are
+// flushed until our stop deadline, with any still queued after that handed to our error callback.
 func (c *committer) Stop() {
 	close(c.stop)
 }
 
-// flushes up to size items from our queue, returning whether any items were committed
-func (c *committer) flush(size int) bool {
+// Stats returns the current stats of this committer
+func (c *committer) Stats() Stats {
+	return Stats{
+		Buffered:     len(c.buffer),
+		Committed:    atomic.LoadInt64(&c.committed),
+		Failed:       atomic.LoadInt64(&c.failed),
+		Overflowed:   atomic.LoadInt64(&c.overflowed),
+		Retries:      atomic.LoadInt64(&c.retries),
+		FlushLatency: time.Duration(atomic.LoadInt64(&c.flushLatency)),
+	}
+}
+
+// drain hands all values remaining in our buffer to our error callback
+func (c *committer) drain(err error) {
+	for {
+		select {
+		case v := <-c.buffer:
+			c.fail(err, v)
+		default:
+			return
+		}
+	}
+}
+
+// fail hands the passed in value to our error callback
+func (c *committer) fail(err error, value Value) {
+	atomic.AddInt64(&c.failed, 1)
+
+	if c.callback != nil {
+		c.callback(err, value)
+	}
+}
+
+// flushes up to size items from our queue, returning whether any items were committed. Batches are retried while the
+// DB is unreachable, giving up at the passed in deadline if there is one.
+func (c *committer) flush(size int, deadline time.Time) bool {
 	if len(c.buffer) <= 0 {
 		return false
 	}
@@ -136,30 +226,101 @@ func (c *committer) flush(size int) bool {
 		}
 
 		// commit our batch
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		defer cancel()
+		reachable, err := c.commitWithRetries(batch, deadline)
 
-		err := batchSQL(ctx, c.label, c.db, c.sql, batch)
-
-		// if we received an error, try again one at a time (in case it is one value hanging us up)
-		if err != nil {
+		// if the DB is still unreachable, hand the whole batch to our error callback
+		if err != nil && !reachable {
+			for _, v := range batch {
+				c.fail(errors.Wrapf(err, "%s: error committing value", c.label), v.(Value))
+			}
+		} else if err != nil {
+			// otherwise try again one at a time (in case it is one value hanging us up)
 			for _, v := range batch {
-				err = batchSQL(ctx, c.label, c.db, c.sql, []interface{}{v})
+				err = c.commit([]interface{}{v})
 				if err != nil {
-					if c.callback != nil {
-						c.callback(errors.Wrapf(err, "%s: error committing value", c.label), v.(Value))
+					err = errors.Wrapf(err, "%s: error committing value", c.label)
+
+					// the DB may have become unreachable since our batch failed
+					if c.reachable() {
+						err = &rejectedError{cause: err}
 					}
+					c.fail(err, v.(Value))
+				} else {
+					atomic.AddInt64(&c.committed, 1)
 				}
 			}
+		} else {
+			atomic.AddInt64(&c.committed, int64(len(batch)))
 		}
 
 		values = dupes
 	}
 
+	atomic.StoreInt64(&c.flushLatency, int64(time.Since(start)))
+
 	logrus.WithField("elapsed", time.Since(start)).WithField("label", c.label).WithField("count", count).Debug("batch committed")
 	return true
 }
 
+// commitWithRetries commits the passed in batch, retrying with backoff for as long as the DB is unreachable or until
+// the passed in deadline. Errors from a reachable DB are returned straight away as they're likely a problem with a value.
+// Returns whether the DB is reachable and the error if the batch couldn't be committed.
+func (c *committer) commitWithRetries(batch []interface{}, deadline time.Time) (bool, error) {
+	backoff := retryBackoff
+
+	for {
+		err := c.commit(batch)
+		if err == nil {
+			return true, nil
+		}
+		if c.reachable() {
+			return true, err
+		}
+
+		// wait before retrying, giving up if that would take us past our deadline or we're stopped while waiting
+		if !deadline.IsZero() {
+			if time.Until(deadline) < backoff {
+				return false, err
+			}
+			c.logRetry(err, backoff)
+			time.Sleep(backoff)
+		} else {
+			c.logRetry(err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-c.stop:
+				return false, err
+			}
+		}
+
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
+
+func (c *committer) logRetry(err error, backoff time.Duration) {
+	atomic.AddInt64(&c.retries, 1)
+	logrus.WithError(err).WithField("label", c.label).WithField("backoff", backoff).Error("DB unreachable, retrying batch")
+}
+
+// commit commits the passed in values in a single statement
+func (c *committer) commit(vs []interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return batchSQL(ctx, c.label, c.db, c.sql, vs)
+}
+
+// reachable returns whether our DB can be reached
+func (c *committer) reachable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return c.db.PingContext(ctx) == nil
+}
+
 type committer struct {
 	db       *sqlx.DB
 	label    string
@@ -167,9 +328,18 @@ type committer struct {
 	timeout  time.Duration
 	callback ErrorCallback
 
-	wg     *sync.WaitGroup
-	stop   chan bool
-	buffer chan Value
+	wg           *sync.WaitGroup
+	stop         chan bool
+	buffer       chan Value
+	stopDeadline time.Duration
+
+	// our stats, updated atomically
+	committed    int64
+	failed       int64
+	overflowed   int64
+	overflowing  int32
+	retries      int64
+	flushLatency int64
 }
 
 func batchSQL(ctx context.Context, label string, db *sqlx.DB, sql string, vs []interface{}) error {
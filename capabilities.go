@@ -0,0 +1,245 @@
+package courier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/buger/jsonparser"
+	"github.com/go-chi/chi"
+	"github.com/nyaruka/courier/utils"
+)
+
+// Capabilities describes what a channel type is able to send, it is used to adapt outgoing messages to what the
+// channel supports or reject them before they are handed to the handler
+type Capabilities struct {
+	// MaxTextLength is the max length of a single message part, 0 meaning there is no limit
+	MaxTextLength int `json:"max_text_length"`
+
+	// MaxTextParts is the max number of parts a message can be split into, 0 meaning there is no limit
+	MaxTextParts int `json:"max_text_parts"`
+
+	// AttachmentTypes are the content types which can be sent as attachments, either exact like image/jpeg or
+	// wildcards like image/* or */*
+	AttachmentTypes []string `json:"attachment_types"`
+
+	// MaxAttachments is the max number of attachments per message, 0 meaning there is no limit
+	MaxAttachments int `json:"max_attachments"`
+
+	// MaxAttachmentSize is the max size in bytes of an attachment, 0 meaning there is no limit
+	MaxAttachmentSize int `json:"max_attachment_size"`
+
+	// QuickReplies is whether quick replies can be sent
+	QuickReplies bool `json:"quick_replies"`
+
+	// MaxQuickReplies is the max number of quick replies per message, 0 meaning there is no limit
+	MaxQuickReplies int `json:"max_quick_replies"`
+
+	// MaxQuickReplyLength is the max length of a single quick reply, 0 meaning there is no limit
+	MaxQuickReplyLength int `json:"max_quick_reply_length"`
+
+	// Templates is whether messages can be sent using pre-approved templates
+	Templates bool `json:"templates"`
+
+	// Location is whether locations can be sent as geo attachments
+	Location bool `json:"location"`
+}
+
+// CapabilityDescriber is the interface handlers which describe their capabilities should satisfy, outgoing messages on
+// the channel types of other handlers are sent as is
+type CapabilityDescriber interface {
+	Capabilities() *Capabilities
+}
+
+// SupportsAttachmentType returns whether attachments of the passed in content type can be sent
+func (c *Capabilities) SupportsAttachmentType(contentType string) bool {
+	if contentType == "geo" {
+		return c.Location
+	}
+
+	for _, supported := range c.AttachmentTypes {
+		if supported == "*/*" || supported == contentType {
+			return true
+		}
+		if strings.HasSuffix(supported, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(supported, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// TextParts returns the number of parts the passed in text will be split into when sent on the passed in channel,
+// lengths being in characters rather than bytes
+func (c *Capabilities) TextParts(channel Channel, text string) int {
+	max := channel.IntConfigForKey(ConfigMaxLength, c.MaxTextLength)
+	length := utf8.RuneCountInString(text)
+	if max <= 0 || length <= max {
+		return 1
+	}
+	return (length + max - 1) / max
+}
+
+// GetCapabilities returns the capabilities of the active handler for the passed in channel type, or nil if that
+// handler doesn't describe them
+func GetCapabilities(channelType ChannelType) *Capabilities {
+	handler, found := activeHandlers[channelType]
+	if !found {
+		return nil
+	}
+
+	describer, isDescriber := handler.(CapabilityDescriber)
+	if !isDescriber {
+		return nil
+	}
+	return describer.Capabilities()
+}
+
+// AttachmentSizeFunc is the function used to look up the size in bytes of attachments, it returns -1 if the size
+// can't be determined
+var AttachmentSizeFunc = fetchAttachmentSize
+
+func fetchAttachmentSize(ctx context.Context, url string) int {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return -1
+	}
+	rr, err := utils.MakeHTTPRequest(req.WithContext(ctx))
+	if err != nil {
+		return -1
+	}
+	return rr.ContentLength
+}
+
+// splitAttachment splits the passed in attachment into its content type and URL
+func splitAttachment(attachment string) (string, string) {
+	parts := strings.SplitN(attachment, ":", 2)
+	if len(parts) < 2 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// adaptedMsg wraps an outgoing message so that its content fits the capabilities of its channel
+type adaptedMsg struct {
+	Msg
+	text         string
+	attachments  []string
+	quickReplies []string
+}
+
+func (m *adaptedMsg) Text() string           { return m.text }
+func (m *adaptedMsg) Attachments() []string  { return m.attachments }
+func (m *adaptedMsg) QuickReplies() []string { return m.quickReplies }
+
+// AdaptMsg checks the passed in message against the passed in capabilities. Content which isn't supported is adapted
+// where possible, attachments being replaced by links in the text and quick replies being trimmed, and a description
+// of each change is returned. An error is returned if the message can't be sent on the channel at all.
+func AdaptMsg(ctx context.Context, caps *Capabilities, msg Msg) (Msg, []string, error) {
+	channel := msg.Channel()
+
+	if !caps.Templates && len(msg.Metadata()) > 0 {
+		if _, _, _, err := jsonparser.Get(msg.Metadata(), MetadataTemplating); err == nil {
+			return nil, nil, NewSendError(SendErrorTemplate, "%s channels can't send template messages", channel.ChannelType())
+		}
+	}
+
+	changes := make([]string, 0)
+	text := msg.Text()
+
+	// work out which attachments we can send, the rest are sent as links
+	attachments := make([]string, 0, len(msg.Attachments()))
+	links := make([]string, 0)
+	for _, attachment := range msg.Attachments() {
+		contentType, url := splitAttachment(attachment)
+
+		if !caps.SupportsAttachmentType(contentType) {
+			changes = append(changes, fmt.Sprintf("attachment of type %s not supported, sent as link: %s", contentType, url))
+			links = append(links, url)
+			continue
+		}
+		if caps.MaxAttachments > 0 && len(attachments) >= caps.MaxAttachments {
+			changes = append(changes, fmt.Sprintf("more than %d attachments, sent as link: %s", caps.MaxAttachments, url))
+			links = append(links, url)
+			continue
+		}
+		if caps.MaxAttachmentSize > 0 && contentType != "geo" {
+			if size := AttachmentSizeFunc(ctx, url); size > caps.MaxAttachmentSize {
+				changes = append(changes, fmt.Sprintf("attachment of %d bytes larger than %d bytes, sent as link: %s", size, caps.MaxAttachmentSize, url))
+				links = append(links, url)
+				continue
+			}
+		}
+		attachments = append(attachments, attachment)
+	}
+	if len(links) > 0 {
+		text = strings.TrimSpace(strings.Join(append([]string{text}, links...), "\n"))
+	}
+
+	// numbered menus render quick replies in the text so those are left alone
+	quickReplies := msg.QuickReplies()
+	if len(quickReplies) > 0 && !channel.BoolConfigForKey(ConfigNumberedMenu, false) {
+		if !caps.QuickReplies {
+			changes = append(changes, fmt.Sprintf("quick replies not supported, dropped %d", len(quickReplies)))
+			quickReplies = nil
+		} else {
+			if caps.MaxQuickReplies > 0 && len(quickReplies) > caps.MaxQuickReplies {
+				changes = append(changes, fmt.Sprintf("more than %d quick replies, dropped %d", caps.MaxQuickReplies, len(quickReplies)-caps.MaxQuickReplies))
+				quickReplies = quickReplies[:caps.MaxQuickReplies]
+			}
+			if caps.MaxQuickReplyLength > 0 {
+				trimmed := make([]string, len(quickReplies))
+				for i, reply := range quickReplies {
+					trimmed[i] = reply
+					if runes := []rune(reply); len(runes) > caps.MaxQuickReplyLength {
+						trimmed[i] = string(runes[:caps.MaxQuickReplyLength])
+						changes = append(changes, fmt.Sprintf("quick reply '%s' longer than %d characters, truncated", reply, caps.MaxQuickReplyLength))
+					}
+				}
+				quickReplies = trimmed
+			}
+		}
+	}
+
+	if caps.MaxTextParts > 0 {
+		if parts := caps.TextParts(channel, text); parts > caps.MaxTextParts {
+			return nil, nil, NewSendError(SendErrorContent, "message text of %d characters needs %d parts, more than the %d allowed on %s channels", utf8.RuneCountInString(text), parts, caps.MaxTextParts, channel.ChannelType())
+		}
+	}
+
+	if len(changes) == 0 {
+		return msg, nil, nil
+	}
+	return &adaptedMsg{Msg: msg, text: text, attachments: attachments, quickReplies: quickReplies}, changes, nil
+}
+
+// NewChannelLogForAdaptation creates a new channel log describing the changes made to a message to fit its channel
+func NewChannelLogForAdaptation(msg Msg, changes []string) *ChannelLog {
+	return NewChannelLog("Message Adapted", msg.Channel(), msg.ID(), "", "", NilStatusCode, "", strings.Join(changes, "\n"), time.Duration(0), nil)
+}
+
+// capabilitiesResponse is what we return from our capabilities endpoint
+type capabilitiesResponse struct {
+	ChannelType     ChannelType      `json:"channel_type"`
+	Declared        bool             `json:"declared"`
+	Capabilities    *Capabilities    `json:"capabilities,omitempty"`
+	ProviderOptions []ProviderOption `json:"provider_options,omitempty"`
+}
+
+func (s *server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	channelType := ChannelType(strings.ToUpper(chi.URLParam(r, "type")))
+	handler, found := activeHandlers[channelType]
+	if !found {
+		WriteDataResponse(r.Context(), w, http.StatusNotFound, "Not Found", []interface{}{NewErrorData(fmt.Sprintf("unknown channel type: %s", channelType))})
+		return
+	}
+
+	caps := GetCapabilities(channelType)
+	response := &capabilitiesResponse{ChannelType: channelType, Declared: caps != nil, Capabilities: caps}
+	if describer, isDescriber := handler.(ProviderOptionsDescriber); isDescriber {
+		response.ProviderOptions = describer.ProviderOptions()
+	}
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", []interface{}{response})
+}
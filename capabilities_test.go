@@ -0,0 +1,146 @@
+package courier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportsAttachmentType(t *testing.T) {
+	caps := &Capabilities{AttachmentTypes: []string{"image/*", "application/pdf"}}
+	assert.True(t, caps.SupportsAttachmentType("image/jpeg"))
+	assert.True(t, caps.SupportsAttachmentType("application/pdf"))
+	assert.False(t, caps.SupportsAttachmentType("application/zip"))
+	assert.False(t, caps.SupportsAttachmentType("video/mp4"))
+	assert.False(t, caps.SupportsAttachmentType("geo"))
+
+	caps = &Capabilities{AttachmentTypes: []string{"*/*"}, Location: true}
+	assert.True(t, caps.SupportsAttachmentType("video/mp4"))
+	assert.True(t, caps.SupportsAttachmentType("geo"))
+}
+
+func TestAdaptMsg(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "2020", "US", map[string]interface{}{})
+	ctx := context.Background()
+
+	defer func() { AttachmentSizeFunc = fetchAttachmentSize }()
+	AttachmentSizeFunc = func(ctx context.Context, url string) int {
+		if strings.Contains(url, "big") {
+			return 2000
+		}
+		return 100
+	}
+
+	caps := &Capabilities{
+		MaxTextLength:       160,
+		MaxTextParts:        2,
+		AttachmentTypes:     []string{"image/*"},
+		MaxAttachments:      1,
+		MaxAttachmentSize:   1000,
+		QuickReplies:        true,
+		MaxQuickReplies:     2,
+		MaxQuickReplyLength: 5,
+	}
+
+	// messages which fit are passed through as is
+	msg := &mockMsg{channel: channel, text: "Hello", attachments: []string{"image/jpeg:https://foo.bar/a.jpg"}, quickReplies: []string{"Yes", "No"}}
+	adapted, changes, err := AdaptMsg(ctx, caps, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+	assert.Equal(t, msg, adapted)
+
+	// otherwise unsupported content is replaced or trimmed
+	msg = &mockMsg{
+		channel:      channel,
+		text:         "Hello",
+		attachments:  []string{"image/jpeg:https://foo.bar/big.jpg", "video/mp4:https://foo.bar/b.mp4", "image/jpeg:https://foo.bar/a.jpg", "image/png:https://foo.bar/c.png"},
+		quickReplies: []string{"Yes", "Maybe later", "No"},
+	}
+	adapted, changes, err = AdaptMsg(ctx, caps, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello\nhttps://foo.bar/big.jpg\nhttps://foo.bar/b.mp4\nhttps://foo.bar/c.png", adapted.Text())
+	assert.Equal(t, []string{"image/jpeg:https://foo.bar/a.jpg"}, adapted.Attachments())
+	assert.Equal(t, []string{"Yes", "Maybe"}, adapted.QuickReplies())
+	assert.Equal(t, msg.ID(), adapted.ID())
+	assert.Equal(t, []string{
+		"attachment of 2000 bytes larger than 1000 bytes, sent as link: https://foo.bar/big.jpg",
+		"attachment of type video/mp4 not supported, sent as link: https://foo.bar/b.mp4",
+		"more than 1 attachments, sent as link: https://foo.bar/c.png",
+		"more than 2 quick replies, dropped 1",
+		"quick reply 'Maybe later' longer than 5 characters, truncated",
+	}, changes)
+
+	// quick replies are dropped on channels which can't send them, unless they're sent as a numbered menu
+	msg = &mockMsg{channel: channel, text: "Hello", quickReplies: []string{"Yes", "No"}}
+	adapted, changes, err = AdaptMsg(ctx, &Capabilities{}, msg)
+	assert.NoError(t, err)
+	assert.Nil(t, adapted.QuickReplies())
+	assert.Equal(t, []string{"quick replies not supported, dropped 2"}, changes)
+
+	menuChannel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "2020", "US", map[string]interface{}{ConfigNumberedMenu: true})
+	msg = &mockMsg{channel: menuChannel, text: "Hello", quickReplies: []string{"Yes", "No"}}
+	adapted, _, err = AdaptMsg(ctx, &Capabilities{}, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Yes", "No"}, adapted.QuickReplies())
+
+	// text which needs too many parts can't be sent
+	msg = &mockMsg{channel: channel, text: strings.Repeat("x", 400)}
+	_, _, err = AdaptMsg(ctx, caps, msg)
+	assert.EqualError(t, err, "content_rejected: message text of 400 characters needs 3 parts, more than the 2 allowed on DM channels")
+
+	// unless the channel has a longer max length configured
+	longChannel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "2020", "US", map[string]interface{}{ConfigMaxLength: 320})
+	msg = &mockMsg{channel: longChannel, text: strings.Repeat("x", 400)}
+	_, _, err = AdaptMsg(ctx, caps, msg)
+	assert.NoError(t, err)
+
+	// neither can templates on channels which don't support them
+	msg = &mockMsg{channel: channel, text: "Hello", metadata: json.RawMessage(`{"templating":{"template":{"name":"hello"}}}`)}
+	_, _, err = AdaptMsg(ctx, caps, msg)
+	assert.EqualError(t, err, "template_invalid: DM channels can't send template messages")
+}
+
+func TestTextParts(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "2020", "US", map[string]interface{}{})
+	caps := &Capabilities{MaxTextLength: 10}
+
+	assert.Equal(t, 1, caps.TextParts(channel, ""))
+	assert.Equal(t, 1, caps.TextParts(channel, "Hello"))
+	assert.Equal(t, 2, caps.TextParts(channel, "Hello World"))
+
+	// lengths are in characters, not bytes
+	assert.Equal(t, 1, caps.TextParts(channel, "ሰላም ዓለም ☺"))
+	assert.Equal(t, 1, caps.TextParts(channel, "😀😀😀😀😀😀😀😀😀😀"))
+	assert.Equal(t, 2, caps.TextParts(channel, "😀😀😀😀😀😀😀😀😀😀😀"))
+
+	// and text of any length fits on channels without a max length
+	assert.Equal(t, 1, (&Capabilities{}).TextParts(channel, strings.Repeat("x", 1000)))
+}
+
+func TestHandleCapabilities(t *testing.T) {
+	defer delete(activeHandlers, "DM")
+	activeHandlers["DM"] = NewHandler()
+
+	s := &server{}
+	router := chi.NewRouter()
+	router.Get("/c/{type:[a-zA-Z0-9]+}/capabilities", s.handleCapabilities)
+
+	// channel types are matched regardless of case, as they are in our other routes
+	for _, path := range []string{"/c/DM/capabilities", "/c/dm/capabilities"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, "status mismatch for %s", path)
+		assert.Contains(t, rr.Body.String(), `"channel_type":"DM"`)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/c/xx/capabilities", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unknown channel type: XX")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/nyaruka/gocommon/uuids"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
 )
 
 // allows queuing a task to celery (with a redis backend)
@@ -78,7 +79,8 @@ func QueueEmptyTask(rc redis.Conn, queueName string, taskName string) error {
 		return err
 	}
 
-	rc.Send("lpush", queueName, string(taskJSON))
+	// the queue belongs to RapidPro so our key prefix isn't applied, but in cluster mode it needs our hash tag
+	rc.Send("lpush", utils.RedisSharedKey(queueName), string(taskJSON))
 	return nil
 }
 
@@ -55,6 +55,82 @@ const (
 
 	// ConfigSendHeaders is a constant key for channel configs
 	ConfigSendHeaders = "headers"
+
+	// ConfigTelPolicy is the policy used to normalize the phone numbers of incoming messages
+	ConfigTelPolicy = "tel_policy"
+
+	// ConfigKeywords enables opt-out and opt-in keyword handling on incoming messages, can also be set on the org
+	ConfigKeywords = "keywords"
+
+	// ConfigOptOutKeywords is the list of keywords which stop a contact, can also be set on the org
+	ConfigOptOutKeywords = "opt_out_keywords"
+
+	// ConfigOptInKeywords is the list of keywords which send the opt-in reply, can also be set on the org. No event is
+	// written for them as RapidPro reactivates a stopped contact when it handles any message from them.
+	ConfigOptInKeywords = "opt_in_keywords"
+
+	// ConfigOptOutReply is the confirmation sent to a contact who opts out, can also be set on the org
+	ConfigOptOutReply = "opt_out_reply"
+
+	// ConfigOptInReply is the confirmation sent to a contact who opts in, can also be set on the org
+	ConfigOptInReply = "opt_in_reply"
+
+	// ConfigVerifySecret is a shared secret incoming requests for the channel must include
+	ConfigVerifySecret = "verify_secret"
+
+	// ConfigVerifySecretHeader is the header the shared secret is read from, defaults to X-Webhook-Secret
+	ConfigVerifySecretHeader = "verify_secret_header"
+
+	// ConfigVerifySecretParam is the query parameter the shared secret is read from, used instead of a header if set
+	ConfigVerifySecretParam = "verify_secret_param"
+
+	// ConfigVerifyHMACKey is the key incoming request bodies must be signed with
+	ConfigVerifyHMACKey = "verify_hmac_key"
+
+	// ConfigVerifyHMACAlgorithm is the hash used for the body signature, one of sha1, sha256 (default) or sha512
+	ConfigVerifyHMACAlgorithm = "verify_hmac_algorithm"
+
+	// ConfigVerifyHMACHeader is the header the body signature is read from, defaults to X-Signature
+	ConfigVerifyHMACHeader = "verify_hmac_header"
+
+	// ConfigVerifyUsername is the username incoming requests must use for basic auth
+	ConfigVerifyUsername = "verify_username"
+
+	// ConfigVerifyPassword is the password incoming requests must use for basic auth
+	ConfigVerifyPassword = "verify_password"
+
+	// ConfigVerifyAllowedIPs is the list of IPs or CIDRs incoming requests must come from
+	ConfigVerifyAllowedIPs = "verify_allowed_ips"
+
+	// ConfigInboundURNLimit overrides the maximum number of incoming messages from a single URN in the flood window
+	ConfigInboundURNLimit = "inbound_urn_limit"
+
+	// ConfigInboundChannelLimit overrides the maximum number of incoming messages on the channel in the flood window
+	ConfigInboundChannelLimit = "inbound_channel_limit"
+
+	// ConfigInboundFloodAction overrides what is done with incoming messages over the flood limits
+	ConfigInboundFloodAction = "inbound_flood_action"
+
+	// ConfigPolling enables polling for incoming messages on channels whose handler supports it
+	ConfigPolling = "polling"
+
+	// ConfigNumberedMenu sends quick replies as a numbered menu in the message text and maps numeric replies back
+	ConfigNumberedMenu = "numbered_menu"
+
+	// ConfigNumberedMenuSegments is the number of SMS segments a message with a numbered menu can use, defaults to 1
+	ConfigNumberedMenuSegments = "numbered_menu_segments"
+
+	// ConfigBulkSendingHours is the windows on each weekday in which bulk messages can be sent, can also be set on the org
+	ConfigBulkSendingHours = "bulk_sending_hours"
+
+	// ConfigBulkSendingTimezone is the timezone of the bulk sending hours, defaults to one for the channel's country
+	ConfigBulkSendingTimezone = "bulk_sending_timezone"
+
+	// ConfigSendWeight is the weight of an org when sharing senders between orgs, set on the org and defaults to 1
+	ConfigSendWeight = "send_weight"
+
+	// ConfigMaxTPS is the maximum number of messages per second sent on the channel, used when queueing messages
+	ConfigMaxTPS = "max_tps"
 )
 
 // ChannelType is our typing of the two char channel types
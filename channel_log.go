@@ -113,6 +113,12 @@ func (l *ChannelLog) WithError(description string, err error) *ChannelLog {
 	return l
 }
 
+// WithSegments records the number of SMS segments the request for this log was sent as
+func (l *ChannelLog) WithSegments(segments int) *ChannelLog {
+	l.Segments = segments
+	return l
+}
+
 func (l *ChannelLog) String() string {
 	return fmt.Sprintf("%s: %d %s %d\n%s\n%s\n%s", l.Description, l.StatusCode, l.URL, l.Elapsed, l.Error, l.Request, l.Response)
 }
@@ -132,4 +138,5 @@ type ChannelLog struct {
 	Response    string
 	Elapsed     time.Duration
 	CreatedOn   time.Time
+	Segments    int
 }
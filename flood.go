@@ -0,0 +1,155 @@
+package courier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/urns"
+)
+
+// FloodAction is what we do with incoming messages which exceed our flood limits
+type FloodAction string
+
+// Possible values for FloodAction
+const (
+	// FloodActionDrop drops the message, logging that we did so
+	FloodActionDrop = FloodAction("drop")
+
+	// FloodActionFlag accepts the message but marks it as flagged in its metadata
+	FloodActionFlag = FloodAction("flag")
+
+	// FloodActionBlock drops the message and blocks the URN on the channel for a period, blocks only apply to
+	// per URN limits, when a per channel limit is exceeded the message is dropped instead
+	FloodActionBlock = FloodAction("block")
+)
+
+// FloodFlaggedKey is the metadata key set on incoming messages which were flagged as part of a flood
+const FloodFlaggedKey = "flood_flagged"
+
+const floodBlocksKey = "flood:blocks"
+
+// FloodBlock is a URN which is currently blocked from sending messages to a channel
+type FloodBlock struct {
+	ChannelUUID ChannelUUID `json:"channel_uuid"`
+	URN         urns.URN    `json:"urn"`
+	Expires     time.Time   `json:"expires_on"`
+}
+
+// FloodCheck is the result of checking an incoming message against our flood limits
+type FloodCheck struct {
+	Action FloodAction
+	Reason string
+}
+
+var luaFloodWindow = redis.NewScript(1, `-- KEYS: [Key] ARGV: [EpochMS, WindowMS, Member]
+	-- remove anything which has fallen out of our window
+	redis.call("zremrangebyscore", KEYS[1], "-inf", tonumber(ARGV[1]) - tonumber(ARGV[2]))
+
+	-- add ourselves and return the count
+	redis.call("zadd", KEYS[1], ARGV[1], ARGV[3])
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return redis.call("zcard", KEYS[1])
+`)
+
+// CheckInboundFlood records the passed in incoming message against our sliding window limits for its channel and
+// URN, returning what action should be taken with it, if any. Limits can be overridden per channel.
+func CheckInboundFlood(rc redis.Conn, config *Config, msg Msg) (*FloodCheck, error) {
+	channel := msg.Channel()
+	blockKey := floodBlockMember(channel.UUID(), msg.URN())
+
+	// if this URN is blocked, nothing else to check
+	expires, err := redis.Int64(rc.Do("ZSCORE", utils.RedisKey(floodBlocksKey), blockKey))
+	if err != nil && err != redis.ErrNil {
+		return nil, err
+	}
+	if err == nil && expires > time.Now().Unix() {
+		return &FloodCheck{Action: FloodActionBlock, Reason: "urn is blocked"}, nil
+	}
+
+	action := FloodAction(channel.StringConfigForKey(ConfigInboundFloodAction, config.InboundFloodAction))
+	urnLimit := channel.IntConfigForKey(ConfigInboundURNLimit, config.InboundURNLimit)
+	channelLimit := channel.IntConfigForKey(ConfigInboundChannelLimit, config.InboundChannelLimit)
+	window := time.Duration(config.InboundFloodWindow) * time.Second
+
+	// each message needs a unique member in our windows
+	member := NewMsgUUID().String()
+
+	if urnLimit > 0 {
+		count, err := floodWindowCount(rc, utils.RedisKey("flood:%s:%s", channel.UUID(), msg.URN().Identity()), window, member)
+		if err != nil {
+			return nil, err
+		}
+		if count > urnLimit {
+			check := &FloodCheck{Action: action, Reason: fmt.Sprintf("urn exceeded limit of %d messages in %s", urnLimit, window)}
+			if action == FloodActionBlock {
+				_, err := rc.Do("ZADD", utils.RedisKey(floodBlocksKey), time.Now().Add(time.Duration(config.InboundBlockDuration)*time.Second).Unix(), blockKey)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return check, nil
+		}
+	}
+
+	if channelLimit > 0 {
+		count, err := floodWindowCount(rc, utils.RedisKey("flood:%s", channel.UUID()), window, member)
+		if err != nil {
+			return nil, err
+		}
+		if count > channelLimit {
+			if action == FloodActionBlock {
+				action = FloodActionDrop
+			}
+			return &FloodCheck{Action: action, Reason: fmt.Sprintf("channel exceeded limit of %d messages in %s", channelLimit, window)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetFloodBlocks returns all the currently active URN blocks
+func GetFloodBlocks(rc redis.Conn) ([]*FloodBlock, error) {
+	// clear out any expired blocks first
+	now := time.Now().Unix()
+	_, err := rc.Do("ZREMRANGEBYSCORE", utils.RedisKey(floodBlocksKey), "-inf", now)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := redis.Int64Map(rc.Do("ZRANGE", utils.RedisKey(floodBlocksKey), 0, -1, "WITHSCORES"))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*FloodBlock, 0, len(values))
+	for member, expires := range values {
+		parts := strings.SplitN(member, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		channelUUID, err := NewChannelUUID(parts[0])
+		if err != nil {
+			continue
+		}
+		blocks = append(blocks, &FloodBlock{ChannelUUID: channelUUID, URN: urns.URN(parts[1]), Expires: time.Unix(expires, 0).UTC()})
+	}
+	return blocks, nil
+}
+
+// ClearFloodBlock clears any block on the passed in channel and URN, returning whether there was one
+func ClearFloodBlock(rc redis.Conn, channelUUID ChannelUUID, urn urns.URN) (bool, error) {
+	removed, err := redis.Int(rc.Do("ZREM", utils.RedisKey(floodBlocksKey), floodBlockMember(channelUUID, urn)))
+	return removed > 0, err
+}
+
+func floodBlockMember(channelUUID ChannelUUID, urn urns.URN) string {
+	return fmt.Sprintf("%s|%s", channelUUID, urn.Identity())
+}
+
+func floodWindowCount(rc redis.Conn, key string, window time.Duration, member string) (int, error) {
+	epochMS := time.Now().UnixNano() / int64(time.Millisecond)
+	return redis.Int(luaFloodWindow.Do(rc, key, epochMS, int64(window/time.Millisecond), member))
+}
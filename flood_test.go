@@ -0,0 +1,81 @@
+package courier
+
+import (
+	"testing"
+
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckInboundFlood(t *testing.T) {
+	mb := NewMockBackend()
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+
+	config := NewConfig()
+	config.InboundURNLimit = 2
+	config.InboundChannelLimit = 2
+
+	urn1 := urns.URN("tel:+12065551212")
+	urn2 := urns.URN("tel:+12065551313")
+
+	dropper := NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", nil)
+	rc.Do("DEL", "flood:"+dropper.UUID().String(), "flood:"+dropper.UUID().String()+":"+string(urn1.Identity()))
+
+	check, err := CheckInboundFlood(rc, config, mb.NewIncomingMsg(dropper, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Nil(t, check)
+
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(dropper, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Nil(t, check)
+
+	// third message from the same URN is over the limit
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(dropper, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, FloodActionDrop, check.Action)
+
+	// a different URN is fine until the channel limit is reached
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(dropper, urn2, "hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, FloodActionDrop, check.Action)
+
+	// channels can override the action
+	blocker := NewMockChannel("dbc126ed-66bc-4e28-b67b-81dc3327c95d", "KN", "2020", "US", map[string]interface{}{
+		ConfigInboundFloodAction: "block",
+		ConfigInboundURNLimit:    1,
+	})
+	rc.Do("DEL", "flood:"+blocker.UUID().String(), "flood:"+blocker.UUID().String()+":"+string(urn1.Identity()))
+	ClearFloodBlock(rc, blocker.UUID(), urn1)
+
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(blocker, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Nil(t, check)
+
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(blocker, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, FloodActionBlock, check.Action)
+
+	blocks, err := GetFloodBlocks(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(blocks))
+	assert.Equal(t, blocker.UUID(), blocks[0].ChannelUUID)
+	assert.Equal(t, urn1, blocks[0].URN)
+
+	// blocked URNs stay blocked even once they are back under the limit
+	check, err = CheckInboundFlood(rc, config, mb.NewIncomingMsg(blocker, urn1, "hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, "urn is blocked", check.Reason)
+
+	cleared, err := ClearFloodBlock(rc, blocker.UUID(), urn1)
+	assert.NoError(t, err)
+	assert.True(t, cleared)
+
+	cleared, err = ClearFloodBlock(rc, blocker.UUID(), urn1)
+	assert.NoError(t, err)
+	assert.False(t, cleared)
+
+	blocks, err = GetFloodBlocks(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(blocks))
+}
@@ -39,6 +39,17 @@ type MediaDownloadRequestBuilder interface {
 	BuildDownloadMediaRequest(context.Context, Backend, Channel, string) (*http.Request, error)
 }
 
+// PollingChannelHandler is the interface handlers which can pull incoming messages from their provider, rather than
+// waiting for webhooks, should satisfy. Courier polls each channel of the handler's type which has polling enabled in
+// its config, only one courier instance will poll a given channel at a time.
+type PollingChannelHandler interface {
+	ChannelHandler
+
+	// PollChannel fetches and writes any events for the passed in channel after the passed in cursor, returning the
+	// events written, logs for any requests made and the cursor the next poll should start from
+	PollChannel(ctx context.Context, channel Channel, cursor string) ([]Event, []*ChannelLog, string, error)
+}
+
 // RegisterHandler adds a new handler for a channel type, this is called by individual handlers when they are initialized
 func RegisterHandler(handler ChannelHandler) {
 	registeredHandlers[handler.ChannelType()] = handler
@@ -49,5 +60,14 @@ func GetHandler(ct ChannelType) ChannelHandler {
 	return registeredHandlers[ct]
 }
 
+// GetActiveChannelTypes returns the channel types of the handlers this courier has initialized
+func GetActiveChannelTypes() []ChannelType {
+	types := make([]ChannelType, 0, len(activeHandlers))
+	for ct := range activeHandlers {
+		types = append(types, ct)
+	}
+	return types
+}
+
 var registeredHandlers = make(map[ChannelType]ChannelHandler)
 var activeHandlers = make(map[ChannelType]ChannelHandler)
@@ -116,6 +116,7 @@ func (h *handler) SendMsg(_ context.Context, msg courier.Msg) (courier.MsgStatus
 		if response.Code == "204" {
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(response.MessageID)
+			status.AddPartExternalID(response.MessageID)
 		} else {
 			status.SetStatus(courier.MsgFailed)
 			log.WithError("Message Send Error", fmt.Errorf("Received invalid response code: %s", response.Code))
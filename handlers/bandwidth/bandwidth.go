@@ -61,7 +61,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	var urn urns.URN
-	urn, err = urns.NewURNFromParts(urns.TelScheme, payload[0].Message.From, "", "")
+	urn, err = handlers.NormalizeTelWithPolicy(channel, payload[0].Message.From, channel.Country(), handlers.TelPolicyAlphanumeric)
 
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
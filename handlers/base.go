@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/nyaruka/courier"
@@ -88,6 +89,19 @@ func (h *BaseHandler) WriteRequestIgnored(ctx context.Context, w http.ResponseWr
 	return courier.WriteIgnored(ctx, w, r, details)
 }
 
+// GetProviderOptions returns the provider options of the passed in message validated against the passed in supported
+// options. If they are invalid a failed status for the message is returned instead as retrying won't fix them.
+func (h *BaseHandler) GetProviderOptions(msg courier.Msg, supported []courier.ProviderOption) (courier.ProviderOptions, courier.MsgStatus) {
+	options, err := courier.GetProviderOptions(msg, supported)
+	if err != nil {
+		status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgFailed)
+		status.SetErrorCode(courier.SendErrorContent)
+		status.AddLog(courier.NewChannelLogFromError("Invalid Provider Options", msg.Channel(), msg.ID(), time.Duration(0), err))
+		return nil, status
+	}
+	return options, nil
+}
+
 func (h *BaseHandler) PurgeOutgoing(ctx context.Context, channel courier.Channel) error {
 	return nil
 }
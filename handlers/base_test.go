@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/nyaruka/courier"
@@ -72,3 +73,21 @@ func TestSplitMsgByChannel(t *testing.T) {
 	assert.Equal([]string{" "}, SplitMsgByChannel(channelWithMaxLength, " ", 20))
 	assert.Equal([]string{"This is a message", "longer than 10"}, SplitMsgByChannel(channelWithMaxLength, "This is a message   longer than 10", 20))
 }
+
+func TestSplitSMSByChannel(t *testing.T) {
+	assert := assert.New(t)
+	var channelWithMaxLength = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "AC", "2020", "US",
+		map[string]interface{}{
+			courier.ConfigMaxLength: 25,
+		})
+	var channelWithoutMaxLength = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "AC", "2020", "US", nil)
+
+	long := strings.Repeat("hello ", 30)
+
+	assert.Equal([]string{"Simple message"}, SplitSMSByChannel(channelWithoutMaxLength, "Simple message", 1))
+	assert.Equal(2, len(SplitSMSByChannel(channelWithoutMaxLength, long, 1)))
+	assert.Equal(1, len(SplitSMSByChannel(channelWithoutMaxLength, long, 2)))
+
+	// a configured max length takes precedence over segments
+	assert.Equal([]string{"This is a message longer", "than 10"}, SplitSMSByChannel(channelWithMaxLength, "This is a message longer than 10", 1))
+}
@@ -91,7 +91,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -183,6 +183,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		externalID, _ := jsonparser.GetString([]byte(rr.Body), "results", "[0]", "msgid")
 		status.SetStatus(courier.MsgWired)
 		status.SetExternalID(externalID)
+		status.AddPartExternalID(externalID)
 
 	}
 	return status, nil
@@ -105,6 +105,7 @@ func (h *handler) SendMsg(_ context.Context, msg courier.Msg) (courier.MsgStatus
 		if response.MessageID != 0 {
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(fmt.Sprintf("%d", response.MessageID))
+			status.AddPartExternalID(fmt.Sprintf("%d", response.MessageID))
 		} else {
 			status.SetStatus(courier.MsgFailed)
 			log.WithError("Message Send Error", fmt.Errorf("Received invalid message id: %d", response.MessageID))
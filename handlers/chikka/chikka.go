@@ -90,7 +90,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 		date := time.Unix(0, int64(form.Timestamp*1000000000)).UTC()
 
 		// create our URN
-		urn, err := handlers.StrictTelForCountry(form.MobileNumber, channel.Country())
+		urn, err := handlers.NormalizeTelForChannel(channel, form.MobileNumber)
 		if err != nil {
 			return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 		}
@@ -138,7 +138,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(payload.FromNumber, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, payload.FromNumber)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -211,6 +211,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		} else {
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(externalID)
+			status.AddPartExternalID(externalID)
 		}
 	}
 
@@ -20,7 +20,7 @@ var failSendResponse = `{"messages":[],"error":"Two-Way integration error - From
 var defaultSendTestCases = []ChannelSendTestCase{
 	{Label: "Plain Send",
 		Text: "Simple Message", URN: "tel:+250788383383",
-		Status: "W", ExternalID: "id1002",
+		Status: "W", ExternalID: "id1002", PartExternalIDs: []string{"id1002"},
 		URLParams:    map[string]string{"content": "Simple Message", "to": "250788383383", "from": "2020", "apiKey": "API-KEY"},
 		ResponseBody: successSendResponse, ResponseStatus: 200,
 		SendPrep: setSendURL},
@@ -72,7 +72,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(payload.Mobile, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, payload.Mobile)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -116,6 +116,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		}
 
 		status.SetExternalID(id)
+		status.AddPartExternalID(id)
 		status.SetStatus(courier.MsgWired)
 	}
 
@@ -69,7 +69,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.Original, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.Original)
 	if err != nil {
 		urn, err = urns.NewURNFromParts(urns.ExternalScheme, form.Original, "", "")
 		if err != nil {
@@ -63,7 +63,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.MSISDN, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.MSISDN)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -156,6 +156,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		if i == 0 {
 			status.SetExternalID(externalID)
 		}
+		status.AddPartExternalID(externalID)
 
 		// this was wired successfully
 		status.SetStatus(courier.MsgWired)
@@ -15,8 +15,8 @@ import (
 
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
-	"github.com/nyaruka/gocommon/gsm7"
 	"github.com/nyaruka/gocommon/urns"
 
 	"github.com/antchfx/xmlquery"
@@ -39,9 +39,6 @@ const (
 	configMOResponse            = "mo_response"
 
 	configMTResponseCheck = "mt_response_check"
-	configEncoding        = "encoding"
-	encodingDefault       = "D"
-	encodingSmart         = "S"
 )
 
 var defaultFromFields = []string{"from", "sender"}
@@ -104,7 +101,7 @@ func (h *handler) receiveStopContact(ctx context.Context, channel courier.Channe
 	// create our URN
 	urn := urns.NilURN
 	if channel.Schemes()[0] == urns.TelScheme {
-		urn, err = handlers.StrictTelForCountry(form.From, channel.Country())
+		urn, err = handlers.NormalizeTelForChannel(channel, form.From)
 	} else {
 		urn, err = urns.NewURNFromParts(channel.Schemes()[0], form.From, "", "")
 	}
@@ -206,7 +203,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	// create our URN
 	urn := urns.NilURN
 	if channel.Schemes()[0] == urns.TelScheme {
-		urn, err = handlers.StrictTelForCountry(from, channel.Country())
+		urn, err = handlers.NormalizeTelForChannel(channel, from)
 	} else {
 		urn, err = urns.NewURNFromParts(channel.Schemes()[0], from, "", "")
 	}
@@ -280,8 +277,8 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		return nil, fmt.Errorf("no send url set for EX channel")
 	}
 
-	// figure out what encoding to tell kannel to send as
-	encoding := msg.Channel().StringConfigForKey(configEncoding, encodingDefault)
+	// figure out what encoding to send as
+	encoding := msg.Channel().StringConfigForKey(sms.ConfigEncoding, sms.EncodingModeDefault)
 	responseContent := msg.Channel().StringConfigForKey(configMTResponseCheck, "")
 	sendMethod := msg.Channel().StringConfigForKey(courier.ConfigSendMethod, http.MethodPost)
 	sendBody := msg.Channel().StringConfigForKey(courier.ConfigSendBody, "")
@@ -317,11 +314,8 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		}
 
 		// if we are smart, first try to convert to GSM7 chars
-		if encoding == encodingSmart {
-			replaced := gsm7.ReplaceSubstitutions(part)
-			if gsm7.IsValid(replaced) {
-				form["text"] = replaced
-			}
+		if encoding == sms.EncodingModeSmart {
+			form["text"], _ = sms.Encode(part, encoding)
 		}
 
 		formEncoded := encodeVariables(form, contentURLEncoded)
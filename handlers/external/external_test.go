@@ -9,6 +9,7 @@ import (
 
 	"github.com/nyaruka/courier"
 	. "github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
 )
 
@@ -398,7 +399,7 @@ func TestSending(t *testing.T) {
 	var getSmartChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "EX", "2020", "US",
 		map[string]interface{}{
 			"send_path":              "?to={{to}}&text={{text}}&from={{from}}{{quick_replies}}",
-			configEncoding:           encodingSmart,
+			sms.ConfigEncoding:       sms.EncodingModeSmart,
 			courier.ConfigSendMethod: http.MethodGet})
 
 	var postChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "EX", "2020", "US",
@@ -418,7 +419,7 @@ func TestSending(t *testing.T) {
 		map[string]interface{}{
 			"send_path":              "",
 			courier.ConfigSendBody:   "to={{to}}&text={{text}}&from={{from}}{{quick_replies}}",
-			configEncoding:           encodingSmart,
+			sms.ConfigEncoding:       sms.EncodingModeSmart,
 			courier.ConfigSendMethod: http.MethodPost})
 
 	var jsonChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "EX", "2020", "US",
@@ -389,6 +389,9 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 			}
 
 			err := h.Backend().WriteMsg(ctx, event)
+			if err == courier.ErrMsgDropped {
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -567,6 +570,8 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			return status, nil
 		}
 
+		status.AddPartExternalID(externalID)
+
 		// if this is our first message, record the external id
 		if i == 0 {
 			status.SetExternalID(externalID)
@@ -127,6 +127,23 @@ type wacMedia struct {
 	Mimetype string `json:"mime_type"`
 	SHA256   string `json:"sha256"`
 }
+
+// wacConversation is the conversation a message sent on a cloud API channel was billed as part of
+type wacConversation struct {
+	ID     string `json:"id"`
+	Origin *struct {
+		Type string `json:"type"`
+	} `json:"origin"`
+	ExpirationTimestamp int64 `json:"expiration_timestamp"`
+}
+
+// wacPricing is how a message sent on a cloud API channel was priced
+type wacPricing struct {
+	PricingModel string `json:"pricing_model"`
+	Billable     bool   `json:"billable"`
+	Category     string `json:"category"`
+}
+
 type moPayload struct {
 	Object string `json:"object"`
 	Entry  []struct {
@@ -175,25 +192,26 @@ type moPayload struct {
 						Text    string `json:"text"`
 						Payload string `json:"payload"`
 					} `json:"button"`
+					Interactive *struct {
+						Type        string `json:"type"`
+						ButtonReply *struct {
+							ID    string `json:"id"`
+							Title string `json:"title"`
+						} `json:"button_reply"`
+						ListReply *struct {
+							ID    string `json:"id"`
+							Title string `json:"title"`
+						} `json:"list_reply"`
+					} `json:"interactive"`
 				} `json:"messages"`
 				Statuses []struct {
-					ID           string `json:"id"`
-					RecipientID  string `json:"recipient_id"`
-					Status       string `json:"status"`
-					Timestamp    string `json:"timestamp"`
-					Type         string `json:"type"`
-					Conversation *struct {
-						ID     string `json:"id"`
-						Origin *struct {
-							Type string `json:"type"`
-						} `json:"origin"`
-						ExpirationTimestamp int64 `json:"expiration_timestamp"`
-					} `json:"conversation"`
-					Pricing *struct {
-						PricingModel string `json:"pricing_model"`
-						Billable     bool   `json:"billable"`
-						Category     string `json:"category"`
-					} `json:"pricing"`
+					ID           string           `json:"id"`
+					RecipientID  string           `json:"recipient_id"`
+					Status       string           `json:"status"`
+					Timestamp    string           `json:"timestamp"`
+					Type         string           `json:"type"`
+					Conversation *wacConversation `json:"conversation"`
+					Pricing      *wacPricing      `json:"pricing"`
 				} `json:"statuses"`
 				Errors []struct {
 					Code  int    `json:"code"`
@@ -246,6 +264,9 @@ type moPayload struct {
 						} `json:"coordinates"`
 					}
 				} `json:"attachments"`
+				QuickReply *struct {
+					Payload string `json:"payload"`
+				} `json:"quick_reply"`
 			} `json:"message"`
 
 			Delivery *struct {
@@ -419,6 +440,7 @@ func (h *handler) processCloudWhatsAppPayload(ctx context.Context, channel couri
 
 				text := ""
 				mediaURL := ""
+				replyID := ""
 
 				if msg.Type == "text" {
 					text = msg.Text.Body
@@ -441,6 +463,14 @@ func (h *handler) processCloudWhatsAppPayload(ctx context.Context, channel couri
 					mediaURL, err = resolveMediaURL(channel, msg.Video.ID)
 				} else if msg.Type == "location" && msg.Location != nil {
 					mediaURL = fmt.Sprintf("geo:%f,%f", msg.Location.Latitude, msg.Location.Longitude)
+				} else if msg.Type == "interactive" && msg.Interactive != nil {
+					if msg.Interactive.Type == "button_reply" && msg.Interactive.ButtonReply != nil {
+						text = msg.Interactive.ButtonReply.Title
+						replyID = msg.Interactive.ButtonReply.ID
+					} else if msg.Interactive.ListReply != nil {
+						text = msg.Interactive.ListReply.Title
+						replyID = msg.Interactive.ListReply.ID
+					}
 				} else {
 					// we received a message type we do not support.
 					courier.LogRequestError(r, channel, fmt.Errorf("unsupported message type %s", msg.Type))
@@ -459,7 +489,14 @@ func (h *handler) processCloudWhatsAppPayload(ctx context.Context, channel couri
 					event.WithAttachment(mediaURL)
 				}
 
+				if replyID != "" {
+					event.WithMetadata(courier.NewInteractiveReplyMetadata(replyID))
+				}
+
 				err = h.Backend().WriteMsg(ctx, event)
+				if err == courier.ErrMsgDropped {
+					continue
+				}
 				if err != nil {
 					return nil, nil, err
 				}
@@ -484,6 +521,7 @@ func (h *handler) processCloudWhatsAppPayload(ctx context.Context, channel couri
 				}
 
 				event := h.Backend().NewMsgStatusForExternalID(channel, status.ID, msgStatus)
+				event.SetCost(newWACCost(status.Pricing, status.Conversation))
 				err := h.Backend().WriteMsgStatus(ctx, event)
 
 				// we don't know about this message, just tell them we ignored it
@@ -705,7 +743,15 @@ func (h *handler) processFacebookInstagramPayload(ctx context.Context, channel c
 				event.WithAttachment(attURL)
 			}
 
+			// record which quick reply was selected
+			if msg.Message.QuickReply != nil && msg.Message.QuickReply.Payload != "" {
+				event.WithMetadata(courier.NewInteractiveReplyMetadata(msg.Message.QuickReply.Payload))
+			}
+
 			err := h.Backend().WriteMsg(ctx, event)
+			if err == courier.ErrMsgDropped {
+				continue
+			}
 			if err != nil {
 				return nil, nil, err
 			}
@@ -776,17 +822,45 @@ type mtPayload struct {
 type mtAttachment struct {
 	Type    string `json:"type"`
 	Payload struct {
-		URL        string `json:"url"`
-		IsReusable bool   `json:"is_reusable"`
+		URL          string     `json:"url,omitempty"`
+		IsReusable   bool       `json:"is_reusable,omitempty"`
+		TemplateType string     `json:"template_type,omitempty"`
+		Text         string     `json:"text,omitempty"`
+		Buttons      []mtButton `json:"buttons,omitempty"`
 	} `json:"payload"`
 }
 
+type mtButton struct {
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
 type mtQuickReply struct {
 	Title       string `json:"title"`
 	Payload     string `json:"payload"`
 	ContentType string `json:"content_type"`
 }
 
+// the provider options we support on Facebook and Instagram channels
+var fbProviderOptions = []courier.ProviderOption{
+	{Name: "messaging_type", Type: courier.ProviderOptionString, Values: []string{"RESPONSE", "UPDATE", "MESSAGE_TAG"}},
+	{Name: "tag", Type: courier.ProviderOptionString, Values: []string{"CONFIRMED_EVENT_UPDATE", "POST_PURCHASE_UPDATE", "ACCOUNT_UPDATE", "HUMAN_AGENT"}},
+}
+
+// the provider options we support on WhatsApp Cloud channels
+var wacProviderOptions = []courier.ProviderOption{
+	{Name: "preview_url", Type: courier.ProviderOptionBool},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption {
+	if h.ChannelType() == "WAC" {
+		return wacProviderOptions
+	}
+	return fbProviderOptions
+}
+
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	if msg.Channel().ChannelType() == "FBA" || msg.Channel().ChannelType() == "IG" {
 		return h.sendFacebookInstagramMsg(ctx, msg)
@@ -817,6 +891,19 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 		payload.MessagingType = "UPDATE"
 	}
 
+	// provider options can override our message type and tag
+	options, failed := h.GetProviderOptions(msg, fbProviderOptions)
+	if failed != nil {
+		return failed, nil
+	}
+	if tag, found := options.String("tag"); found {
+		payload.MessagingType = "MESSAGE_TAG"
+		payload.Tag = tag
+	}
+	if messagingType, found := options.String("messaging_type"); found {
+		payload.MessagingType = messagingType
+	}
+
 	// build our recipient
 	if msg.URN().IsFacebookRef() {
 		payload.Recipient.UserRef = msg.URN().FacebookRef()
@@ -831,9 +918,37 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
 
+	text := msg.Text()
+	quickReplies := make([]mtQuickReply, 0, len(msg.QuickReplies()))
+	for _, qr := range msg.QuickReplies() {
+		quickReplies = append(quickReplies, mtQuickReply{qr, qr, "text"})
+	}
+
+	// interactive buttons and list rows are sent as quick replies with their ids as payloads, a call to action is
+	// sent as a button template
+	interactive := msg.Interactive()
+	var ctaTemplate *mtAttachment
+	if interactive != nil {
+		text = interactive.WrapText(text)
+		quickReplies = quickReplies[:0]
+		for _, button := range interactive.Buttons {
+			quickReplies = append(quickReplies, mtQuickReply{button.Title, button.ID, "text"})
+		}
+		if len(interactive.Buttons) == 0 && interactive.List != nil {
+			for _, row := range interactive.List.Rows() {
+				quickReplies = append(quickReplies, mtQuickReply{row.Title, row.ID, "text"})
+			}
+		}
+		if interactive.CTA != nil {
+			ctaTemplate = &mtAttachment{Type: "template"}
+			ctaTemplate.Payload.TemplateType = "button"
+			ctaTemplate.Payload.Buttons = []mtButton{{Type: "web_url", URL: interactive.CTA.URL, Title: interactive.CTA.Title}}
+		}
+	}
+
 	msgParts := make([]string, 0)
-	if msg.Text() != "" {
-		msgParts = handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
+	if text != "" {
+		msgParts = handlers.SplitMsgByChannel(msg.Channel(), text, maxMsgLength)
 	}
 
 	// send each part and each attachment separately. we send attachments first as otherwise quick replies
@@ -851,6 +966,11 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 			payload.Message.Attachment.Payload.URL = attURL
 			payload.Message.Attachment.Payload.IsReusable = true
 			payload.Message.Text = ""
+		} else if ctaTemplate != nil && i == (len(msgParts)+len(msg.Attachments()))-1 {
+			// the last msg part becomes the text of our call to action template
+			ctaTemplate.Payload.Text = msgParts[i-len(msg.Attachments())]
+			payload.Message.Attachment = ctaTemplate
+			payload.Message.Text = ""
 		} else {
 			// this is still a msg part
 			payload.Message.Text = msgParts[i-len(msg.Attachments())]
@@ -858,10 +978,8 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 		}
 
 		// include any quick replies on the last piece we send
-		if i == (len(msgParts)+len(msg.Attachments()))-1 {
-			for _, qr := range msg.QuickReplies() {
-				payload.Message.QuickReplies = append(payload.Message.QuickReplies, mtQuickReply{qr, qr, "text"})
-			}
+		if i == (len(msgParts)+len(msg.Attachments()))-1 && len(quickReplies) > 0 {
+			payload.Message.QuickReplies = quickReplies
 		} else {
 			payload.Message.QuickReplies = nil
 		}
@@ -884,6 +1002,7 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 		log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithError("Message Send Error", err)
 		status.AddLog(log)
 		if err != nil {
+			status.SetErrorCode(sendErrorCode(rr.Body))
 			return status, nil
 		}
 
@@ -893,6 +1012,8 @@ func (h *handler) sendFacebookInstagramMsg(ctx context.Context, msg courier.Msg)
 			return status, nil
 		}
 
+		status.AddPartExternalID(externalID)
+
 		// if this is our first message, record the external id
 		if i == 0 {
 			status.SetExternalID(externalID)
@@ -970,8 +1091,11 @@ type wacMTButton struct {
 }
 
 type wacParam struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string      `json:"type"`
+	Text     string      `json:"text,omitempty"`
+	Image    *wacMTMedia `json:"image,omitempty"`
+	Video    *wacMTMedia `json:"video,omitempty"`
+	Document *wacMTMedia `json:"document,omitempty"`
 }
 
 type wacComponent struct {
@@ -993,29 +1117,71 @@ type wacLanguage struct {
 type wacTemplate struct {
 	Name       string          `json:"name"`
 	Language   *wacLanguage    `json:"language"`
-	Components []*wacComponent `json:"components"`
+	Components []*wacComponent `json:"components,omitempty"`
+}
+
+// newWACTemplate renders the passed in template with its language mapped to the passed in WhatsApp language code
+func newWACTemplate(templating *courier.Templating, language string) *wacTemplate {
+	template := &wacTemplate{Name: templating.Template.Name, Language: &wacLanguage{Policy: "deterministic", Code: language}}
+
+	for _, component := range templating.Components {
+		wc := &wacComponent{Type: component.Type, SubType: component.SubType}
+		if component.Type == "button" {
+			wc.Index = strconv.Itoa(component.Index)
+		}
+
+		for _, param := range component.Params {
+			wp := &wacParam{Type: param.Type}
+			switch param.Type {
+			case "image":
+				wp.Image = &wacMTMedia{Link: param.Value}
+			case "video":
+				wp.Video = &wacMTMedia{Link: param.Value}
+			case "document":
+				wp.Document = &wacMTMedia{Link: param.Value}
+			default:
+				wp.Text = param.Value
+			}
+			wc.Params = append(wc.Params, wp)
+		}
+		template.Components = append(template.Components, wc)
+	}
+	return template
 }
 
 type wacInteractive struct {
-	Type   string `json:"type"`
-	Header *struct {
-		Type     string `json:"type"`
-		Text     string `json:"text,omitempty"`
-		Video    string `json:"video,omitempty"`
-		Image    string `json:"image,omitempty"`
-		Document string `json:"document,omitempty"`
-	} `json:"header,omitempty"`
-	Body struct {
+	Type   string       `json:"type"`
+	Header *wacMTHeader `json:"header,omitempty"`
+	Body   struct {
 		Text string `json:"text"`
 	} `json:"body" validate:"required"`
-	Footer *struct {
-		Text string `json:"text"`
-	} `json:"footer,omitempty"`
-	Action *struct {
-		Button   string         `json:"button,omitempty"`
-		Sections []wacMTSection `json:"sections,omitempty"`
-		Buttons  []wacMTButton  `json:"buttons,omitempty"`
-	} `json:"action,omitempty"`
+	Footer *wacMTFooter `json:"footer,omitempty"`
+	Action *wacMTAction `json:"action,omitempty"`
+}
+
+type wacMTHeader struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Video    string `json:"video,omitempty"`
+	Image    string `json:"image,omitempty"`
+	Document string `json:"document,omitempty"`
+}
+
+type wacMTFooter struct {
+	Text string `json:"text"`
+}
+
+type wacMTAction struct {
+	Button     string         `json:"button,omitempty"`
+	Sections   []wacMTSection `json:"sections,omitempty"`
+	Buttons    []wacMTButton  `json:"buttons,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	Parameters *wacMTCTA      `json:"parameters,omitempty"`
+}
+
+type wacMTCTA struct {
+	DisplayText string `json:"display_text"`
+	URL         string `json:"url"`
 }
 
 type wacMTPayload struct {
@@ -1037,12 +1203,89 @@ type wacMTPayload struct {
 	Template *wacTemplate `json:"template,omitempty"`
 }
 
+// mapping from the error codes the graph API returns on sends to our send error codes
+var sendErrorCodes = map[int64]courier.SendErrorCode{
+	4:      courier.SendErrorRateLimited,      // application request limit reached
+	10:     courier.SendErrorWindowClosed,     // message sent outside of the allowed window
+	190:    courier.SendErrorAuth,             // access token expired or invalid
+	551:    courier.SendErrorInvalidRecipient, // person isn't available right now
+	613:    courier.SendErrorRateLimited,      // calls to this API have exceeded the rate limit
+	80007:  courier.SendErrorRateLimited,      // page rate limit reached
+	130429: courier.SendErrorRateLimited,      // cloud API throughput reached
+	131026: courier.SendErrorInvalidRecipient, // receiver incapable of receiving this message
+	131047: courier.SendErrorWindowClosed,     // more than 24 hours since the contact last replied
+	131051: courier.SendErrorContent,          // unsupported message type
+	131052: courier.SendErrorMedia,            // media download error
+	131053: courier.SendErrorMedia,            // media upload error
+	132000: courier.SendErrorTemplate,         // number of template params doesn't match
+	132001: courier.SendErrorTemplate,         // template doesn't exist
+}
+
+// sendErrorCode returns the send error code for the error in the passed in graph API response body, if we know it
+func sendErrorCode(body []byte) courier.SendErrorCode {
+	code, err := jsonparser.GetInt(body, "error", "code")
+	if err != nil {
+		return courier.NilSendErrorCode
+	}
+	return sendErrorCodes[code]
+}
+
 type wacMTResponse struct {
 	Messages []*struct {
 		ID string `json:"id"`
 	} `json:"messages"`
 }
 
+// newWACInteractive builds the interactive payload for the passed in structured interactive content and body
+func newWACInteractive(interactive *courier.Interactive, body string) *wacInteractive {
+	wi := &wacInteractive{}
+	wi.Body.Text = body
+
+	if interactive.Header != "" {
+		wi.Header = &wacMTHeader{Type: "text", Text: interactive.Header}
+	}
+	if interactive.Footer != "" {
+		wi.Footer = &wacMTFooter{Text: interactive.Footer}
+	}
+
+	if len(interactive.Buttons) > 0 {
+		wi.Type = "button"
+		btns := make([]wacMTButton, len(interactive.Buttons))
+		for i, button := range interactive.Buttons {
+			btns[i] = wacMTButton{
+				Type: "reply",
+			}
+			btns[i].Reply.ID = button.ID
+			btns[i].Reply.Title = button.Title
+		}
+		wi.Action = &wacMTAction{Buttons: btns}
+	} else if interactive.List != nil {
+		wi.Type = "list"
+		sections := make([]wacMTSection, len(interactive.List.Sections))
+		for i, s := range interactive.List.Sections {
+			sections[i] = wacMTSection{
+				Title: s.Title,
+				Rows:  make([]wacMTSectionRow, len(s.Rows)),
+			}
+			for j, row := range s.Rows {
+				sections[i].Rows[j] = wacMTSectionRow{
+					ID:          row.ID,
+					Title:       row.Title,
+					Description: row.Description,
+				}
+			}
+		}
+		wi.Action = &wacMTAction{Button: interactive.List.Button, Sections: sections}
+	} else {
+		wi.Type = "cta_url"
+		wi.Action = &wacMTAction{Name: "cta_url", Parameters: &wacMTCTA{DisplayText: interactive.CTA.Title, URL: interactive.CTA.URL}}
+	}
+	return wi
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
 func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	// can't do anything without an access token
 	accessToken := h.Server().Config().WhatsappAdminSystemUserToken
@@ -1058,38 +1301,40 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 		msgParts = handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
 	}
 	qrs := msg.QuickReplies()
+	interactive := msg.Interactive()
+
+	options, failed := h.GetProviderOptions(msg, wacProviderOptions)
+	if failed != nil {
+		return failed, nil
+	}
+
+	templating, err := courier.GetTemplating(msg)
+	language := ""
+	if err == nil && templating != nil {
+		language, err = handlers.WhatsAppLanguage(templating)
+	}
+	if err != nil {
+		return handlers.FailedStatusForTemplateError(status, msg, time.Duration(0), err), nil
+	}
 
 	for i := 0; i < len(msgParts)+len(msg.Attachments()); i++ {
 		payload := wacMTPayload{MessagingProduct: "whatsapp", RecipientType: "individual", To: msg.URN().Path()}
 
 		if len(msg.Attachments()) == 0 {
 			// do we have a template?
-			var templating *MsgTemplating
-			templating, err := h.getTemplate(msg)
-			if err != nil {
-				return nil, errors.Wrapf(err, "unable to decode template: %s for channel: %s", string(msg.Metadata()), msg.Channel().UUID())
-			}
 			if templating != nil {
-
 				payload.Type = "template"
-
-				template := wacTemplate{Name: templating.Template.Name, Language: &wacLanguage{Policy: "deterministic", Code: templating.Language}}
-				payload.Template = &template
-
-				component := &wacComponent{Type: "body"}
-
-				for _, v := range templating.Variables {
-					component.Params = append(component.Params, &wacParam{Type: "text", Text: v})
-				}
-				template.Components = append(payload.Template.Components, component)
-
+				payload.Template = newWACTemplate(templating, language)
 			} else {
 				if i < (len(msgParts) + len(msg.Attachments()) - 1) {
 					// this is still a msg part
 					payload.Type = "text"
 					payload.Text = &wacText{Body: msgParts[i-len(msg.Attachments())]}
 				} else {
-					if len(qrs) > 0 {
+					if interactive != nil {
+						payload.Type = "interactive"
+						payload.Interactive = newWACInteractive(interactive, msgParts[i-len(msg.Attachments())])
+					} else if len(qrs) > 0 {
 						payload.Type = "interactive"
 						// We can use buttons
 						if len(qrs) <= 3 {
@@ -1105,11 +1350,7 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 								btns[i].Reply.ID = fmt.Sprint(i)
 								btns[i].Reply.Title = qr
 							}
-							interactive.Action = &struct {
-								Button   string         "json:\"button,omitempty\""
-								Sections []wacMTSection "json:\"sections,omitempty\""
-								Buttons  []wacMTButton  "json:\"buttons,omitempty\""
-							}{Buttons: btns}
+							interactive.Action = &wacMTAction{Buttons: btns}
 							payload.Interactive = &interactive
 						} else if len(qrs) <= 10 {
 							interactive := wacInteractive{Type: "list", Body: struct {
@@ -1126,11 +1367,7 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 								}
 							}
 
-							interactive.Action = &struct {
-								Button   string         "json:\"button,omitempty\""
-								Sections []wacMTSection "json:\"sections,omitempty\""
-								Buttons  []wacMTButton  "json:\"buttons,omitempty\""
-							}{Button: "Menu", Sections: []wacMTSection{
+							interactive.Action = &wacMTAction{Button: "Menu", Sections: []wacMTSection{
 								section,
 							}}
 
@@ -1170,7 +1407,10 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 				payload.Type = "text"
 				payload.Text = &wacText{Body: msgParts[i-len(msg.Attachments())]}
 			} else {
-				if len(qrs) > 0 {
+				if interactive != nil {
+					payload.Type = "interactive"
+					payload.Interactive = newWACInteractive(interactive, msgParts[i-len(msg.Attachments())])
+				} else if len(qrs) > 0 {
 					payload.Type = "interactive"
 					// We can use buttons
 					if len(qrs) <= 3 {
@@ -1186,11 +1426,7 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 							btns[i].Reply.ID = fmt.Sprint(i)
 							btns[i].Reply.Title = qr
 						}
-						interactive.Action = &struct {
-							Button   string         "json:\"button,omitempty\""
-							Sections []wacMTSection "json:\"sections,omitempty\""
-							Buttons  []wacMTButton  "json:\"buttons,omitempty\""
-						}{Buttons: btns}
+						interactive.Action = &wacMTAction{Buttons: btns}
 						payload.Interactive = &interactive
 
 					} else if len(qrs) <= 10 {
@@ -1208,11 +1444,7 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 							}
 						}
 
-						interactive.Action = &struct {
-							Button   string         "json:\"button,omitempty\""
-							Sections []wacMTSection "json:\"sections,omitempty\""
-							Buttons  []wacMTButton  "json:\"buttons,omitempty\""
-						}{Button: "Menu", Sections: []wacMTSection{
+						interactive.Action = &wacMTAction{Button: "Menu", Sections: []wacMTSection{
 							section,
 						}}
 
@@ -1229,6 +1461,10 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 
 		}
 
+		if payload.Type == "text" {
+			payload.PreviewURL, _ = options.Bool("preview_url")
+		}
+
 		jsonBody, err := json.Marshal(payload)
 		if err != nil {
 			return status, err
@@ -1248,6 +1484,7 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 		log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithError("Message Send Error", err)
 		status.AddLog(log)
 		if err != nil {
+			status.SetErrorCode(sendErrorCode(rr.Body))
 			return status, nil
 		}
 
@@ -1258,8 +1495,11 @@ func (h *handler) sendCloudAPIWhatsappMsg(ctx context.Context, msg courier.Msg)
 			return status, nil
 		}
 		externalID := respPayload.Messages[0].ID
-		if i == 0 && externalID != "" {
-			status.SetExternalID(externalID)
+		if externalID != "" {
+			if i == 0 {
+				status.SetExternalID(externalID)
+			}
+			status.AddPartExternalID(externalID)
 		}
 		// this was wired successfully
 		status.SetStatus(courier.MsgWired)
@@ -1359,129 +1599,25 @@ func fbCalculateSignature(appSecret string, body []byte) (string, error) {
 	return hex.EncodeToString(mac.Sum(nil)), nil
 }
 
-func (h *handler) getTemplate(msg courier.Msg) (*MsgTemplating, error) {
-	mdJSON := msg.Metadata()
-	if len(mdJSON) == 0 {
-		return nil, nil
-	}
-	metadata := &TemplateMetadata{}
-	err := json.Unmarshal(mdJSON, metadata)
-	if err != nil {
-		return nil, err
-	}
-	templating := metadata.Templating
-	if templating == nil {
-		return nil, nil
+// newWACCost returns the cost of a message from the pricing and conversation in its status, the cloud API bills by
+// conversation so these only tell us how the message was categorized and not what it cost
+func newWACCost(pricing *wacPricing, conversation *wacConversation) *courier.MsgCost {
+	if pricing == nil && conversation == nil {
+		return nil
 	}
 
-	// check our template is valid
-	err = handlers.Validate(templating)
-	if err != nil {
-		return nil, errors.Wrapf(err, "invalid templating definition")
-	}
-	// check country
-	if templating.Country != "" {
-		templating.Language = fmt.Sprintf("%s_%s", templating.Language, templating.Country)
+	cost := &courier.MsgCost{}
+	if pricing != nil {
+		billable := pricing.Billable
+		cost.Billable = &billable
+		cost.PricingModel = pricing.PricingModel
+		cost.Category = pricing.Category
 	}
-
-	// map our language from iso639-3_iso3166-2 to the WA country / iso638-2 pair
-	language, found := languageMap[templating.Language]
-	if !found {
-		return nil, fmt.Errorf("unable to find mapping for language: %s", templating.Language)
+	if conversation != nil {
+		cost.ConversationID = conversation.ID
+		if cost.Category == "" && conversation.Origin != nil {
+			cost.Category = conversation.Origin.Type
+		}
 	}
-	templating.Language = language
-
-	return templating, err
-}
-
-type TemplateMetadata struct {
-	Templating *MsgTemplating `json:"templating"`
-}
-
-type MsgTemplating struct {
-	Template struct {
-		Name string `json:"name" validate:"required"`
-		UUID string `json:"uuid" validate:"required"`
-	} `json:"template" validate:"required,dive"`
-	Language  string   `json:"language" validate:"required"`
-	Country   string   `json:"country"`
-	Namespace string   `json:"namespace"`
-	Variables []string `json:"variables"`
-}
-
-// mapping from iso639-3_iso3166-2 to WA language code
-var languageMap = map[string]string{
-	"afr":    "af",    // Afrikaans
-	"sqi":    "sq",    // Albanian
-	"ara":    "ar",    // Arabic
-	"aze":    "az",    // Azerbaijani
-	"ben":    "bn",    // Bengali
-	"bul":    "bg",    // Bulgarian
-	"cat":    "ca",    // Catalan
-	"zho":    "zh_CN", // Chinese
-	"zho_CN": "zh_CN", // Chinese (CHN)
-	"zho_HK": "zh_HK", // Chinese (HKG)
-	"zho_TW": "zh_TW", // Chinese (TAI)
-	"hrv":    "hr",    // Croatian
-	"ces":    "cs",    // Czech
-	"dah":    "da",    // Danish
-	"nld":    "nl",    // Dutch
-	"eng":    "en",    // English
-	"eng_GB": "en_GB", // English (UK)
-	"eng_US": "en_US", // English (US)
-	"est":    "et",    // Estonian
-	"fil":    "fil",   // Filipino
-	"fin":    "fi",    // Finnish
-	"fra":    "fr",    // French
-	"kat":    "ka",    // Georgian
-	"deu":    "de",    // German
-	"ell":    "el",    // Greek
-	"guj":    "gu",    // Gujarati
-	"hau":    "ha",    // Hausa
-	"enb":    "he",    // Hebrew
-	"hin":    "hi",    // Hindi
-	"hun":    "hu",    // Hungarian
-	"ind":    "id",    // Indonesian
-	"gle":    "ga",    // Irish
-	"ita":    "it",    // Italian
-	"jpn":    "ja",    // Japanese
-	"kan":    "kn",    // Kannada
-	"kaz":    "kk",    // Kazakh
-	"kin":    "rw_RW", // Kinyarwanda
-	"kor":    "ko",    // Korean
-	"kir":    "ky_KG", // Kyrgyzstan
-	"lao":    "lo",    // Lao
-	"lav":    "lv",    // Latvian
-	"lit":    "lt",    // Lithuanian
-	"mal":    "ml",    // Malayalam
-	"mkd":    "mk",    // Macedonian
-	"msa":    "ms",    // Malay
-	"mar":    "mr",    // Marathi
-	"nob":    "nb",    // Norwegian
-	"fas":    "fa",    // Persian
-	"pol":    "pl",    // Polish
-	"por":    "pt_PT", // Portuguese
-	"por_BR": "pt_BR", // Portuguese (BR)
-	"por_PT": "pt_PT", // Portuguese (POR)
-	"pan":    "pa",    // Punjabi
-	"ron":    "ro",    // Romanian
-	"rus":    "ru",    // Russian
-	"srp":    "sr",    // Serbian
-	"slk":    "sk",    // Slovak
-	"slv":    "sl",    // Slovenian
-	"spa":    "es",    // Spanish
-	"spa_AR": "es_AR", // Spanish (ARG)
-	"spa_ES": "es_ES", // Spanish (SPA)
-	"spa_MX": "es_MX", // Spanish (MEX)
-	"swa":    "sw",    // Swahili
-	"swe":    "sv",    // Swedish
-	"tam":    "ta",    // Tamil
-	"tel":    "te",    // Telugu
-	"tha":    "th",    // Thai
-	"tur":    "tr",    // Turkish
-	"ukr":    "uk",    // Ukrainian
-	"urd":    "ur",    // Urdu
-	"uzb":    "uz",    // Uzbek
-	"vie":    "vi",    // Vietnamese
-	"zul":    "zu",    // Zulu
+	return cost
 }
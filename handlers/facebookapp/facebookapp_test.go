@@ -272,7 +272,8 @@ var testCasesWAC = []ChannelHandleTestCase{
 	{Label: "Receive Invalid JSON", URL: wacReceiveURL, Data: string(courier.ReadFile("./testdata/wac/invalidTimestamp.json")), Status: 400, Response: "invalid timestamp", PrepRequest: addValidSignature},
 
 	{Label: "Receive Valid Status", URL: wacReceiveURL, Data: string(courier.ReadFile("./testdata/wac/validStatusWAC.json")), Status: 200, Response: `"type":"status"`,
-		MsgStatus: Sp("S"), ExternalID: Sp("external_id"), PrepRequest: addValidSignature},
+		MsgStatus: Sp("S"), ExternalID: Sp("external_id"), PrepRequest: addValidSignature,
+		MsgCost: &courier.MsgCost{Billable: Bp(false), PricingModel: "CBP", Category: "referral_conversion", ConversationID: "CONVERSATION_ID"}},
 	{Label: "Receive Invalid Status", URL: wacReceiveURL, Data: string(courier.ReadFile("./testdata/wac/invalidStatusWAC.json")), Status: 400, Response: `"unknown status: in_orbit"`, PrepRequest: addValidSignature},
 	{Label: "Receive Ignore Status", URL: wacReceiveURL, Data: string(courier.ReadFile("./testdata/wac/ignoreStatusWAC.json")), Status: 200, Response: `"ignoring status: deleted"`, PrepRequest: addValidSignature},
 }
@@ -359,6 +360,22 @@ func TestVerify(t *testing.T) {
 
 }
 
+func TestVerifyWithChannelVerification(t *testing.T) {
+	channels := []courier.Channel{
+		courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c568c", "FBA", "12345", "", map[string]interface{}{
+			courier.ConfigAuthToken:    "a123",
+			courier.ConfigVerifySecret: "sesame",
+		}),
+	}
+
+	// webhook verifications aren't for a channel so aren't subject to the verification configured on channels
+	RunChannelTestCases(t, channels, newHandler("FBA", "Facebook", false), []ChannelHandleTestCase{
+		{Label: "Valid Secret", URL: "/c/fba/receive?hub.mode=subscribe&hub.verify_token=fb_webhook_secret&hub.challenge=yarchallenge", Status: 200,
+			Response: "yarchallenge", NoQueueErrorCheck: true, NoInvalidChannelCheck: true},
+		{Label: "Invalid Secret", URL: "/c/fba/receive?hub.mode=subscribe&hub.verify_token=blah", Status: 400, Response: "token does not match secret"},
+	})
+}
+
 // setSendURL takes care of setting the send_url to our test server host
 func setSendURL(s *httptest.Server, h courier.ChannelHandler, c courier.Channel, m courier.Msg) {
 	sendURL = s.URL
@@ -391,6 +408,20 @@ var SendTestCasesFBA = []ChannelSendTestCase{
 		ResponseBody: `{"message_id": "mid.133"}`, ResponseStatus: 200,
 		RequestBody: `{"messaging_type":"UPDATE","recipient":{"id":"12345"},"message":{"text":"Are you happy?","quick_replies":[{"title":"Yes","payload":"Yes","content_type":"text"},{"title":"No","payload":"No","content_type":"text"}]}}`,
 		SendPrep:    setSendURL},
+	{Label: "Interactive Buttons",
+		Text: "Are you happy?", URN: "facebook:12345",
+		Metadata: json.RawMessage(`{"interactive":{"header":"Survey","buttons":[{"id":"y","title":"Yes"},{"id":"n","title":"No"}]}}`),
+		Status:   "W", ExternalID: "mid.133",
+		ResponseBody: `{"message_id": "mid.133"}`, ResponseStatus: 200,
+		RequestBody: `{"messaging_type":"UPDATE","recipient":{"id":"12345"},"message":{"text":"Survey\n\nAre you happy?","quick_replies":[{"title":"Yes","payload":"y","content_type":"text"},{"title":"No","payload":"n","content_type":"text"}]}}`,
+		SendPrep:    setSendURL},
+	{Label: "Interactive CTA",
+		Text: "See our menu", URN: "facebook:12345",
+		Metadata: json.RawMessage(`{"interactive":{"cta":{"title":"Open","url":"https://foo.bar/menu"}}}`),
+		Status:   "W", ExternalID: "mid.133",
+		ResponseBody: `{"message_id": "mid.133"}`, ResponseStatus: 200,
+		RequestBody: `{"messaging_type":"UPDATE","recipient":{"id":"12345"},"message":{"attachment":{"type":"template","payload":{"template_type":"button","text":"See our menu","buttons":[{"type":"web_url","url":"https://foo.bar/menu","title":"Open"}]}}}}`,
+		SendPrep:    setSendURL},
 	{Label: "Long Message",
 		Text: "This is a long message which spans more than one part, what will actually be sent in the end if we exceed the max length?",
 		URN:  "facebook:12345", QuickReplies: []string{"Yes", "No"}, Topic: "account",
@@ -428,6 +459,12 @@ var SendTestCasesFBA = []ChannelSendTestCase{
 		Status:       "E",
 		ResponseBody: `{ "is_error": true }`, ResponseStatus: 403,
 		SendPrep: setSendURL},
+	{Label: "Unavailable Error",
+		Text: "Error", URN: "facebook:12345",
+		Status:       "E",
+		ErrorCode:    courier.SendErrorInvalidRecipient,
+		ResponseBody: `{"error": {"message": "(#551) This person isn't available right now.", "type": "OAuthException", "code": 551, "error_subcode": 1545041}}`, ResponseStatus: 400,
+		SendPrep: setSendURL},
 }
 
 var SendTestCasesIG = []ChannelSendTestCase{
@@ -624,9 +661,18 @@ var SendTestCasesWAC = []ChannelSendTestCase{
 		RequestBody: `{"messaging_product":"whatsapp","preview_url":false,"recipient_type":"individual","to":"250788123123","type":"template","template":{"name":"revive_issue","language":{"policy":"deterministic","code":"en_US"},"components":[{"type":"body","sub_type":"","index":"","parameters":[{"type":"text","text":"Chef"},{"type":"text","text":"tomorrow"}]}]}}`,
 		SendPrep:    setSendURL,
 	},
+	{Label: "Template Components",
+		Text:   "templated message",
+		URN:    "whatsapp:250788123123",
+		Status: "W", ExternalID: "157b5e14568e8",
+		Metadata:     json.RawMessage(`{ "templating": { "template": { "name": "order_update", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3" }, "language": "eng", "components": [{"type": "header", "params": [{"type": "document", "value": "https://foo.bar/order.pdf"}]}, {"type": "button", "sub_type": "quick_reply", "index": 1, "params": [{"type": "text", "value": "stop"}]}]}}`),
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 200,
+		RequestBody: `{"messaging_product":"whatsapp","preview_url":false,"recipient_type":"individual","to":"250788123123","type":"template","template":{"name":"order_update","language":{"policy":"deterministic","code":"en"},"components":[{"type":"header","sub_type":"","index":"","parameters":[{"type":"document","document":{"link":"https://foo.bar/order.pdf"}}]},{"type":"button","sub_type":"quick_reply","index":"1","parameters":[{"type":"text","text":"stop"}]}]}}`,
+		SendPrep:    setSendURL,
+	},
 	{Label: "Template Invalid Language",
 		Text: "templated message", URN: "whatsapp:250788123123",
-		Error:    `unable to decode template: {"templating": { "template": { "name": "revive_issue", "uuid": "8ca114b4-bee2-4d3b-aaf1-9aa6b48d41e8" }, "language": "bnt", "variables": ["Chef", "tomorrow"]}} for channel: 8eb23e93-5ecb-45ba-b726-3b064e0c56ab: unable to find mapping for language: bnt`,
+		Status:   "F",
 		Metadata: json.RawMessage(`{"templating": { "template": { "name": "revive_issue", "uuid": "8ca114b4-bee2-4d3b-aaf1-9aa6b48d41e8" }, "language": "bnt", "variables": ["Chef", "tomorrow"]}}`),
 	},
 	{Label: "Interactive Button Message Send",
@@ -635,6 +681,20 @@ var SendTestCasesWAC = []ChannelSendTestCase{
 		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
 		RequestBody: `{"messaging_product":"whatsapp","preview_url":false,"recipient_type":"individual","to":"250788123123","type":"interactive","interactive":{"type":"button","body":{"text":"Interactive Button Msg"},"action":{"buttons":[{"type":"reply","reply":{"id":"0","title":"BUTTON1"}}]}}}`,
 		SendPrep:    setSendURL},
+	{Label: "Structured Interactive Buttons Send",
+		Text: "Pick one", URN: "whatsapp:250788123123",
+		Metadata: json.RawMessage(`{"interactive":{"header":"Choices","footer":"Reply now","buttons":[{"id":"yes","title":"Yes"}]}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"messaging_product":"whatsapp","preview_url":false,"recipient_type":"individual","to":"250788123123","type":"interactive","interactive":{"type":"button","header":{"type":"text","text":"Choices"},"body":{"text":"Pick one"},"footer":{"text":"Reply now"},"action":{"buttons":[{"type":"reply","reply":{"id":"yes","title":"Yes"}}]}}}`,
+		SendPrep:    setSendURL},
+	{Label: "Structured Interactive CTA Send",
+		Text: "See our menu", URN: "whatsapp:250788123123",
+		Metadata: json.RawMessage(`{"interactive":{"cta":{"title":"Open","url":"https://foo.bar/menu"}}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"messaging_product":"whatsapp","preview_url":false,"recipient_type":"individual","to":"250788123123","type":"interactive","interactive":{"type":"cta_url","body":{"text":"See our menu"},"action":{"name":"cta_url","parameters":{"display_text":"Open","url":"https://foo.bar/menu"}}}}`,
+		SendPrep:    setSendURL},
 	{Label: "Interactive List Message Send",
 		Text: "Interactive List Msg", URN: "whatsapp:250788123123", QuickReplies: []string{"ROW1", "ROW2", "ROW3", "ROW4"},
 		Status: "W", ExternalID: "157b5e14568e8",
@@ -209,15 +209,18 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			return status, nil
 		}
 
-		// grab the id if this is our first part
+		// grab the id of each part, our first part's id is required
+		externalID, err := jsonparser.GetInt(rr.Body, "multicast_id")
 		if i == 0 {
-			externalID, err := jsonparser.GetInt(rr.Body, "multicast_id")
 			if err != nil {
 				log.WithError("Message Send Error", errors.Errorf("unable to get multicast_id from response"))
 				return status, nil
 			}
 			status.SetExternalID(fmt.Sprintf("%d", externalID))
 		}
+		if err == nil {
+			status.AddPartExternalID(fmt.Sprintf("%d", externalID))
+		}
 	}
 
 	status.SetStatus(courier.MsgWired)
@@ -23,7 +23,7 @@ func NewTelReceiveHandler(h *BaseHandler, fromField string, bodyField string) co
 			return nil, WriteAndLogRequestError(ctx, h, c, w, r, fmt.Errorf("missing required field '%s'", fromField))
 		}
 		// create our URN
-		urn, err := StrictTelForCountry(from, c.Country())
+		urn, err := NormalizeTelForChannel(c, from)
 		if err != nil {
 			return nil, WriteAndLogRequestError(ctx, h, c, w, r, err)
 		}
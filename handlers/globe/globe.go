@@ -99,7 +99,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 			return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, fmt.Errorf("invalid 'senderAddress' parameter"))
 		}
 
-		urn, err := handlers.StrictTelForCountry(glMsg.SenderAddress[4:], c.Country())
+		urn, err := handlers.NormalizeTelForChannel(c, glMsg.SenderAddress[4:])
 		if err != nil {
 			return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 		}
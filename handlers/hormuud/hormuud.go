@@ -62,7 +62,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 	// create our date from the timestamp
 	date := time.Unix(payload.TimeSent, 0).UTC()
 
-	urn, err := handlers.StrictTelForCountry(payload.Sender, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, payload.Sender)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -133,8 +133,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 		// try to get the message id out
 		id, _ := jsonparser.GetString(rr.Body, "Data", "MessageID")
-		if id != "" && i == 0 {
-			status.SetExternalID(id)
+		if id != "" {
+			if i == 0 {
+				status.SetExternalID(id)
+			}
+			status.AddPartExternalID(id)
 		}
 	}
 
@@ -149,7 +152,7 @@ type tokenResponse struct {
 func (h *handler) FetchToken(ctx context.Context, channel courier.Channel, msg courier.Msg) (string, *utils.RequestResponse, error) {
 	// first check whether we have it in redis
 	conn := h.Backend().RedisPool().Get()
-	token, err := redis.String(conn.Do("GET", fmt.Sprintf("hm_token_%s", channel.UUID())))
+	token, err := redis.String(conn.Do("GET", utils.RedisKey("hm_token_%s", channel.UUID())))
 	conn.Close()
 
 	// got a token, use it
@@ -195,7 +198,7 @@ func (h *handler) FetchToken(ctx context.Context, channel courier.Channel, msg c
 
 	// we got a token, cache it to redis with a 90 minute expiration
 	conn = h.Backend().RedisPool().Get()
-	_, err = conn.Do("SETEX", fmt.Sprintf("hm_token_%s", channel.UUID()), 5340, token)
+	_, err = conn.Do("SETEX", utils.RedisKey("hm_token_%s", channel.UUID()), 5340, token)
 	conn.Close()
 
 	if err != nil {
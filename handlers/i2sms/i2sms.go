@@ -56,7 +56,7 @@ func (h *handler) receive(ctx context.Context, c courier.Channel, w http.Respons
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(from, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, from)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -140,6 +140,7 @@ func (h *handler) SendMsg(_ context.Context, msg courier.Msg) (courier.MsgStatus
 		if response.ErrorCode == "00" {
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(response.Result.SessionID)
+			status.AddPartExternalID(response.Result.SessionID)
 		} else {
 			status.SetStatus(courier.MsgFailed)
 			log.WithError("Message Send Error", fmt.Errorf("Received invalid response code: %s", response.ErrorCode))
@@ -12,6 +12,7 @@ import (
 	"github.com/buger/jsonparser"
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
 	"github.com/pkg/errors"
 )
@@ -56,6 +57,10 @@ type ibStatus struct {
 	Status    struct {
 		GroupName string `validate:"required" json:"groupName"`
 	} `validate:"required" json:"status"`
+	Price *struct {
+		PricePerMessage float64 `json:"pricePerMessage"`
+		Currency        string  `json:"currency"`
+	} `json:"price"`
 }
 
 // statusMessage is our HTTP handler function for status updates
@@ -76,6 +81,9 @@ func (h *handler) statusMessage(ctx context.Context, channel courier.Channel, w
 
 		// write our status
 		status := h.Backend().NewMsgStatusForExternalID(channel, s.MessageID, msgStatus)
+		if s.Price != nil && s.Price.PricePerMessage > 0 {
+			status.SetCost(&courier.MsgCost{Amount: s.Price.PricePerMessage, Currency: s.Price.Currency})
+		}
 		err = h.Backend().WriteMsgStatus(ctx, status)
 		if err == courier.ErrMsgNotFound {
 			data = append(data, courier.NewInfoData(fmt.Sprintf("ignoring status update message id: %s, not found", s.MessageID)))
@@ -157,7 +165,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 		}
 
 		// create our URN
-		urn, err := handlers.StrictTelForCountry(infobipMessage.From, channel.Country())
+		urn, err := handlers.NormalizeTelForChannel(channel, infobipMessage.From)
 		if err != nil {
 			return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 		}
@@ -175,6 +183,14 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	return handlers.WriteMsgsAndResponse(ctx, h, msgs, w, r)
 }
 
+// the provider options we support
+var providerOptions = []courier.ProviderOption{
+	{Name: "flash", Type: courier.ProviderOptionBool},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption { return providerOptions }
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	username := msg.Channel().StringConfigForKey(courier.ConfigUsername, "")
@@ -187,7 +203,14 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		return nil, fmt.Errorf("no password set for IB channel")
 	}
 
+	options, failed := h.GetProviderOptions(msg, providerOptions)
+	if failed != nil {
+		return failed, nil
+	}
+	flash, _ := options.Bool("flash")
+
 	transliteration := msg.Channel().StringConfigForKey(configTransliteration, "")
+	text, _ := sms.Encode(handlers.GetTextAndAttachments(msg), msg.Channel().StringConfigForKey(sms.ConfigEncoding, sms.EncodingModeDefault))
 
 	callbackDomain := msg.Channel().CallbackDomain(h.Server().Config().Domain)
 	statusURL := fmt.Sprintf("https://%s%s%s/delivered", callbackDomain, "/c/ib/", msg.Channel().UUID())
@@ -202,11 +225,12 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 						MessageID: msg.ID().String(),
 					},
 				},
-				Text:               handlers.GetTextAndAttachments(msg),
+				Text:               text,
 				NotifyContentType:  "application/json",
 				IntermediateReport: true,
 				NotifyURL:          statusURL,
 				Transliteration:    transliteration,
+				Flash:              flash,
 			},
 		},
 	}
@@ -230,7 +254,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	// record our status and log
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
-	log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr)
+	log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithSegments(sms.Segments(text))
 	status.AddLog(log)
 	if err != nil {
 		log.WithError("Message Send Error", err)
@@ -295,6 +319,7 @@ type mtMessage struct {
 	IntermediateReport bool            `json:"intermediateReport"`
 	NotifyURL          string          `json:"notifyUrl"`
 	Transliteration    string          `json:"transliteration,omitempty"`
+	Flash              bool            `json:"flash,omitempty"`
 }
 
 type mtDestination struct {
@@ -128,6 +128,21 @@ var validStatusDelivered = `{
 	]
 }`
 
+var validStatusDeliveredWithPrice = `{
+	"results": [
+		{
+			"messageId": "12345",
+			"status": {
+				"groupName": "DELIVERED"
+			},
+			"price": {
+				"pricePerMessage": 0.01,
+				"currency": "EUR"
+			}
+		}
+	]
+}`
+
 var validStatusRejected = `{
 	"results": [
 		{
@@ -198,6 +213,8 @@ var testCases = []ChannelHandleTestCase{
 	{Label: "Status report invalid JSON", URL: statusURL, Data: invalidJSONStatus, Status: 400, Response: "unable to parse request JSON"},
 	{Label: "Status report missing results key", URL: statusURL, Data: statusMissingResultsKey, Status: 400, Response: "Field validation for 'Results' failed"},
 	{Label: "Status delivered", URL: statusURL, Data: validStatusDelivered, Status: 200, Response: `"status":"D"`},
+	{Label: "Status delivered with price", URL: statusURL, Data: validStatusDeliveredWithPrice, Status: 200, Response: `"status":"D"`,
+		MsgCost: &courier.MsgCost{Amount: 0.01, Currency: "EUR"}},
 	{Label: "Status rejected", URL: statusURL, Data: validStatusRejected, Status: 200, Response: `"status":"F"`},
 	{Label: "Status undeliverable", URL: statusURL, Data: validStatusUndeliverable, Status: 200, Response: `"status":"F"`},
 	{Label: "Status pending", URL: statusURL, Data: validStatusPending, Status: 200, Response: `"status":"S"`},
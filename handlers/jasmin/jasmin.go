@@ -6,11 +6,13 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
 	"github.com/nyaruka/gocommon/gsm7"
 )
@@ -83,7 +85,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.From, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, form.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -119,6 +121,14 @@ func writeJasminACK(w http.ResponseWriter) error {
 	return err
 }
 
+// the provider options we support, validity_period is in minutes
+var providerOptions = []courier.ProviderOption{
+	{Name: "validity_period", Type: courier.ProviderOptionInt},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption { return providerOptions }
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	username := msg.Channel().StringConfigForKey(courier.ConfigUsername, "")
@@ -136,9 +146,17 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		return nil, fmt.Errorf("no send url set for JS channel")
 	}
 
+	options, failed := h.GetProviderOptions(msg, providerOptions)
+	if failed != nil {
+		return failed, nil
+	}
+
 	callbackDomain := msg.Channel().CallbackDomain(h.Server().Config().Domain)
 	dlrURL := fmt.Sprintf("https://%s/c/js/%s/status", callbackDomain, msg.Channel().UUID())
 
+	// we always send as GSM7 so replace what we can with look-alikes
+	content, segments := encodeContent(sms.ReplaceLookalikes(handlers.GetTextAndAttachments(msg)))
+
 	// build our request
 	form := url.Values{
 		"username":   []string{username},
@@ -150,7 +168,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		"dlr-level":  []string{"2"},
 		"dlr-method": []string{http.MethodPost},
 		"coding":     []string{"0"},
-		"content":    []string{string(gsm7.Encode(gsm7.ReplaceSubstitutions(handlers.GetTextAndAttachments(msg))))},
+		"content":    []string{string(content)},
+	}
+
+	if validity, found := options.Int("validity_period"); found {
+		form["validity-period"] = []string{strconv.Itoa(validity)}
 	}
 
 	fullURL, _ := url.Parse(sendURL)
@@ -164,7 +186,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	// record our status and log
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
-	status.AddLog(courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithError("Message Send Error", err))
+	status.AddLog(courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithSegments(segments).WithError("Message Send Error", err))
 	if err == nil {
 		status.SetStatus(courier.MsgWired)
 	}
@@ -177,3 +199,10 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	return status, nil
 }
+
+// encodeContent encodes the passed in text as GSM7, returning the encoded content and the number of segments it will be
+// sent as. Characters which can't be encoded are replaced so segments are counted from what is actually sent.
+func encodeContent(text string) ([]byte, int) {
+	content := gsm7.Encode(text)
+	return content, sms.Segments(gsm7.Decode(content))
+}
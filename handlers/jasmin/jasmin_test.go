@@ -3,10 +3,12 @@ package jasmin
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/nyaruka/courier"
 	. "github.com/nyaruka/courier/handlers"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -98,3 +100,16 @@ func TestSending(t *testing.T) {
 
 	RunChannelSendTestCases(t, defaultChannel, newHandler(), defaultSendTestCases, nil)
 }
+
+func TestEncodeContent(t *testing.T) {
+	content, segments := encodeContent("Simple Message")
+	assert.Equal(t, "Simple Message", string(content))
+	assert.Equal(t, 1, segments)
+
+	// characters GSM7 can't encode are replaced, so this fits in a single GSM7 segment rather than two UCS2 ones
+	_, segments = encodeContent(strings.Repeat("☺", 100))
+	assert.Equal(t, 1, segments)
+
+	_, segments = encodeContent(strings.Repeat("x", 161))
+	assert.Equal(t, 2, segments)
+}
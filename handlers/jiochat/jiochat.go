@@ -207,7 +207,7 @@ func (h *handler) fetchAccessToken(ctx context.Context, channel courier.Channel)
 	rc := h.Backend().RedisPool().Get()
 	defer rc.Close()
 
-	cacheKey := fmt.Sprintf("jiochat_channel_access_token:%s", channel.UUID().String())
+	cacheKey := utils.RedisKey("jiochat_channel_access_token:%s", channel.UUID().String())
 	_, err = rc.Do("SET", cacheKey, accessToken, 7200)
 
 	if err != nil {
@@ -220,7 +220,7 @@ func (h *handler) getAccessToken(channel courier.Channel) (string, error) {
 	rc := h.Backend().RedisPool().Get()
 	defer rc.Close()
 
-	cacheKey := fmt.Sprintf("jiochat_channel_access_token:%s", channel.UUID().String())
+	cacheKey := utils.RedisKey("jiochat_channel_access_token:%s", channel.UUID().String())
 	accessToken, err := redis.String(rc.Do("GET", cacheKey))
 	if err != nil {
 		return "", err
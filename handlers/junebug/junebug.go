@@ -79,7 +79,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, fmt.Errorf("unable to parse date: %s", payload.Timestamp))
 	}
 
-	urn, err := handlers.StrictTelForCountry(payload.From, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, payload.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -217,6 +217,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		if i == 0 {
 			status.SetExternalID(externalID)
 		}
+		status.AddPartExternalID(externalID)
 	}
 
 	// this was wired successfully
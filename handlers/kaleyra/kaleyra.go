@@ -154,8 +154,20 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	var kwaRes *utils.RequestResponse
 	var kwaErr error
 
-	// make multipart form requests if we have attachments, the kaleyra api doesn't supports media url nor media upload before send
-	if len(msg.Attachments()) > 0 {
+	templating, err := courier.GetTemplating(msg)
+	if err != nil {
+		return handlers.FailedStatusForTemplateError(status, msg, time.Now().Sub(start), err), nil
+	}
+
+	if templating != nil {
+		baseForm, err := h.newTemplateForm(msg, templating)
+		if err != nil {
+			return handlers.FailedStatusForTemplateError(status, msg, time.Now().Sub(start), err), nil
+		}
+		kwaRes, kwaErr = h.postForm(sendURL, baseForm)
+
+	} else if len(msg.Attachments()) > 0 {
+		// make multipart form requests if we have attachments, the kaleyra api doesn't supports media url nor media upload before send
 	attachmentsLoop:
 		for i, attachment := range msg.Attachments() {
 			_, attachmentURL := handlers.SplitAttachment(attachment)
@@ -218,20 +230,13 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			kwaRes, kwaErr = utils.MakeHTTPRequest(req)
 		}
 	} else {
-		form := url.Values{}
 		baseForm := h.newSendForm(msg.Channel(), "text", msg.URN().Path())
 		baseForm["body"] = msg.Text()
 		// checks if the message has a valid url to activate the preview
 		if urlRegex.MatchString(msg.Text()) {
 			baseForm["preview_url"] = "true"
 		}
-		for k, v := range baseForm {
-			form.Set(k, v)
-		}
-
-		req, _ := http.NewRequest(http.MethodPost, sendURL, strings.NewReader(form.Encode()))
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		kwaRes, kwaErr = utils.MakeHTTPRequest(req)
+		kwaRes, kwaErr = h.postForm(sendURL, baseForm)
 	}
 
 	if kwaRes != nil {
@@ -271,3 +276,44 @@ func (h *handler) newSendForm(channel courier.Channel, msgType, toContact string
 		"to":           toContact,
 	}
 }
+
+// newTemplateForm builds the form to send the passed in template with, body params are sent as a list of quoted values
+func (h *handler) newTemplateForm(msg courier.Msg, templating *courier.Templating) (map[string]string, error) {
+	language, err := handlers.WhatsAppLanguage(templating)
+	if err != nil {
+		return nil, err
+	}
+
+	form := h.newSendForm(msg.Channel(), "template", msg.URN().Path())
+	form["template_name"] = templating.Template.Name
+	form["lang_code"] = language
+
+	if params := templating.TextParams("body"); len(params) > 0 {
+		quoted := make([]string, len(params))
+		for i, p := range params {
+			quoted[i] = `"` + strings.Replace(p, `"`, `\"`, -1) + `"`
+		}
+		form["params"] = strings.Join(quoted, ",")
+	}
+	if params := templating.TextParams("header"); len(params) > 0 {
+		form["param_header"] = params[0]
+	}
+	for _, button := range templating.ComponentsOfType("button") {
+		if button.SubType == "url" && len(button.Params) > 0 {
+			form["param_url"] = button.Params[0].Value
+		}
+	}
+	return form, nil
+}
+
+// postForm posts the passed in values as a URL encoded form to the passed in URL
+func (h *handler) postForm(sendURL string, values map[string]string) (*utils.RequestResponse, error) {
+	form := url.Values{}
+	for k, v := range values {
+		form.Set(k, v)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, sendURL, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return utils.MakeHTTPRequest(req)
+}
@@ -1,6 +1,7 @@
 package kaleyra
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -129,6 +130,28 @@ var sendTestCases = []ChannelSendTestCase{
 		ResponseBody:   `{"id":"58f86fab-85c5-4f7c-9b68-9c323248afc4:0"}`,
 		SendPrep:       setSendURL,
 	},
+	{
+		Label:          "Template Send",
+		Text:           "Hi Chef, see you tomorrow",
+		URN:            "whatsapp:14133881111",
+		Metadata:       json.RawMessage(`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "variables": ["Chef", "tomorrow"]}}`),
+		Status:         "W",
+		ExternalID:     "58f86fab-85c5-4f7c-9b68-9c323248afc4:0",
+		Path:           "/v1/SID/messages",
+		Headers:        map[string]string{"Content-type": "application/x-www-form-urlencoded"},
+		RequestBody:    "api-key=123456&callback_url=https%3A%2F%2Flocalhost%2Fc%2Fkwa%2F8eb23e93-5ecb-45ba-b726-3b064e0c568c%2Fstatus&channel=WhatsApp&from=250788383383&lang_code=en&params=%22Chef%22%2C%22tomorrow%22&template_name=revive_issue&to=14133881111&type=template",
+		ResponseStatus: 200,
+		ResponseBody:   `{"id":"58f86fab-85c5-4f7c-9b68-9c323248afc4:0"}`,
+		SendPrep:       setSendURL,
+	},
+	{
+		Label:    "Template Invalid Language",
+		Text:     "Hi Chef, see you tomorrow",
+		URN:      "whatsapp:14133881111",
+		Metadata: json.RawMessage(`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "bnt", "variables": ["Chef"]}}`),
+		Status:   "F",
+		SendPrep: setSendURL,
+	},
 	{
 		Label:          "Unicode Send",
 		Text:           "☺",
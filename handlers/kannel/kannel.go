@@ -5,25 +5,21 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
-	"github.com/nyaruka/gocommon/gsm7"
 )
 
 const (
-	configEncoding   = "encoding"
 	configVerifySSL  = "verify_ssl"
 	configDLRMask    = "dlr_mask"
 	configIgnoreSent = "ignore_sent"
 
-	encodingDefault = "D"
-	encodingUnicode = "U"
-	encodingSmart   = "S"
-
 	// see: https://kannel.org/download/1.5.0/userguide-1.5.0/userguide.html#DELIVERY-REPORTS
 	// registers us for submit to smsc failure, submit to smsc success, delivery to handset success, delivery to handset failure
 	defaultDLRMask = "27"
@@ -69,7 +65,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	date := time.Unix(form.TS, 0).UTC()
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.Sender, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.Sender)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -119,6 +115,15 @@ func (h *handler) receiveStatus(ctx context.Context, channel courier.Channel, w
 	return handlers.WriteMsgStatusAndResponse(ctx, h, channel, status, w, r)
 }
 
+// the provider options we support, validity is in minutes
+var providerOptions = []courier.ProviderOption{
+	{Name: "validity", Type: courier.ProviderOptionInt},
+	{Name: "dlr_mask", Type: courier.ProviderOptionInt},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption { return providerOptions }
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	username := msg.Channel().StringConfigForKey(courier.ConfigUsername, "")
@@ -136,7 +141,15 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		return nil, fmt.Errorf("no send url set for KN channel")
 	}
 
+	options, failed := h.GetProviderOptions(msg, providerOptions)
+	if failed != nil {
+		return failed, nil
+	}
+
 	dlrMask := msg.Channel().StringConfigForKey(configDLRMask, defaultDLRMask)
+	if mask, found := options.Int("dlr_mask"); found {
+		dlrMask = strconv.Itoa(mask)
+	}
 
 	callbackDomain := msg.Channel().CallbackDomain(h.Server().Config().Domain)
 	dlrURL := fmt.Sprintf("https://%s/c/kn/%s/status?id=%s&status=%%d", callbackDomain, msg.Channel().UUID(), msg.ID().String())
@@ -156,6 +169,10 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		form["priority"] = []string{"1"}
 	}
 
+	if validity, found := options.Int("validity"); found {
+		form["validity"] = []string{strconv.Itoa(validity)}
+	}
+
 	useNationalStr := msg.Channel().ConfigForKey(courier.ConfigUseNational, false)
 	useNational, _ := useNationalStr.(bool)
 
@@ -165,21 +182,13 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		form["to"] = []string{nationalTo.Path()}
 	}
 
-	// figure out what encoding to tell kannel to send as
-	encoding := msg.Channel().StringConfigForKey(configEncoding, encodingSmart)
-
-	// if we are smart, first try to convert to GSM7 chars
-	if encoding == encodingSmart {
-		replaced := gsm7.ReplaceSubstitutions(handlers.GetTextAndAttachments(msg))
-		if gsm7.IsValid(replaced) {
-			form["text"] = []string{replaced}
-		} else {
-			encoding = encodingUnicode
-		}
-	}
+	// figure out what encoding to tell kannel to send as, if we are smart this will first try to convert to GSM7 chars
+	mode := msg.Channel().StringConfigForKey(sms.ConfigEncoding, sms.EncodingModeSmart)
+	text, encoding := sms.Encode(handlers.GetTextAndAttachments(msg), mode)
+	form["text"] = []string{text}
 
 	// if we are UTF8, set our coding appropriately
-	if encoding == encodingUnicode {
+	if mode != sms.EncodingModeDefault && encoding == sms.EncodingUCS2 {
 		form["coding"] = []string{"2"}
 		form["charset"] = []string{"utf8"}
 	}
@@ -210,7 +219,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	// record our status and log
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
-	status.AddLog(courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithError("Message Send Error", err))
+	status.AddLog(courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithSegments(sms.SegmentsFor(text, encoding)).WithError("Message Send Error", err))
 	if err == nil {
 		status.SetStatus(courier.MsgWired)
 	}
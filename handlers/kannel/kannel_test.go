@@ -1,6 +1,7 @@
 package kannel
 
 import (
+	"encoding/json"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -79,6 +80,18 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		URLParams: map[string]string{"text": "Simple Message", "to": "+250788383383", "coding": "", "priority": "",
 			"dlr-url": "https://localhost/c/kn/8eb23e93-5ecb-45ba-b726-3b064e0c56ab/status?id=10&status=%d"},
 		SendPrep: setSendURL},
+	{Label: "Provider Options Send",
+		Text: "Simple Message", URN: "tel:+250788383383",
+		Metadata:     json.RawMessage(`{"provider_options": {"kn": {"validity": 60, "dlr_mask": 3}}}`),
+		Status:       "W",
+		ResponseBody: "0: Accepted for delivery", ResponseStatus: 200,
+		URLParams: map[string]string{"text": "Simple Message", "to": "+250788383383", "validity": "60", "dlr-mask": "3"},
+		SendPrep:  setSendURL},
+	{Label: "Invalid Provider Options",
+		Text: "Simple Message", URN: "tel:+250788383383",
+		Metadata: json.RawMessage(`{"provider_options": {"kn": {"validity": "an hour"}}}`),
+		Status:   "F",
+		SendPrep: setSendURL},
 	{Label: "Unicode Send",
 		Text: "☺", URN: "tel:+250788383383", HighPriority: false,
 		Status:       "W",
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/courier"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultOptOutKeywords are the keywords which stop a contact when keywords are enabled without a configured list
+var DefaultOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT", "ARRET", "ARRÊT", "PARAR", "PARE", "ALTO", "BAJA"}
+
+// DefaultOptInKeywords are the keywords which opt a contact back in when keywords are enabled without a configured list
+var DefaultOptInKeywords = []string{"START", "UNSTOP", "SUBSCRIBE", "COMMENCER", "INICIAR"}
+
+// Keyword is the kind of keyword an incoming message can match
+type Keyword string
+
+// Possible values for Keyword
+const (
+	NoKeyword     Keyword = ""
+	OptOutKeyword Keyword = "opt_out"
+	OptInKeyword  Keyword = "opt_in"
+)
+
+// MatchKeyword returns the kind of keyword the passed in incoming text matches on the passed in channel, or no keyword
+// if keywords aren't enabled or nothing matches. Matching ignores case, surrounding whitespace and punctuation, so
+// "Stop." matches STOP but "please stop" doesn't.
+func MatchKeyword(channel courier.Channel, text string) Keyword {
+	enabled, _ := channelOrOrgConfig(channel, courier.ConfigKeywords).(bool)
+	if !enabled {
+		return NoKeyword
+	}
+
+	normalized := normalizeKeyword(text)
+	if normalized == "" {
+		return NoKeyword
+	}
+
+	for _, k := range keywordsForChannel(channel, courier.ConfigOptOutKeywords, DefaultOptOutKeywords) {
+		if normalizeKeyword(k) == normalized {
+			return OptOutKeyword
+		}
+	}
+	for _, k := range keywordsForChannel(channel, courier.ConfigOptInKeywords, DefaultOptInKeywords) {
+		if normalizeKeyword(k) == normalized {
+			return OptInKeyword
+		}
+	}
+	return NoKeyword
+}
+
+// handleKeyword handles the keyword matched by the passed in message, queueing the configured confirmation reply if
+// there is one. Opt-outs write a stop contact event, and the event is returned. Opt-ins don't write an event as there
+// is no event type for them, RapidPro reactivates a stopped contact when it handles any message from them so it's the
+// message itself which opts the contact back in, and nil is returned.
+func handleKeyword(ctx context.Context, h ResponseWriter, msg courier.Msg, keyword Keyword) (courier.ChannelEvent, error) {
+	channel := msg.Channel()
+
+	var event courier.ChannelEvent
+	replyKey := courier.ConfigOptInReply
+
+	if keyword == OptOutKeyword {
+		event = h.Backend().NewChannelEvent(channel, courier.StopContact, msg.URN()).WithContactName(msg.ContactName())
+		err := h.Backend().WriteChannelEvent(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		replyKey = courier.ConfigOptOutReply
+	}
+
+	// replies are queued like any other outgoing message, failing to queue one doesn't fail the incoming message
+	reply, _ := channelOrOrgConfig(channel, replyKey).(string)
+	if reply != "" {
+		if _, err := h.Backend().QueueOutgoingReply(ctx, channel, msg.URN(), reply); err != nil {
+			logrus.WithError(err).WithField("channel_uuid", channel.UUID()).WithField("keyword", keyword).Error("error queueing keyword reply")
+		}
+	}
+
+	return event, nil
+}
+
+// keywordsForChannel returns the keywords for the passed in config key, which may be a list or a comma separated string
+func keywordsForChannel(channel courier.Channel, key string, defaults []string) []string {
+	switch v := channelOrOrgConfig(channel, key).(type) {
+	case []string:
+		return v
+	case []interface{}:
+		keywords := make([]string, 0, len(v))
+		for _, k := range v {
+			if s, isStr := k.(string); isStr {
+				keywords = append(keywords, s)
+			}
+		}
+		return keywords
+	case string:
+		return strings.Split(v, ",")
+	}
+	return defaults
+}
+
+// channelOrOrgConfig looks up the passed in key on the channel config, falling back to the org config
+func channelOrOrgConfig(channel courier.Channel, key string) interface{} {
+	value := channel.ConfigForKey(key, nil)
+	if value == nil {
+		value = channel.OrgConfigForKey(key, nil)
+	}
+	return value
+}
+
+func normalizeKeyword(text string) string {
+	return strings.ToUpper(strings.TrimFunc(text, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }))
+}
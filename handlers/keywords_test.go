@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchKeyword(t *testing.T) {
+	disabled := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", nil)
+	defaults := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", map[string]interface{}{courier.ConfigKeywords: true})
+	custom := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", map[string]interface{}{
+		courier.ConfigKeywords:       true,
+		courier.ConfigOptOutKeywords: []interface{}{"sortir", "fin"},
+		courier.ConfigOptInKeywords:  "entrer,debut",
+	})
+	org := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", nil)
+	org.SetOrgConfig(courier.ConfigKeywords, true)
+	org.SetOrgConfig(courier.ConfigOptOutKeywords, []interface{}{"BASTA"})
+
+	tcs := []struct {
+		channel courier.Channel
+		text    string
+		keyword Keyword
+	}{
+		{disabled, "STOP", NoKeyword},
+		{defaults, "STOP", OptOutKeyword},
+		{defaults, " stop. ", OptOutKeyword},
+		{defaults, "Arrêt", OptOutKeyword},
+		{defaults, "please stop", NoKeyword},
+		{defaults, "start", OptInKeyword},
+		{defaults, "", NoKeyword},
+		{defaults, "!!", NoKeyword},
+		{custom, "STOP", NoKeyword},
+		{custom, "Sortir", OptOutKeyword},
+		{custom, "debut", OptInKeyword},
+		{org, "basta!", OptOutKeyword},
+		{org, "start", OptInKeyword},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.keyword, MatchKeyword(tc.channel, tc.text), "keyword mismatch for '%s'", tc.text)
+	}
+}
+
+func TestWriteMsgsAndResponseKeywords(t *testing.T) {
+	mb := courier.NewMockBackend()
+	h := NewBaseHandler("KN", "Kannel")
+	h.SetServer(newServer(mb))
+
+	channel := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", map[string]interface{}{
+		courier.ConfigKeywords:    true,
+		courier.ConfigOptOutReply: "You have been unsubscribed",
+		courier.ConfigOptInReply:  "Welcome back",
+	})
+	urn := urns.URN("tel:+12065551212")
+
+	writeMsg := func(text string) []courier.Event {
+		msg := mb.NewIncomingMsg(channel, urn, text)
+		r := httptest.NewRequest(http.MethodPost, "/c/kn/8eb23e93-5ecb-45ba-b726-3b064e0c56ab/receive", nil)
+		w := httptest.NewRecorder()
+
+		events, err := WriteMsgsAndResponse(context.Background(), &h, []courier.Msg{msg}, w, r)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return events
+	}
+	popReply := func() courier.Msg {
+		reply, err := mb.PopNextOutgoingMsg(context.Background())
+		assert.NoError(t, err)
+		return reply
+	}
+
+	// opting out writes the message and a stop contact event, and queues the opt-out reply
+	events := writeMsg("Stop")
+	assert.Len(t, events, 2)
+
+	msg, err := mb.GetLastQueueMsg()
+	assert.NoError(t, err)
+	assert.Equal(t, "Stop", msg.Text())
+
+	event, err := mb.GetLastChannelEvent()
+	assert.NoError(t, err)
+	assert.Equal(t, courier.StopContact, event.EventType())
+	assert.Equal(t, urn, event.URN())
+
+	reply := popReply()
+	assert.Equal(t, "You have been unsubscribed", reply.Text())
+	assert.Equal(t, urn, reply.URN())
+	assert.True(t, reply.HighPriority())
+
+	// opting in just writes the message, which reactivates the contact, and queues the opt-in reply
+	events = writeMsg("start")
+	assert.Len(t, events, 1)
+
+	msg, err = mb.GetLastQueueMsg()
+	assert.NoError(t, err)
+	assert.Equal(t, "start", msg.Text())
+
+	event, err = mb.GetLastChannelEvent()
+	assert.NoError(t, err)
+	assert.Equal(t, courier.StopContact, event.EventType())
+
+	assert.Equal(t, "Welcome back", popReply().Text())
+
+	// other messages are just written
+	events = writeMsg("hello")
+	assert.Len(t, events, 1)
+	assert.Nil(t, popReply())
+
+	// keywords in messages dropped over our flood limits are ignored
+	mb.SetDropMsgs(true)
+	events = writeMsg("stop")
+	assert.Len(t, events, 0)
+	assert.Nil(t, popReply())
+	mb.SetDropMsgs(false)
+}
@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/buger/jsonparser"
 
@@ -29,6 +31,10 @@ var (
 	maxMsgLength = 2000
 	maxMsgSend   = 5
 
+	// limits on quick replies, see https://developers.line.biz/en/reference/messaging-api/#quick-reply
+	maxQuickReplies     = 13
+	maxActionLabelRunes = 20
+
 	signatureHeader = "X-Line-Signature"
 )
 
@@ -92,6 +98,9 @@ type moPayload struct {
 			Type string `json:"type"`
 			Text string `json:"text"`
 		} `json:"message"`
+		Postback *struct {
+			Data string `json:"data"`
+		} `json:"postback"`
 	} `json:"events"`
 }
 
@@ -111,6 +120,25 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	msgs := []courier.Msg{}
 
 	for _, lineEvent := range payload.Events {
+		// postbacks are sent when a contact selects one of our interactive quick replies
+		if lineEvent.Type == "postback" && lineEvent.Postback != nil && lineEvent.ReplyToken != "" && lineEvent.Source.UserID != "" {
+			replyID, title := parsePostbackData(lineEvent.Postback.Data)
+			if replyID == "" {
+				continue
+			}
+
+			date := time.Unix(0, lineEvent.Timestamp*1000000).UTC()
+			urn, err := urns.NewURNFromParts(urns.LineScheme, lineEvent.Source.UserID, "", "")
+			if err != nil {
+				return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
+			}
+
+			msg := h.Backend().NewIncomingMsg(channel, urn, title).WithExternalID(lineEvent.ReplyToken).WithReceivedOn(date)
+			msg.WithMetadata(courier.NewInteractiveReplyMetadata(replyID))
+			msgs = append(msgs, msg)
+			continue
+		}
+
 		if lineEvent.ReplyToken == "" || (lineEvent.Source.Type == "" && lineEvent.Source.UserID == "") || (lineEvent.Message.Type == "" && lineEvent.Message.ID == "" && lineEvent.Message.Text == "") || lineEvent.Message.Type != "text" {
 			continue
 		}
@@ -181,8 +209,36 @@ func calculateSignature(secret string, r *http.Request) ([]byte, error) {
 }
 
 type mtTextMsg struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type       string        `json:"type"`
+	Text       string        `json:"text"`
+	QuickReply *mtQuickReply `json:"quickReply,omitempty"`
+}
+
+type mtQuickReply struct {
+	Items []mtQuickReplyItem `json:"items"`
+}
+
+type mtQuickReplyItem struct {
+	Type   string   `json:"type"`
+	Action mtAction `json:"action"`
+}
+
+type mtAction struct {
+	Type        string `json:"type"`
+	Label       string `json:"label"`
+	Data        string `json:"data,omitempty"`
+	DisplayText string `json:"displayText,omitempty"`
+	URI         string `json:"uri,omitempty"`
+}
+
+type mtTemplateMsg struct {
+	Type     string `json:"type"`
+	AltText  string `json:"altText"`
+	Template struct {
+		Type    string     `json:"type"`
+		Text    string     `json:"text"`
+		Actions []mtAction `json:"actions"`
+	} `json:"template"`
 }
 
 type mtImageMsg struct {
@@ -205,12 +261,36 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	}
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
 
+	// interactive buttons and list rows are sent as postback quick replies on our last text part
+	text := msg.Text()
+	interactive := msg.Interactive()
+	var quickReply *mtQuickReply
+	if interactive != nil {
+		text = interactive.WrapText(text)
+		quickReply = newQuickReplyFromInteractive(interactive)
+	}
+
 	// all msg parts in JSON
 	var jsonMsgs []string
-	parts := handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
+	parts := handlers.SplitMsgByChannel(msg.Channel(), text, maxMsgLength)
 	// fill all msg parts with text parts
-	for _, part := range parts {
-		if jsonMsg, err := json.Marshal(mtTextMsg{Type: "text", Text: part}); err == nil {
+	for i, part := range parts {
+		textMsg := mtTextMsg{Type: "text", Text: part}
+		if i == len(parts)-1 {
+			textMsg.QuickReply = quickReply
+		}
+		if jsonMsg, err := json.Marshal(textMsg); err == nil {
+			jsonMsgs = append(jsonMsgs, string(jsonMsg))
+		}
+	}
+
+	// a call to action is sent as a buttons template
+	if interactive != nil && interactive.CTA != nil {
+		templateMsg := mtTemplateMsg{Type: "template", AltText: fmt.Sprintf("%s: %s", interactive.CTA.Title, interactive.CTA.URL)}
+		templateMsg.Template.Type = "buttons"
+		templateMsg.Template.Text = interactive.CTA.Title
+		templateMsg.Template.Actions = []mtAction{{Type: "uri", Label: truncateRunes(interactive.CTA.Title, maxActionLabelRunes), URI: interactive.CTA.URL}}
+		if jsonMsg, err := json.Marshal(templateMsg); err == nil {
 			jsonMsgs = append(jsonMsgs, string(jsonMsg))
 		}
 	}
@@ -275,6 +355,69 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	return status, nil
 }
 
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		MaxTextLength:   maxMsgLength,
+		AttachmentTypes: []string{"image/*"},
+	}
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
+// newQuickReplyFromInteractive builds postback quick replies for the buttons or list rows of the passed in interactive
+// content, the postback data carries both the ID and title so we can use the title as the text of the reply
+func newQuickReplyFromInteractive(interactive *courier.Interactive) *mtQuickReply {
+	items := make([]mtQuickReplyItem, 0)
+	addItem := func(id, title string) {
+		if len(items) < maxQuickReplies {
+			data := url.Values{"id": []string{id}, "title": []string{title}}
+			items = append(items, mtQuickReplyItem{Type: "action", Action: mtAction{
+				Type:        "postback",
+				Label:       truncateRunes(title, maxActionLabelRunes),
+				Data:        data.Encode(),
+				DisplayText: title,
+			}})
+		}
+	}
+
+	for _, button := range interactive.Buttons {
+		addItem(button.ID, button.Title)
+	}
+	if len(interactive.Buttons) == 0 && interactive.List != nil {
+		for _, row := range interactive.List.Rows() {
+			addItem(row.ID, row.Title)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	return &mtQuickReply{Items: items}
+}
+
+// parsePostbackData parses the ID and title from the data of one of our quick reply postbacks
+func parsePostbackData(data string) (string, string) {
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return "", ""
+	}
+	id, title := values.Get("id"), values.Get("title")
+	if title == "" {
+		title = id
+	}
+	return id, title
+}
+
+// truncateRunes truncates the passed in string to at most the given number of runes
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
 func buildSendMsgRequest(authToken, to string, replyToken string, jsonMsgs []string) (*http.Request, error) {
 	// convert from string slice to bytes JSON
 	rawJsonMsgs := bytes.Buffer{}
@@ -1,6 +1,7 @@
 package line
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -133,6 +134,22 @@ var testChannels = []courier.Channel{
 		}),
 }
 
+var receivePostback = `
+{
+	"events": [{
+		"replyToken": "abcdefghij",
+		"type": "postback",
+		"timestamp": 1459991487970,
+		"source": {
+			"type": "user",
+			"userId": "uabcdefghij"
+		},
+		"postback": {
+			"data": "id=y&title=Yes"
+		}
+	}]
+}`
+
 var handleTestCases = []ChannelHandleTestCase{
 	{Label: "Receive Valid Message", URL: receiveURL, Data: receiveValidMessage, Status: 200, Response: "Accepted",
 		Text: Sp("Hello, world"), URN: Sp("line:uabcdefghij"), Date: Tp(time.Date(2016, 4, 7, 1, 11, 27, 970000000, time.UTC)),
@@ -140,6 +157,9 @@ var handleTestCases = []ChannelHandleTestCase{
 	{Label: "Receive Valid Message", URL: receiveURL, Data: receiveValidMessageLast, Status: 200, Response: "Accepted",
 		Text: Sp("Last event"), URN: Sp("line:uabcdefghij"), Date: Tp(time.Date(2016, 4, 7, 1, 11, 27, 970000000, time.UTC)),
 		PrepRequest: addValidSignature},
+	{Label: "Receive Postback", URL: receiveURL, Data: receivePostback, Status: 200, Response: "Accepted",
+		Text: Sp("Yes"), URN: Sp("line:uabcdefghij"), Date: Tp(time.Date(2016, 4, 7, 1, 11, 27, 970000000, time.UTC)),
+		PrepRequest: addValidSignature},
 	{Label: "Missing message", URL: receiveURL, Data: missingMessage, Status: 200, Response: "ignoring request, no message",
 		PrepRequest: addValidSignature},
 	{Label: "Invalid URN", URL: receiveURL, Data: invalidURN, Status: 400, Response: "invalid line id",
@@ -197,6 +217,18 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		},
 		RequestBody: `{"to":"uabcdefghij","messages":[{"type":"text","text":"Simple Message"}]}`,
 		SendPrep:    setSendURL},
+	{Label: "Interactive Send",
+		Text: "Are you happy?", URN: "line:uabcdefghij",
+		Metadata:     json.RawMessage(`{"interactive":{"buttons":[{"id":"y","title":"Yes"}],"cta":{"title":"More","url":"https://foo.bar"}}}`),
+		Status:       "W",
+		ResponseBody: `{}`, ResponseStatus: 200,
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Accept":        "application/json",
+			"Authorization": "Bearer AccessToken",
+		},
+		RequestBody: `{"to":"uabcdefghij","messages":[{"type":"text","text":"Are you happy?","quickReply":{"items":[{"type":"action","action":{"type":"postback","label":"Yes","data":"id=y\u0026title=Yes","displayText":"Yes"}}]}},{"type":"template","altText":"More: https://foo.bar","template":{"type":"buttons","text":"More","actions":[{"type":"uri","label":"More","uri":"https://foo.bar"}]}}]}`,
+		SendPrep:    setSendURL},
 	{Label: "Unicode Send",
 		Text: "Simple Message ☺", URN: "line:uabcdefghij",
 		Status:       "W",
@@ -52,7 +52,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(from, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, from)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -123,7 +123,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(sender, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, sender)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -208,6 +208,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		if i == 0 {
 			status.SetExternalID(externalID)
 		}
+		status.AddPartExternalID(externalID)
 	}
 	status.SetStatus(courier.MsgWired)
 	return status, nil
@@ -93,7 +93,7 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 		}
 
 		// create our URN
-		urn, err := handlers.StrictTelForCountry(payload.From, channel.Country())
+		urn, err := handlers.NormalizeTelForChannel(channel, payload.From)
 		if err != nil {
 			return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 		}
@@ -158,6 +158,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 		status.SetStatus(courier.MsgWired)
 		status.SetExternalID(externalID)
+		status.AddPartExternalID(externalID)
 	}
 
 	return status, nil
@@ -86,7 +86,7 @@ func (h *handler) receiveMsg(ctx context.Context, c courier.Channel, w http.Resp
 		defer rc.Close()
 
 		// first things first, populate the new part we just received
-		mapKey := fmt.Sprintf("%s:%s", c.UUID().String(), longID)
+		mapKey := utils.RedisKey("%s:%s", c.UUID().String(), longID)
 		rc.Send("MULTI")
 		rc.Send("HSET", mapKey, longRef, text)
 		rc.Send("EXPIRE", mapKey, 300)
@@ -127,7 +127,7 @@ func (h *handler) receiveMsg(ctx context.Context, c courier.Channel, w http.Resp
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(from, c.Country())
+	urn, err := handlers.NormalizeTelForChannel(c, from)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 	}
@@ -204,6 +204,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			// all went well, set ourselves to wired
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(externalID)
+			status.AddPartExternalID(externalID)
 		} else {
 			status.SetStatus(courier.MsgFailed)
 			log.WithError("Message Send Error", fmt.Errorf("Error status code, failing permanently"))
@@ -12,8 +12,8 @@ import (
 
 	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
-	"github.com/nyaruka/gocommon/gsm7"
 
 	"github.com/buger/jsonparser"
 	"github.com/pkg/errors"
@@ -27,9 +27,9 @@ const (
 )
 
 var (
-	maxMsgLength = 1600
-	sendURL      = "https://rest.nexmo.com/sms/json"
-	throttledRE  = regexp.MustCompile(`.*Throughput Rate Exceeded - please wait \[ (\d+) \] and retry.*`)
+	maxMsgSegments = 10
+	sendURL        = "https://rest.nexmo.com/sms/json"
+	throttledRE    = regexp.MustCompile(`.*Throughput Rate Exceeded - please wait \[ (\d+) \] and retry.*`)
 )
 
 func init() {
@@ -106,7 +106,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -132,16 +132,16 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	callbackDomain := msg.Channel().CallbackDomain(h.Server().Config().Domain)
 	callbackURL := fmt.Sprintf("https://%s/c/nx/%s/status", callbackDomain, msg.Channel().UUID())
 
-	text := handlers.GetTextAndAttachments(msg)
+	text, encoding := sms.Encode(handlers.GetTextAndAttachments(msg), msg.Channel().StringConfigForKey(sms.ConfigEncoding, sms.EncodingModeDefault))
 
 	textType := "text"
-	if !gsm7.IsValid(text) {
+	if encoding == sms.EncodingUCS2 {
 		textType = "unicode"
 	}
 
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
-	parts := handlers.SplitMsgByChannel(msg.Channel(), text, maxMsgLength)
-	for _, part := range parts {
+	parts := handlers.SplitSMSByChannel(msg.Channel(), text, maxMsgSegments)
+	for i, part := range parts {
 		form := url.Values{
 			"api_key":           []string{nexmoAPIKey},
 			"api_secret":        []string{nexmoAPISecret},
@@ -173,7 +173,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		}
 
 		// record our status and log
-		log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr)
+		log := courier.NewChannelLogFromRR("Message Sent", msg.Channel(), msg.ID(), rr).WithSegments(sms.Segments(part))
 		status.AddLog(log)
 		if requestErr != nil {
 			log.WithError("Message Send Error", requestErr)
@@ -186,9 +186,17 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			return status, nil
 		}
 
+		// record what nexmo charged for this part, which is always in euros
+		price, _ := jsonparser.GetString([]byte(rr.Body), "messages", "[0]", "message-price")
+		handlers.AddMsgCost(status, courier.NewMsgCost(price, "EUR"))
+
 		externalID, err := jsonparser.GetString([]byte(rr.Body), "messages", "[0]", "message-id")
 		if err == nil {
-			status.SetExternalID(externalID)
+			// set the external id if this is our first part
+			if i == 0 {
+				status.SetExternalID(externalID)
+			}
+			status.AddPartExternalID(externalID)
 		}
 
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/nyaruka/courier"
 	. "github.com/nyaruka/courier/handlers"
+	"github.com/nyaruka/courier/sms"
 )
 
 var testChannels = []courier.Channel{
@@ -65,6 +66,13 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		PostParams:   map[string]string{"text": "Simple Message", "to": "250788383383", "from": "2020", "api_key": "nexmo-api-key", "api_secret": "nexmo-api-secret", "status-report-req": "1", "type": "text"},
 		ResponseBody: `{"messages":[{"status":"0","message-id":"1002"}]}`, ResponseStatus: 200,
 		SendPrep: setSendURL},
+	{Label: "Send With Price",
+		Text: "Priced Message", URN: "tel:+250788383383",
+		Status: "W", ExternalID: "1002",
+		Cost:         &courier.MsgCost{Amount: 0.0333, Currency: "EUR"},
+		PostParams:   map[string]string{"text": "Priced Message", "to": "250788383383", "from": "2020", "type": "text"},
+		ResponseBody: `{"messages":[{"status":"0","message-id":"1002","message-price":"0.03330000"}]}`, ResponseStatus: 200,
+		SendPrep: setSendURL},
 	{Label: "Unicode Send",
 		Text: "Unicode ☺", URN: "tel:+250788383383",
 		Status: "W", ExternalID: "1002",
@@ -75,7 +83,7 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		Text:   "This is a longer message than 160 characters and will cause us to split it into two separate parts, isn't that right but it is even longer than before I say, I need to keep adding more things to make it work",
 		URN:    "tel:+250788383383",
 		Status: "W", ExternalID: "1002",
-		PostParams:   map[string]string{"text": "I need to keep adding more things to make it work", "to": "250788383383", "from": "2020", "api_key": "nexmo-api-key", "api_secret": "nexmo-api-secret", "status-report-req": "1", "type": "text"},
+		PostParams:   map[string]string{"text": "need to keep adding more things to make it work", "to": "250788383383", "from": "2020", "api_key": "nexmo-api-key", "api_secret": "nexmo-api-secret", "status-report-req": "1", "type": "text"},
 		ResponseBody: `{"messages":[{"status":"0","message-id":"1002"}]}`, ResponseStatus: 200,
 		SendPrep: setSendURL},
 	{Label: "Send Attachment",
@@ -110,8 +118,23 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		SendPrep: setSendURL},
 }
 
+var smartSendTestCases = []ChannelSendTestCase{
+	{Label: "Smart Encoding",
+		Text: "Fancy “Smart” Quotes…", URN: "tel:+250788383383",
+		Status: "W", ExternalID: "1002",
+		PostParams:   map[string]string{"text": `Fancy "Smart" Quotes...`, "to": "250788383383", "from": "2020", "api_key": "nexmo-api-key", "api_secret": "nexmo-api-secret", "status-report-req": "1", "type": "text"},
+		ResponseBody: `{"messages":[{"status":"0","message-id":"1002"}]}`, ResponseStatus: 200,
+		SendPrep: setSendURL},
+	{Label: "Smart Encoding Unicode",
+		Text: "Fancy “Smart” Quotes ☺", URN: "tel:+250788383383",
+		Status: "W", ExternalID: "1002",
+		PostParams:   map[string]string{"text": "Fancy “Smart” Quotes ☺", "to": "250788383383", "from": "2020", "api_key": "nexmo-api-key", "api_secret": "nexmo-api-secret", "status-report-req": "1", "type": "unicode"},
+		ResponseBody: `{"messages":[{"status":"0","message-id":"1002"}]}`, ResponseStatus: 200,
+		SendPrep: setSendURL},
+}
+
 func TestSending(t *testing.T) {
-	maxMsgLength = 160
+	maxMsgSegments = 1
 	var defaultChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "NX", "2020", "US",
 		map[string]interface{}{
 			configNexmoAPIKey:        "nexmo-api-key",
@@ -120,5 +143,13 @@ func TestSending(t *testing.T) {
 			configNexmoAppPrivateKey: "nexmo-app-private-key",
 		})
 
+	var smartChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "NX", "2020", "US",
+		map[string]interface{}{
+			configNexmoAPIKey:    "nexmo-api-key",
+			configNexmoAPISecret: "nexmo-api-secret",
+			sms.ConfigEncoding:   sms.EncodingModeSmart,
+		})
+
 	RunChannelSendTestCases(t, defaultChannel, newHandler(), defaultSendTestCases, nil)
+	RunChannelSendTestCases(t, smartChannel, newHandler(), smartSendTestCases, nil)
 }
@@ -114,7 +114,7 @@ func (h *handler) receiveMessage(ctx context.Context, c courier.Channel, w http.
 		}
 
 		// create our URN
-		urn, err := handlers.StrictTelForCountry(pmMsg.MSIDSN, c.Country())
+		urn, err := handlers.NormalizeTelForChannel(c, pmMsg.MSIDSN)
 		if err != nil {
 			return nil, handlers.WriteAndLogRequestError(ctx, h, c, w, r, err)
 		}
@@ -14,7 +14,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -128,26 +127,12 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	urns.ValidSchemes[scheme] = true
 
 	//Handle SMS (tel) specially, everything else is a straight string passthrough
-	if mode == "SMS" {
-		// Remove out + and - just in case
-		value := payload.Contact.Value
-		value = strings.Replace(value, " ", "", -1)
-		value = strings.Replace(value, "-", "", -1)
-
-		// Only add + if it is a full phone, not a shortcode
-		if len(value) > 8 {
-			isNumeric := regexp.MustCompile(`^[0-9 ]+$`).MatchString
-
-			if isNumeric(value) {
-				value = "+" + value
-			}
-		}
-
-		payload.Contact.Value = value
+	if mode == "SMS" && scheme == urns.TelScheme {
+		urn, err = handlers.NormalizeTelWithPolicy(channel, payload.Contact.Value, channel.Country(), handlers.TelPolicyAlphanumeric)
+	} else {
+		urn, err = urns.NewURNFromParts(scheme, payload.Contact.Value, "", "")
 	}
 
-	urn, err = urns.NewURNFromParts(scheme, payload.Contact.Value, "", "")
-
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
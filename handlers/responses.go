@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/nyaruka/courier"
+	"github.com/sirupsen/logrus"
 )
 
 // ResponseWriter interace with response methods for success responses
@@ -16,18 +17,62 @@ type ResponseWriter interface {
 	WriteRequestIgnored(ctx context.Context, w http.ResponseWriter, r *http.Request, msg string) error
 }
 
-// WriteMsgsAndResponse writes the passed in message to our backend
+// WriteMsgsAndResponse writes the passed in message to our backend, along with any opt-out events triggered by
+// keywords in them
 func WriteMsgsAndResponse(ctx context.Context, h ResponseWriter, msgs []courier.Msg, w http.ResponseWriter, r *http.Request) ([]courier.Event, error) {
-	events := make([]courier.Event, len(msgs), len(msgs))
-	for i, m := range msgs {
+	events, err := WriteMsgs(ctx, h, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, h.WriteMsgSuccessResponse(ctx, w, r, msgs)
+}
+
+// WriteMsgs writes the passed in messages to our backend, along with any opt-out events triggered by keywords in
+// them, for handlers which receive messages other than by webhook
+func WriteMsgs(ctx context.Context, h ResponseWriter, msgs []courier.Msg) ([]courier.Event, error) {
+	events := make([]courier.Event, 0, len(msgs))
+	for _, m := range msgs {
+		// map numeric replies to numbered menus back to the option picked
+		if m.Channel().BoolConfigForKey(courier.ConfigNumberedMenu, false) {
+			resolveNumberedMenuReply(h.Backend(), m)
+		}
+
+		// check for keywords before writing, matched messages are still kept
+		keyword := MatchKeyword(m.Channel(), m.Text())
+
 		err := h.Backend().WriteMsg(ctx, m)
+		if err == courier.ErrMsgDropped {
+			logrus.WithField("channel_uuid", m.Channel().UUID()).WithField("msg_uuid", m.UUID()).Info("incoming message dropped, ignoring any keyword in it")
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
-		events[i] = m
+		events = append(events, m)
+
+		if keyword != NoKeyword {
+			event, err := handleKeyword(ctx, h, m, keyword)
+			if err != nil {
+				return nil, err
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
 	}
+	return events, nil
+}
 
-	return events, h.WriteMsgSuccessResponse(ctx, w, r, msgs)
+// resolveNumberedMenuReply maps the passed in message to the numbered menu option it picked, failures are logged and
+// the message written as is
+func resolveNumberedMenuReply(b courier.Backend, m courier.Msg) {
+	rc := b.RedisPool().Get()
+	defer rc.Close()
+
+	if _, err := courier.ResolveNumberedMenuReply(rc, m); err != nil {
+		logrus.WithError(err).WithField("channel_uuid", m.Channel().UUID()).Error("error resolving numbered menu reply")
+	}
 }
 
 // WriteMsgStatusAndResponse write the passed in status to our backend
@@ -70,7 +70,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(payload.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, payload.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -181,6 +181,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			if i == 0 {
 				status.SetExternalID(response.ID)
 			}
+			status.AddPartExternalID(response.ID)
 		}
 	}
 
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/sirupsen/logrus"
+)
+
+// TelPolicy determines how the phone numbers of incoming messages are normalized into tel URNs
+type TelPolicy string
+
+// Possible values for TelPolicy
+const (
+	// TelPolicyStrict only accepts numbers which can be normalized to E.164
+	TelPolicyStrict TelPolicy = "strict"
+
+	// TelPolicyCountry parses numbers using the channel country and accepts anything numeric, this is the default
+	TelPolicyCountry TelPolicy = "country"
+
+	// TelPolicyShortCode accepts E.164 numbers as well as numeric short codes, which are kept as is
+	TelPolicyShortCode TelPolicy = "short_code"
+
+	// TelPolicyAlphanumeric parses numbers using the channel country and also accepts alphanumeric sender IDs
+	TelPolicyAlphanumeric TelPolicy = "alphanumeric"
+)
+
+// short codes are numeric and never longer than this, anything longer is treated as a full number
+const maxShortCodeLength = 8
+
+// NormalizeTelForChannel creates a tel URN for the passed in number using the normalization policy configured
+// on the channel, falling back to the country policy
+func NormalizeTelForChannel(channel courier.Channel, number string) (urns.URN, error) {
+	return NormalizeTelWithPolicy(channel, number, channel.Country(), TelPolicyCountry)
+}
+
+// NormalizeTelWithPolicy creates a tel URN for the passed in number and country using the normalization policy
+// configured on the channel, falling back to the passed in policy. Rejections are logged at debug level without the
+// number itself, as it's contact data.
+func NormalizeTelWithPolicy(channel courier.Channel, number string, country string, defaultPolicy TelPolicy) (urns.URN, error) {
+	policy := TelPolicy(channel.StringConfigForKey(courier.ConfigTelPolicy, string(defaultPolicy)))
+
+	urn, err := NormalizeTel(number, country, policy)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"channel_uuid": channel.UUID(),
+			"channel_type": channel.ChannelType(),
+			"tel_policy":   policy,
+		}).WithError(err).Debug("phone number rejected")
+	}
+	return urn, err
+}
+
+// NormalizeTel creates a tel URN for the passed in number and country using the passed in policy
+func NormalizeTel(number string, country string, policy TelPolicy) (urns.URN, error) {
+	switch policy {
+	case TelPolicyCountry, "":
+		return StrictTelForCountry(number, country)
+
+	case TelPolicyStrict:
+		return strictE164Tel(number, country)
+
+	case TelPolicyShortCode:
+		if isShortCode(number) {
+			return urns.NewURNFromParts(urns.TelScheme, strings.TrimSpace(number), "", "")
+		}
+		return strictE164Tel(number, country)
+
+	case TelPolicyAlphanumeric:
+		return urns.NewTelURNForCountry(number, country)
+	}
+
+	return urns.NilURN, fmt.Errorf("unknown phone number policy '%s'", policy)
+}
+
+// strictE164Tel returns a tel URN for the passed in number only if it can be normalized to E.164
+func strictE164Tel(number string, country string) (urns.URN, error) {
+	urn, err := StrictTelForCountry(number, country)
+	if err != nil {
+		return urns.NilURN, err
+	}
+
+	if !strings.HasPrefix(urn.Path(), "+") {
+		return urns.NilURN, fmt.Errorf("phone number supplied is not a valid E.164 number")
+	}
+	return urn, nil
+}
+
+// isShortCode returns whether the passed in number looks like a short code
+func isShortCode(number string) bool {
+	number = strings.TrimSpace(number)
+	if number == "" || len(number) > maxShortCodeLength {
+		return false
+	}
+	_, err := strconv.Atoi(number)
+	return err == nil && !strings.HasPrefix(number, "+") && !strings.HasPrefix(number, "-")
+}
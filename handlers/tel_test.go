@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTel(t *testing.T) {
+	tcs := []struct {
+		number  string
+		country string
+		policy  TelPolicy
+		urn     urns.URN
+		err     string
+	}{
+		{"+250788383383", "RW", TelPolicyCountry, "tel:+250788383383", ""},
+		{"0788383383", "RW", TelPolicyCountry, "tel:+250788383383", ""},
+		{"2020", "RW", TelPolicyCountry, "tel:2020", ""},
+		{"MTN", "RW", TelPolicyCountry, urns.NilURN, "phone number supplied is not a number"},
+		{"0788383383", "RW", "", "tel:+250788383383", ""},
+
+		{"+250788383383", "RW", TelPolicyStrict, "tel:+250788383383", ""},
+		{"0788383383", "RW", TelPolicyStrict, "tel:+250788383383", ""},
+		{"2020", "RW", TelPolicyStrict, urns.NilURN, "phone number supplied is not a valid E.164 number"},
+		{"MTN", "RW", TelPolicyStrict, urns.NilURN, "phone number supplied is not a number"},
+
+		{"+250788383383", "RW", TelPolicyShortCode, "tel:+250788383383", ""},
+		{"2020", "RW", TelPolicyShortCode, "tel:2020", ""},
+		{"12345678", "RW", TelPolicyShortCode, "tel:12345678", ""},
+		{"MTN", "RW", TelPolicyShortCode, urns.NilURN, "phone number supplied is not a number"},
+
+		{"+250788383383", "RW", TelPolicyAlphanumeric, "tel:+250788383383", ""},
+		{"2020", "RW", TelPolicyAlphanumeric, "tel:2020", ""},
+		{"MTN", "RW", TelPolicyAlphanumeric, "tel:mtn", ""},
+
+		{"+250788383383", "RW", "foo", urns.NilURN, "unknown phone number policy 'foo'"},
+	}
+
+	for _, tc := range tcs {
+		urn, err := NormalizeTel(tc.number, tc.country, tc.policy)
+		assert.Equal(t, tc.urn, urn, "urn mismatch for %s with policy %s", tc.number, tc.policy)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "error mismatch for %s with policy %s", tc.number, tc.policy)
+		} else {
+			assert.NoError(t, err, "unexpected error for %s with policy %s", tc.number, tc.policy)
+		}
+	}
+}
+
+func TestNormalizeTelForChannel(t *testing.T) {
+	defaultChannel := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "RW", nil)
+	strictChannel := courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "RW", map[string]interface{}{courier.ConfigTelPolicy: "strict"})
+
+	urn, err := NormalizeTelForChannel(defaultChannel, "2020")
+	assert.NoError(t, err)
+	assert.Equal(t, urns.URN("tel:2020"), urn)
+
+	_, err = NormalizeTelForChannel(strictChannel, "2020")
+	assert.Error(t, err)
+
+	// channel config takes precedence over the handler's default
+	urn, err = NormalizeTelWithPolicy(defaultChannel, "MTN", "RW", TelPolicyAlphanumeric)
+	assert.NoError(t, err)
+	assert.Equal(t, urns.URN("tel:mtn"), urn)
+
+	_, err = NormalizeTelWithPolicy(strictChannel, "MTN", "RW", TelPolicyAlphanumeric)
+	assert.Error(t, err)
+}
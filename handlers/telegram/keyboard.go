@@ -1,6 +1,9 @@
 package telegram
 
-import "github.com/nyaruka/courier/utils"
+import (
+	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/utils"
+)
 
 // KeyboardButton is button on a keyboard, see https://core.telegram.org/bots/api/#keyboardbutton
 type KeyboardButton struct {
@@ -30,3 +33,70 @@ func NewKeyboardFromReplies(replies []string) *ReplyKeyboardMarkup {
 
 	return &ReplyKeyboardMarkup{Keyboard: keyboard, ResizeKeyboard: true, OneTimeKeyboard: true}
 }
+
+// InlineKeyboardButton is a button on an inline keyboard, see https://core.telegram.org/bots/api/#inlinekeyboardbutton
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// InlineKeyboardMarkup models a keyboard attached to a message, see https://core.telegram.org/bots/api/#inlinekeyboardmarkup
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// maximum number of bytes Telegram allows in callback data
+const maxCallbackDataBytes = 64
+
+// NewInlineKeyboardFromReplies creates an inline keyboard from the given quick replies, pressing a button sends
+// us a callback query with the reply as its data
+func NewInlineKeyboardFromReplies(replies []string) *InlineKeyboardMarkup {
+	rows := utils.StringsToRows(replies, 5, 30, 2)
+	keyboard := make([][]InlineKeyboardButton, len(rows))
+
+	for i := range rows {
+		keyboard[i] = make([]InlineKeyboardButton, len(rows[i]))
+		for j := range rows[i] {
+			keyboard[i][j].Text = rows[i][j]
+			keyboard[i][j].CallbackData = truncateBytes(rows[i][j], maxCallbackDataBytes)
+		}
+	}
+
+	return &InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// NewInlineKeyboardFromInteractive creates an inline keyboard from the given interactive content, buttons and list rows
+// send their IDs as callback data and a call to action opens its URL
+func NewInlineKeyboardFromInteractive(interactive *courier.Interactive) *InlineKeyboardMarkup {
+	keyboard := make([][]InlineKeyboardButton, 0)
+
+	for _, button := range interactive.Buttons {
+		keyboard = append(keyboard, []InlineKeyboardButton{{Text: button.Title, CallbackData: truncateBytes(button.ID, maxCallbackDataBytes)}})
+	}
+	if len(interactive.Buttons) == 0 && interactive.List != nil {
+		for _, row := range interactive.List.Rows() {
+			keyboard = append(keyboard, []InlineKeyboardButton{{Text: row.Title, CallbackData: truncateBytes(row.ID, maxCallbackDataBytes)}})
+		}
+	}
+	if interactive.CTA != nil {
+		keyboard = append(keyboard, []InlineKeyboardButton{{Text: interactive.CTA.Title, URL: interactive.CTA.URL}})
+	}
+
+	return &InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// truncateBytes truncates the passed in string to at most the given number of bytes without splitting a character
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if i > max {
+			break
+		}
+		cut = i
+	}
+	return s[:cut]
+}
@@ -1,8 +1,10 @@
 package telegram_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers/telegram"
 	"github.com/stretchr/testify/assert"
 )
@@ -60,3 +62,45 @@ func TestKeyboardFromReplies(t *testing.T) {
 		assert.Equal(t, tc.expected, kb, "keyboard mismatch for replies %v", tc.replies)
 	}
 }
+
+func TestInlineKeyboardFromReplies(t *testing.T) {
+	keyboard := telegram.NewInlineKeyboardFromReplies([]string{"Yes", "No"})
+	assert.Equal(t, &telegram.InlineKeyboardMarkup{
+		[][]telegram.InlineKeyboardButton{
+			{{Text: "Yes", CallbackData: "Yes"}, {Text: "No", CallbackData: "No"}},
+		},
+	}, keyboard)
+
+	// callback data is limited to 64 bytes
+	long := strings.Repeat("é", 40)
+	keyboard = telegram.NewInlineKeyboardFromReplies([]string{long})
+	assert.Equal(t, long, keyboard.InlineKeyboard[0][0].Text)
+	assert.Equal(t, strings.Repeat("é", 32), keyboard.InlineKeyboard[0][0].CallbackData)
+}
+
+func TestInlineKeyboardFromInteractive(t *testing.T) {
+	keyboard := telegram.NewInlineKeyboardFromInteractive(&courier.Interactive{
+		Buttons: []courier.InteractiveButton{{ID: "y", Title: "Yes"}, {ID: "n", Title: "No"}},
+		CTA:     &courier.InteractiveCTA{Title: "More", URL: "https://foo.bar"},
+	})
+	assert.Equal(t, &telegram.InlineKeyboardMarkup{
+		[][]telegram.InlineKeyboardButton{
+			{{Text: "Yes", CallbackData: "y"}},
+			{{Text: "No", CallbackData: "n"}},
+			{{Text: "More", URL: "https://foo.bar"}},
+		},
+	}, keyboard)
+
+	keyboard = telegram.NewInlineKeyboardFromInteractive(&courier.Interactive{
+		List: &courier.InteractiveList{Button: "Colors", Sections: []courier.InteractiveSection{
+			{Title: "Warm", Rows: []courier.InteractiveRow{{ID: "r", Title: "Red"}}},
+			{Title: "Cool", Rows: []courier.InteractiveRow{{ID: "b", Title: "Blue"}}},
+		}},
+	})
+	assert.Equal(t, &telegram.InlineKeyboardMarkup{
+		[][]telegram.InlineKeyboardButton{
+			{{Text: "Red", CallbackData: "r"}},
+			{{Text: "Blue", CallbackData: "b"}},
+		},
+	}, keyboard)
+}
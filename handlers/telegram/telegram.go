@@ -24,6 +24,14 @@ var apiURL = "https://api.telegram.org"
 
 var forwardConfigKey = "forward_id"
 
+// channel config or msg metadata key to send quick replies as an inline keyboard
+var configInlineKeyboard = "inline_keyboard"
+
+// how many seconds Telegram holds our getUpdates requests open waiting for updates
+var pollTimeout = 25
+
+var errAttachmentDownload = errors.New("Could not download attachments")
+
 func init() {
 	courier.RegisterHandler(newHandler())
 }
@@ -52,72 +60,174 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// no message? ignore this
-	if payload.Message.MessageID == 0 {
+	if !payload.hasContent() {
 		return nil, handlers.WriteAndLogRequestIgnored(ctx, h, channel, w, r, "Ignoring request, no message")
 	}
 
+	event, err := h.eventForPayload(ctx, channel, payload)
+	if err == errAttachmentDownload {
+		w.WriteHeader(200)
+		return nil, err
+	}
+	if err != nil {
+		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
+	}
+
+	// this is a start command, trigger a new conversation
+	if channelEvent, isChannelEvent := event.(courier.ChannelEvent); isChannelEvent {
+		err = h.Backend().WriteChannelEvent(ctx, channelEvent)
+		if err != nil {
+			return nil, err
+		}
+		return []courier.Event{channelEvent}, courier.WriteChannelEventSuccess(ctx, w, r, channelEvent)
+	}
+
+	// and finally write our message
+	events, err := handlers.WriteMsgsAndResponse(ctx, h, []courier.Msg{event.(courier.Msg)}, w, r)
+	if err == nil && payload.CallbackQuery != nil {
+		h.answerCallbackQuery(ctx, channel, payload.CallbackQuery)
+	}
+	return events, err
+}
+
+// PollChannel fetches any new updates for the passed in channel using long polling, for channels which can't
+// receive webhooks. Telegram won't return updates for bots which have a webhook set.
+func (h *handler) PollChannel(ctx context.Context, channel courier.Channel, cursor string) ([]courier.Event, []*courier.ChannelLog, string, error) {
+	authToken := channel.StringConfigForKey(courier.ConfigAuthToken, "")
+	if authToken == "" {
+		return nil, nil, cursor, fmt.Errorf("invalid auth token config")
+	}
+
+	form := url.Values{
+		"timeout":         []string{strconv.Itoa(pollTimeout)},
+		"allowed_updates": []string{`["message","edited_message","callback_query"]`},
+	}
+	if cursor != "" {
+		form.Set("offset", cursor)
+	}
+
+	pollURL := fmt.Sprintf("%s/bot%s/getUpdates", apiURL, authToken)
+	req, err := http.NewRequest(http.MethodPost, pollURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, cursor, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	rr, err := utils.MakeHTTPRequest(req)
+	logs := []*courier.ChannelLog{courier.NewChannelLogFromRR("Updates Polled", channel, courier.NilMsgID, rr).WithError("Polling Error", err)}
+	if err != nil {
+		return nil, logs, cursor, err
+	}
+
+	response := &pollResponse{}
+	err = json.Unmarshal(rr.Body, response)
+	if err != nil || !response.Ok {
+		return nil, logs, cursor, errors.Errorf("response not 'ok'")
+	}
+
+	events := make([]courier.Event, 0, len(response.Result))
+	for _, payload := range response.Result {
+		if payload.hasContent() {
+			event, err := h.eventForPayload(ctx, channel, payload)
+			if err != nil {
+				logrus.WithError(err).WithField("channel_uuid", channel.UUID()).WithField("update_id", payload.UpdateID).Error("error handling polled update")
+			} else if channelEvent, isChannelEvent := event.(courier.ChannelEvent); isChannelEvent {
+				err = h.Backend().WriteChannelEvent(ctx, channelEvent)
+				if err != nil {
+					return events, logs, cursor, err
+				}
+				events = append(events, channelEvent)
+			} else {
+				written, err := handlers.WriteMsgs(ctx, h, []courier.Msg{event.(courier.Msg)})
+				if err != nil {
+					return events, logs, cursor, err
+				}
+				events = append(events, written...)
+
+				if payload.CallbackQuery != nil {
+					h.answerCallbackQuery(ctx, channel, payload.CallbackQuery)
+				}
+			}
+		}
+
+		// this update is dealt with, the next poll should start after it
+		cursor = strconv.FormatInt(payload.UpdateID+1, 10)
+	}
+
+	return events, logs, cursor, nil
+}
+
+// eventForPayload builds the msg or channel event for the passed in update
+func (h *handler) eventForPayload(ctx context.Context, channel courier.Channel, payload *moPayload) (courier.Event, error) {
+	// callback queries come from buttons on inline keyboards, the button data is the text of a new message
+	if payload.CallbackQuery != nil {
+		return h.callbackQueryMsg(channel, payload.CallbackQuery)
+	}
+
+	// edits of previous messages come through as new messages, with metadata so they can be told apart
+	message, edited := &payload.Message, false
+	if message.MessageID == 0 {
+		message, edited = &payload.EditedMessage, true
+	}
+
 	// create our date from the timestamp
-	date := time.Unix(payload.Message.Date, 0).UTC()
+	date := time.Unix(message.Date, 0).UTC()
 
 	// create our URN
-	urn, err := urns.NewTelegramURN(payload.Message.From.ContactID, strings.ToLower(payload.Message.From.Username))
+	urn, err := urns.NewTelegramURN(message.From.ContactID, strings.ToLower(message.From.Username))
 	if err != nil {
-		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
+		return nil, err
 	}
 
 	// build our name from first and last
-	name := handlers.NameFromFirstLastUsername(payload.Message.From.FirstName, payload.Message.From.LastName, payload.Message.From.Username)
+	name := handlers.NameFromFirstLastUsername(message.From.FirstName, message.From.LastName, message.From.Username)
 
 	// our text is either "text" or "caption" (or empty)
-	text := payload.Message.Text
+	text := message.Text
 
 	// this is a start command, trigger a new conversation
 	if text == "/start" {
-		event := h.Backend().NewChannelEvent(channel, courier.NewConversation, urn).WithContactName(name).WithOccurredOn(date)
-		err = h.Backend().WriteChannelEvent(ctx, event)
-		if err != nil {
-			return nil, err
-		}
-		return []courier.Event{event}, courier.WriteChannelEventSuccess(ctx, w, r, event)
+		return h.Backend().NewChannelEvent(channel, courier.NewConversation, urn).WithContactName(name).WithOccurredOn(date), nil
 	}
 
 	// normal message of some kind
-	if text == "" && payload.Message.Caption != "" {
-		text = payload.Message.Caption
+	if text == "" && message.Caption != "" {
+		text = message.Caption
 	}
 
 	// deal with attachments
 	mediaURL := ""
-	if len(payload.Message.Photo) > 0 {
+	if len(message.Photo) > 0 {
 		// grab the largest photo less than 100k
-		photo := payload.Message.Photo[0]
-		for i := 1; i < len(payload.Message.Photo); i++ {
-			if payload.Message.Photo[i].FileSize > 100000 {
+		photo := message.Photo[0]
+		for i := 1; i < len(message.Photo); i++ {
+			if message.Photo[i].FileSize > 100000 {
 				break
 			}
-			photo = payload.Message.Photo[i]
+			photo = message.Photo[i]
 		}
 		mediaURL, err = h.resolveFileID(ctx, channel, photo.FileID)
-	} else if payload.Message.Video != nil {
-		mediaURL, err = h.resolveFileID(ctx, channel, payload.Message.Video.FileID)
-	} else if payload.Message.Voice != nil {
-		mediaURL, err = h.resolveFileID(ctx, channel, payload.Message.Voice.FileID)
-	} else if payload.Message.Sticker != nil {
-		mediaURL, err = h.resolveFileID(ctx, channel, payload.Message.Sticker.Thumb.FileID)
-	} else if payload.Message.Document != nil {
-		mediaURL, err = h.resolveFileID(ctx, channel, payload.Message.Document.FileID)
-	} else if payload.Message.Venue != nil {
-		text = utils.JoinNonEmpty(", ", payload.Message.Venue.Title, payload.Message.Venue.Address)
-		mediaURL = fmt.Sprintf("geo:%f,%f", payload.Message.Location.Latitude, payload.Message.Location.Longitude)
-	} else if payload.Message.Location != nil {
-		text = fmt.Sprintf("%f,%f", payload.Message.Location.Latitude, payload.Message.Location.Longitude)
-		mediaURL = fmt.Sprintf("geo:%f,%f", payload.Message.Location.Latitude, payload.Message.Location.Longitude)
-	} else if payload.Message.Contact != nil {
+	} else if message.Video != nil {
+		mediaURL, err = h.resolveFileID(ctx, channel, message.Video.FileID)
+	} else if message.Voice != nil {
+		mediaURL, err = h.resolveFileID(ctx, channel, message.Voice.FileID)
+	} else if message.Sticker != nil {
+		mediaURL, err = h.resolveFileID(ctx, channel, message.Sticker.Thumb.FileID)
+	} else if message.Document != nil {
+		mediaURL, err = h.resolveFileID(ctx, channel, message.Document.FileID)
+	} else if message.Venue != nil {
+		text = utils.JoinNonEmpty(", ", message.Venue.Title, message.Venue.Address)
+		mediaURL = fmt.Sprintf("geo:%f,%f", message.Location.Latitude, message.Location.Longitude)
+	} else if message.Location != nil {
+		text = fmt.Sprintf("%f,%f", message.Location.Latitude, message.Location.Longitude)
+		mediaURL = fmt.Sprintf("geo:%f,%f", message.Location.Latitude, message.Location.Longitude)
+	} else if message.Contact != nil {
 		phone := ""
-		if payload.Message.Contact.PhoneNumber != "" {
-			phone = fmt.Sprintf("(%s)", payload.Message.Contact.PhoneNumber)
+		if message.Contact.PhoneNumber != "" {
+			phone = fmt.Sprintf("(%s)", message.Contact.PhoneNumber)
 		}
-		text = utils.JoinNonEmpty(" ", payload.Message.Contact.FirstName, payload.Message.Contact.LastName, phone)
+		text = utils.JoinNonEmpty(" ", message.Contact.FirstName, message.Contact.LastName, phone)
 	}
 
 	// we had an error downloading media
@@ -125,21 +235,85 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	//	return nil, handlers.WriteAndLogRequestIgnored(ctx, h, channel, w, r, fmt.Sprintf("unable to resolve file: %s", err.Error()))
 	//}
 	if err != nil {
-		h.forwardMsg(channel, payload)
-
-		w.WriteHeader(200)
-
-		return nil, fmt.Errorf("Could not download attachments")
+		h.forwardMsg(channel, message)
+		return nil, errAttachmentDownload
 	}
 
 	// build our msg
-	msg := h.Backend().NewIncomingMsg(channel, urn, text).WithReceivedOn(date).WithExternalID(fmt.Sprintf("%d", payload.Message.MessageID)).WithContactName(name)
+	msg := h.Backend().NewIncomingMsg(channel, urn, text).WithReceivedOn(date).WithExternalID(fmt.Sprintf("%d", message.MessageID)).WithContactName(name)
 
 	if mediaURL != "" {
 		msg.WithAttachment(mediaURL)
 	}
-	// and finally write our message
-	return handlers.WriteMsgsAndResponse(ctx, h, []courier.Msg{msg}, w, r)
+	if edited {
+		msg.WithMetadata(json.RawMessage(fmt.Sprintf(`{"edited":true,"edit_date":%d}`, message.EditDate)))
+	}
+	return msg, nil
+}
+
+// callbackQueryMsg builds the msg for a button being pressed on one of our inline keyboards
+func (h *handler) callbackQueryMsg(channel courier.Channel, callback *moCallbackQuery) (courier.Msg, error) {
+	urn, err := urns.NewTelegramURN(callback.From.ContactID, strings.ToLower(callback.From.Username))
+	if err != nil {
+		return nil, err
+	}
+
+	name := handlers.NameFromFirstLastUsername(callback.From.FirstName, callback.From.LastName, callback.From.Username)
+
+	// the callback data is the ID of the button pressed, use the button's text as our text if we can find it
+	text := callback.Data
+	if callback.Message != nil && callback.Message.ReplyMarkup != nil {
+		for _, row := range callback.Message.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if button.CallbackData == callback.Data && button.Text != "" {
+					text = button.Text
+				}
+			}
+		}
+	}
+
+	msg := h.Backend().NewIncomingMsg(channel, urn, text).WithReceivedOn(time.Now().UTC()).WithExternalID(callback.ID).WithContactName(name)
+	if callback.Message != nil {
+		replyID, _ := json.Marshal(callback.Data)
+		msg.WithMetadata(json.RawMessage(fmt.Sprintf(`{"callback_query":true,"message_id":%d,"%s":%s}`, callback.Message.MessageID, courier.MetadataInteractiveReplyID, replyID)))
+	} else {
+		msg.WithMetadata(courier.NewInteractiveReplyMetadata(callback.Data))
+	}
+	return msg, nil
+}
+
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		MaxTextLength:   4096,
+		MaxTextParts:    1,
+		AttachmentTypes: []string{"image/*", "video/*", "audio/*", "application/*"},
+		QuickReplies:    true,
+	}
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
+// answerCallbackQuery acknowledges the passed in callback query, which stops the button showing as loading
+func (h *handler) answerCallbackQuery(ctx context.Context, channel courier.Channel, callback *moCallbackQuery) {
+	authToken := channel.StringConfigForKey(courier.ConfigAuthToken, "")
+	form := url.Values{"callback_query_id": []string{callback.ID}}
+
+	answerURL := fmt.Sprintf("%s/bot%s/answerCallbackQuery", apiURL, authToken)
+	req, _ := http.NewRequest(http.MethodPost, answerURL, strings.NewReader(form.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	rr, err := utils.MakeHTTPRequest(req)
+	if err != nil {
+		log := courier.NewChannelLogFromRR("Callback Answered", channel, courier.NilMsgID, rr).WithError("Callback Answer Error", err)
+		h.Backend().WriteChannelLogs(ctx, []*courier.ChannelLog{log})
+	}
+}
+
+type pollResponse struct {
+	Ok     bool         `json:"ok"`
+	Result []*moPayload `json:"result"`
 }
 
 type mtResponse struct {
@@ -151,7 +325,7 @@ type mtResponse struct {
 	} `json:"result"`
 }
 
-func (h *handler) forwardMsg(channel courier.Channel, msg *moPayload) (bool, error) {
+func (h *handler) forwardMsg(channel courier.Channel, msg *moMessage) (bool, error) {
 	confAuth := channel.ConfigForKey(courier.ConfigAuthToken, "")
 	authToken, isStr := confAuth.(string)
 	if !isStr || authToken == "" {
@@ -171,8 +345,8 @@ func (h *handler) forwardMsg(channel courier.Channel, msg *moPayload) (bool, err
 
 	form := url.Values{
 		"chat_id":      []string{forward_chat_id},
-		"from_chat_id": []string{strconv.FormatInt(msg.Message.Chat.ChatID, 10)},
-		"message_id":   []string{strconv.FormatInt(msg.Message.MessageID, 10)},
+		"from_chat_id": []string{strconv.FormatInt(msg.Chat.ChatID, 10)},
+		"message_id":   []string{strconv.FormatInt(msg.MessageID, 10)},
 	}
 
 	sendURL := fmt.Sprintf("%s/bot%s/%s", apiURL, authToken, "forwardMessage")
@@ -193,7 +367,14 @@ func (h *handler) forwardMsg(channel courier.Channel, msg *moPayload) (bool, err
 	return true, nil
 }
 
-func (h *handler) sendMsgPart(msg courier.Msg, token string, path string, form url.Values, keyboard *ReplyKeyboardMarkup) (string, *courier.ChannelLog, bool, error) {
+func (h *handler) sendMsgPart(msg courier.Msg, token string, path string, form url.Values, keyboard interface{}, options courier.ProviderOptions) (string, *courier.ChannelLog, bool, error) {
+	if disable, found := options.Bool("disable_notification"); found {
+		form.Set("disable_notification", strconv.FormatBool(disable))
+	}
+	if parseMode, found := options.String("parse_mode"); found {
+		form.Set("parse_mode", parseMode)
+	}
+
 	// either include or remove our keyboard
 	if keyboard == nil {
 		form.Add("reply_markup", `{"remove_keyboard":true}`)
@@ -231,6 +412,25 @@ func (h *handler) sendMsgPart(msg courier.Msg, token string, path string, form u
 	return "", log, false, errors.Errorf("no 'result.message_id' in response")
 }
 
+// useInlineKeyboard returns whether quick replies on the passed in message should be sent as an inline keyboard,
+// either the channel or the message itself can opt in
+func useInlineKeyboard(msg courier.Msg) bool {
+	if msg.Channel().BoolConfigForKey(configInlineKeyboard, false) {
+		return true
+	}
+	inline, _ := jsonparser.GetBoolean(msg.Metadata(), configInlineKeyboard)
+	return inline
+}
+
+// the provider options we support
+var providerOptions = []courier.ProviderOption{
+	{Name: "disable_notification", Type: courier.ProviderOptionBool},
+	{Name: "parse_mode", Type: courier.ProviderOptionString, Values: []string{"Markdown", "MarkdownV2", "HTML"}},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption { return providerOptions }
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	confAuth := msg.Channel().ConfigForKey(courier.ConfigAuthToken, "")
@@ -239,10 +439,29 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		return nil, fmt.Errorf("invalid auth token config")
 	}
 
+	options, failed := h.GetProviderOptions(msg, providerOptions)
+	if failed != nil {
+		return failed, nil
+	}
+
+	// figure out whether we have a keyboard to send as well, interactive content is always sent as an inline keyboard
+	text := msg.Text()
+	var keyboard interface{}
+	if interactive := msg.Interactive(); interactive != nil {
+		text = interactive.WrapText(text)
+		keyboard = NewInlineKeyboardFromInteractive(interactive)
+	} else if qrs := msg.QuickReplies(); len(qrs) > 0 {
+		if useInlineKeyboard(msg) {
+			keyboard = NewInlineKeyboardFromReplies(qrs)
+		} else {
+			keyboard = NewKeyboardFromReplies(qrs)
+		}
+	}
+
 	// we only caption if there is only a single attachment
 	caption := ""
 	if len(msg.Attachments()) == 1 {
-		caption = msg.Text()
+		caption = text
 	}
 
 	// the status that will be written for this message
@@ -251,29 +470,23 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	// whether we encountered any errors sending any parts
 	hasError := true
 
-	// figure out whether we have a keyboard to send as well
-	qrs := msg.QuickReplies()
-	var keyboard *ReplyKeyboardMarkup
-	if len(qrs) > 0 {
-		keyboard = NewKeyboardFromReplies(qrs)
-	}
-
 	// if we have text, send that if we aren't sending it as a caption
-	if msg.Text() != "" && caption == "" {
-		var msgKeyBoard *ReplyKeyboardMarkup
+	if text != "" && caption == "" {
+		var msgKeyBoard interface{}
 		if len(msg.Attachments()) == 0 {
 			msgKeyBoard = keyboard
 		}
 
 		form := url.Values{
 			"chat_id": []string{msg.URN().Path()},
-			"text":    []string{msg.Text()},
+			"text":    []string{text},
 		}
 
-		externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendMessage", form, msgKeyBoard)
+		externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendMessage", form, msgKeyBoard, options)
 		status.AddLog(log)
 		if botBlocked {
 			status.SetStatus(courier.MsgFailed)
+			status.SetErrorCode(courier.SendErrorOptedOut)
 			channelEvent := h.Backend().NewChannelEvent(msg.Channel(), courier.StopContact, msg.URN())
 			err = h.Backend().WriteChannelEvent(ctx, channelEvent)
 			return status, err
@@ -285,7 +498,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	// send each attachment
 	for i, attachment := range msg.Attachments() {
-		var attachmentKeyBoard *ReplyKeyboardMarkup
+		var attachmentKeyBoard interface{}
 		if i == len(msg.Attachments())-1 {
 			attachmentKeyBoard = keyboard
 		}
@@ -298,10 +511,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 				"photo":   []string{mediaURL},
 				"caption": []string{caption},
 			}
-			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendPhoto", form, attachmentKeyBoard)
+			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendPhoto", form, attachmentKeyBoard, options)
 			status.AddLog(log)
 			if botBlocked {
 				status.SetStatus(courier.MsgFailed)
+				status.SetErrorCode(courier.SendErrorOptedOut)
 				channelEvent := h.Backend().NewChannelEvent(msg.Channel(), courier.StopContact, msg.URN())
 				err = h.Backend().WriteChannelEvent(ctx, channelEvent)
 				return status, err
@@ -315,10 +529,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 				"video":   []string{mediaURL},
 				"caption": []string{caption},
 			}
-			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendVideo", form, attachmentKeyBoard)
+			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendVideo", form, attachmentKeyBoard, options)
 			status.AddLog(log)
 			if botBlocked {
 				status.SetStatus(courier.MsgFailed)
+				status.SetErrorCode(courier.SendErrorOptedOut)
 				channelEvent := h.Backend().NewChannelEvent(msg.Channel(), courier.StopContact, msg.URN())
 				err = h.Backend().WriteChannelEvent(ctx, channelEvent)
 				return status, err
@@ -332,10 +547,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 				"audio":   []string{mediaURL},
 				"caption": []string{caption},
 			}
-			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendAudio", form, attachmentKeyBoard)
+			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendAudio", form, attachmentKeyBoard, options)
 			status.AddLog(log)
 			if botBlocked {
 				status.SetStatus(courier.MsgFailed)
+				status.SetErrorCode(courier.SendErrorOptedOut)
 				channelEvent := h.Backend().NewChannelEvent(msg.Channel(), courier.StopContact, msg.URN())
 				err = h.Backend().WriteChannelEvent(ctx, channelEvent)
 				return status, err
@@ -349,10 +565,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 				"document": []string{mediaURL},
 				"caption":  []string{caption},
 			}
-			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendDocument", form, attachmentKeyBoard)
+			externalID, log, botBlocked, err := h.sendMsgPart(msg, authToken, "sendDocument", form, attachmentKeyBoard, options)
 			status.AddLog(log)
 			if botBlocked {
 				status.SetStatus(courier.MsgFailed)
+				status.SetErrorCode(courier.SendErrorOptedOut)
 				channelEvent := h.Backend().NewChannelEvent(msg.Channel(), courier.StopContact, msg.URN())
 				err = h.Backend().WriteChannelEvent(ctx, channelEvent)
 				return status, err
@@ -453,39 +670,73 @@ type moLocation struct {
 // 	 }
 // }
 type moPayload struct {
-	UpdateID int64 `json:"update_id" validate:"required"`
-	Message  struct {
-		MessageID int64 `json:"message_id"`
-		From      struct {
-			ContactID int64  `json:"id"`
-			FirstName string `json:"first_name"`
-			LastName  string `json:"last_name"`
-			Username  string `json:"username"`
-		} `json:"from"`
-		Chat struct {
-			ChatID int64  `json:"id"`
-			Type   string `json:"type"`
-		} `json:"chat"`
-		Date    int64  `json:"date"`
-		Text    string `json:"text"`
-		Caption string `json:"caption"`
-		Sticker *struct {
-			Thumb moFile `json:"thumb"`
-		} `json:"sticker"`
-		Photo    []moFile    `json:"photo"`
-		Video    *moFile     `json:"video"`
-		Voice    *moFile     `json:"voice"`
-		Document *moFile     `json:"document"`
+	UpdateID      int64            `json:"update_id" validate:"required"`
+	Message       moMessage        `json:"message"`
+	EditedMessage moMessage        `json:"edited_message"`
+	CallbackQuery *moCallbackQuery `json:"callback_query"`
+}
+
+// hasContent returns whether this update contains anything we handle
+func (p *moPayload) hasContent() bool {
+	return p.Message.MessageID != 0 || p.EditedMessage.MessageID != 0 || p.CallbackQuery != nil
+}
+
+type moUser struct {
+	ContactID int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+}
+
+type moMessage struct {
+	MessageID int64  `json:"message_id"`
+	From      moUser `json:"from"`
+	Chat      struct {
+		ChatID int64  `json:"id"`
+		Type   string `json:"type"`
+	} `json:"chat"`
+	Date     int64  `json:"date"`
+	EditDate int64  `json:"edit_date"`
+	Text     string `json:"text"`
+	Caption  string `json:"caption"`
+	Sticker  *struct {
+		Thumb moFile `json:"thumb"`
+	} `json:"sticker"`
+	Photo    []moFile    `json:"photo"`
+	Video    *moFile     `json:"video"`
+	Voice    *moFile     `json:"voice"`
+	Document *moFile     `json:"document"`
+	Location *moLocation `json:"location"`
+	Venue    *struct {
 		Location *moLocation `json:"location"`
-		Venue    *struct {
-			Location *moLocation `json:"location"`
-			Title    string      `json:"title"`
-			Address  string      `json:"address"`
-		}
-		Contact *struct {
-			PhoneNumber string `json:"phone_number"`
-			FirstName   string `json:"first_name"`
-			LastName    string `json:"last_name"`
-		}
-	} `json:"message"`
+		Title    string      `json:"title"`
+		Address  string      `json:"address"`
+	}
+	Contact *struct {
+		PhoneNumber string `json:"phone_number"`
+		FirstName   string `json:"first_name"`
+		LastName    string `json:"last_name"`
+	}
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup"`
+}
+
+// {
+//   "id": "4382bfdwdsb323b2d9",
+//   "from": {
+//     "id": 3527065,
+//     "first_name": "Nic",
+//     "last_name": "Pottier",
+//     "username": "nicpottier"
+//   },
+//   "message": {
+//     "message_id": 42,
+//     ...
+//   },
+//   "data": "Yes"
+// }
+type moCallbackQuery struct {
+	ID      string     `json:"id"`
+	From    moUser     `json:"from"`
+	Message *moMessage `json:"message"`
+	Data    string     `json:"data"`
 }
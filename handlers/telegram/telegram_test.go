@@ -1,6 +1,8 @@
 package telegram
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/nyaruka/courier"
 	. "github.com/nyaruka/courier/handlers"
+	"github.com/stretchr/testify/assert"
 )
 
 var testChannels = []courier.Channel{
@@ -450,6 +453,51 @@ var contactMsg = `
     }
 }`
 
+var editedMsg = `{
+  "update_id": 174114375,
+  "edited_message": {
+	"message_id": 41,
+	"from": {
+		"id": 3527065,
+		"first_name": "Nic",
+		"last_name": "Pottier",
+		"username": "nicpottier"
+	},
+	"chat": {
+		"id": 3527065,
+		"first_name": "Nic",
+		"last_name": "Pottier",
+		"type": "private"
+	},
+	"date": 1454119029,
+	"edit_date": 1454119089,
+	"text": "Hello Again"
+  }
+}`
+
+var callbackQueryMsg = `{
+  "update_id": 174114376,
+  "callback_query": {
+	"id": "4382bfdwdsb323b2d9",
+	"from": {
+		"id": 3527065,
+		"first_name": "Nic",
+		"last_name": "Pottier",
+		"username": "nicpottier"
+	},
+	"message": {
+		"message_id": 133,
+		"chat": {
+			"id": 3527065,
+			"type": "private"
+		},
+		"date": 1454119029,
+		"text": "Are you happy?"
+	},
+	"data": "Yes"
+  }
+}`
+
 var testCases = []ChannelHandleTestCase{
 	{Label: "Receive Valid Message", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: helloMsg, Status: 200, Response: "Accepted",
 		Name: Sp("Nic Pottier"), Text: Sp("Hello World"), URN: Sp("telegram:3527065#nicpottier"), ExternalID: Sp("41"), Date: Tp(time.Date(2016, 1, 30, 1, 57, 9, 0, time.UTC))},
@@ -457,6 +505,12 @@ var testCases = []ChannelHandleTestCase{
 	{Label: "Receive Start Message", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: startMsg, Status: 200, Response: "Accepted",
 		Name: Sp("Nic Pottier"), ChannelEvent: Sp(string(courier.NewConversation)), URN: Sp("telegram:3527065#nicpottier"), Date: Tp(time.Date(2016, 1, 30, 1, 57, 9, 0, time.UTC))},
 
+	{Label: "Receive Edited Message", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: editedMsg, Status: 200, Response: "Accepted",
+		Name: Sp("Nic Pottier"), Text: Sp("Hello Again"), URN: Sp("telegram:3527065#nicpottier"), ExternalID: Sp("41"), Date: Tp(time.Date(2016, 1, 30, 1, 57, 9, 0, time.UTC))},
+
+	{Label: "Receive Callback Query", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: callbackQueryMsg, Status: 200, Response: "Accepted",
+		Name: Sp("Nic Pottier"), Text: Sp("Yes"), URN: Sp("telegram:3527065#nicpottier"), ExternalID: Sp("4382bfdwdsb323b2d9")},
+
 	{Label: "Receive No Params", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: emptyMsg, Status: 200, Response: "Ignoring"},
 
 	{Label: "Receive Invalid JSON", URL: "/c/tg/8eb23e93-5ecb-45ba-b726-3b064e0c568c/receive/", Data: "foo", Status: 400, Response: "unable to parse"},
@@ -574,6 +628,23 @@ var defaultSendTestCases = []ChannelSendTestCase{
 			"reply_markup": `{"remove_keyboard":true}`,
 		},
 		SendPrep: setSendURL},
+	{Label: "Provider Options",
+		Text: "*Simple* Message", URN: "telegram:12345",
+		Metadata: json.RawMessage(`{"provider_options": {"tg": {"disable_notification": true, "parse_mode": "MarkdownV2"}}}`),
+		Status:   "W", ExternalID: "133",
+		ResponseBody: `{ "ok": true, "result": { "message_id": 133 } }`, ResponseStatus: 200,
+		PostParams: map[string]string{
+			"text":                 "*Simple* Message",
+			"chat_id":              "12345",
+			"disable_notification": "true",
+			"parse_mode":           "MarkdownV2",
+		},
+		SendPrep: setSendURL},
+	{Label: "Invalid Provider Options",
+		Text: "Simple Message", URN: "telegram:12345",
+		Metadata: json.RawMessage(`{"provider_options": {"tg": {"parse_mode": "BBCode"}}}`),
+		Status:   "F",
+		SendPrep: setSendURL},
 	{Label: "Quick Reply",
 		Text: "Are you happy?", URN: "telegram:12345", QuickReplies: []string{"Yes", "No"},
 		Status: "W", ExternalID: "133",
@@ -611,6 +682,7 @@ var defaultSendTestCases = []ChannelSendTestCase{
 	{Label: "Stopped Contact Code",
 		Text: "Stopped Contact", URN: "telegram:12345",
 		Status:       "F",
+		ErrorCode:    courier.SendErrorOptedOut,
 		ResponseBody: `{ "ok": false, "error_code":403, "description":"Forbidden: bot was blocked by the user"}`, ResponseStatus: 403,
 		PostParams: map[string]string{"text": `Stopped Contact`, "chat_id": "12345"},
 		SendPrep:   setSendURL,
@@ -656,9 +728,85 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		SendPrep: setSendURL},
 }
 
+var inlineSendTestCases = []ChannelSendTestCase{
+	{Label: "Inline Keyboard",
+		Text: "Are you happy?", URN: "telegram:12345", QuickReplies: []string{"Yes", "No"},
+		Status: "W", ExternalID: "133",
+		ResponseBody: `{ "ok": true, "result": { "message_id": 133 } }`, ResponseStatus: 200,
+		PostParams: map[string]string{
+			"text":         "Are you happy?",
+			"chat_id":      "12345",
+			"reply_markup": `{"inline_keyboard":[[{"text":"Yes","callback_data":"Yes"},{"text":"No","callback_data":"No"}]]}`,
+		},
+		SendPrep: setSendURL},
+	{Label: "Interactive Keyboard",
+		Text: "Are you happy?", URN: "telegram:12345",
+		Metadata: json.RawMessage(`{"interactive":{"header":"Survey","buttons":[{"id":"y","title":"Yes"}]}}`),
+		Status:   "W", ExternalID: "133",
+		ResponseBody: `{ "ok": true, "result": { "message_id": 133 } }`, ResponseStatus: 200,
+		PostParams: map[string]string{
+			"text":         "Survey\n\nAre you happy?",
+			"chat_id":      "12345",
+			"reply_markup": `{"inline_keyboard":[[{"text":"Yes","callback_data":"y"}]]}`,
+		},
+		SendPrep: setSendURL},
+	{Label: "No Keyboard",
+		Text: "Simple Message", URN: "telegram:12345",
+		Status: "W", ExternalID: "133",
+		ResponseBody: `{ "ok": true, "result": { "message_id": 133 } }`, ResponseStatus: 200,
+		PostParams: map[string]string{
+			"text":         "Simple Message",
+			"chat_id":      "12345",
+			"reply_markup": `{"remove_keyboard":true}`,
+		},
+		SendPrep: setSendURL},
+}
+
 func TestSending(t *testing.T) {
 	var defaultChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2020", "US",
 		map[string]interface{}{courier.ConfigAuthToken: "auth_token"})
 
+	var inlineChannel = courier.NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2020", "US",
+		map[string]interface{}{courier.ConfigAuthToken: "auth_token", "inline_keyboard": true})
+
 	RunChannelSendTestCases(t, defaultChannel, newHandler(), defaultSendTestCases, nil)
+	RunChannelSendTestCases(t, inlineChannel, newHandler(), inlineSendTestCases, nil)
+}
+
+func TestPollChannel(t *testing.T) {
+	var offset string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset = r.FormValue("offset")
+		w.Write([]byte(`{"ok": true, "result": [` + startMsg + `,` + helloMsg + `, {"update_id": 174114371}]}`))
+	}))
+	defer server.Close()
+	apiURL = server.URL
+
+	mb := courier.NewMockBackend()
+	h := newHandler().(*handler)
+	h.Initialize(courier.NewServer(courier.NewConfig(), mb))
+
+	events, logs, cursor, err := h.PollChannel(context.Background(), testChannels[0], "174114370")
+	assert.NoError(t, err)
+	assert.Equal(t, "174114370", offset)
+	assert.Equal(t, "174114372", cursor)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, 2, len(events))
+
+	evt, err := mb.GetLastChannelEvent()
+	assert.NoError(t, err)
+	assert.Equal(t, courier.NewConversation, evt.EventType())
+
+	msg, err := mb.GetLastQueueMsg()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World", msg.Text())
+	assert.Equal(t, "telegram:3527065#nicpottier", msg.URN().String())
+
+	// a failed poll leaves our cursor unchanged
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false}`))
+	})
+	_, _, cursor, err = h.PollChannel(context.Background(), testChannels[0], "174114372")
+	assert.EqualError(t, err, "response not 'ok'")
+	assert.Equal(t, "174114372", cursor)
 }
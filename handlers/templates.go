@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/nyaruka/courier"
+)
+
+// WhatsAppLanguage returns the WhatsApp language code for the locale of the passed in template
+func WhatsAppLanguage(templating *courier.Templating) (string, error) {
+	language, found := whatsAppLanguages[templating.Locale()]
+	if !found {
+		return "", courier.NewTemplateError(courier.TemplateUnsupportedLanguage, "unable to find mapping for language: %s", templating.Locale())
+	}
+	return language, nil
+}
+
+// FailedStatusForTemplateError marks the passed in status as failed, adding a log describing the passed in template
+// error, messages with invalid templates can't be sent so there's no point in retrying them
+func FailedStatusForTemplateError(status courier.MsgStatus, msg courier.Msg, elapsed time.Duration, err error) courier.MsgStatus {
+	status.SetStatus(courier.MsgFailed)
+	status.SetErrorCode(courier.SendErrorTemplate)
+	status.AddLog(courier.NewChannelLogFromError("Template Error", msg.Channel(), msg.ID(), elapsed, err))
+	return status
+}
+
+// mapping from iso639-3_iso3166-2 to WhatsApp language code
+var whatsAppLanguages = map[string]string{
+	"afr":    "af",    // Afrikaans
+	"sqi":    "sq",    // Albanian
+	"ara":    "ar",    // Arabic
+	"aze":    "az",    // Azerbaijani
+	"ben":    "bn",    // Bengali
+	"bul":    "bg",    // Bulgarian
+	"cat":    "ca",    // Catalan
+	"zho":    "zh_CN", // Chinese
+	"zho_CN": "zh_CN", // Chinese (CHN)
+	"zho_HK": "zh_HK", // Chinese (HKG)
+	"zho_TW": "zh_TW", // Chinese (TAI)
+	"hrv":    "hr",    // Croatian
+	"ces":    "cs",    // Czech
+	"dah":    "da",    // Danish
+	"nld":    "nl",    // Dutch
+	"eng":    "en",    // English
+	"eng_GB": "en_GB", // English (UK)
+	"eng_US": "en_US", // English (US)
+	"est":    "et",    // Estonian
+	"fil":    "fil",   // Filipino
+	"fin":    "fi",    // Finnish
+	"fra":    "fr",    // French
+	"kat":    "ka",    // Georgian
+	"deu":    "de",    // German
+	"ell":    "el",    // Greek
+	"guj":    "gu",    // Gujarati
+	"hau":    "ha",    // Hausa
+	"enb":    "he",    // Hebrew
+	"hin":    "hi",    // Hindi
+	"hun":    "hu",    // Hungarian
+	"ind":    "id",    // Indonesian
+	"gle":    "ga",    // Irish
+	"ita":    "it",    // Italian
+	"jpn":    "ja",    // Japanese
+	"kan":    "kn",    // Kannada
+	"kaz":    "kk",    // Kazakh
+	"kin":    "rw_RW", // Kinyarwanda
+	"kor":    "ko",    // Korean
+	"kir":    "ky_KG", // Kyrgyzstan
+	"lao":    "lo",    // Lao
+	"lav":    "lv",    // Latvian
+	"lit":    "lt",    // Lithuanian
+	"mal":    "ml",    // Malayalam
+	"mkd":    "mk",    // Macedonian
+	"msa":    "ms",    // Malay
+	"mar":    "mr",    // Marathi
+	"nob":    "nb",    // Norwegian
+	"fas":    "fa",    // Persian
+	"pol":    "pl",    // Polish
+	"por":    "pt_PT", // Portuguese
+	"por_BR": "pt_BR", // Portuguese (BR)
+	"por_PT": "pt_PT", // Portuguese (POR)
+	"pan":    "pa",    // Punjabi
+	"ron":    "ro",    // Romanian
+	"rus":    "ru",    // Russian
+	"srp":    "sr",    // Serbian
+	"slk":    "sk",    // Slovak
+	"slv":    "sl",    // Slovenian
+	"spa":    "es",    // Spanish
+	"spa_AR": "es_AR", // Spanish (ARG)
+	"spa_ES": "es_ES", // Spanish (SPA)
+	"spa_MX": "es_MX", // Spanish (MEX)
+	"swa":    "sw",    // Swahili
+	"swe":    "sv",    // Swedish
+	"tam":    "ta",    // Tamil
+	"tel":    "te",    // Telugu
+	"tha":    "th",    // Thai
+	"tur":    "tr",    // Turkish
+	"ukr":    "uk",    // Ukrainian
+	"urd":    "ur",    // Urdu
+	"uzb":    "uz",    // Uzbek
+	"vie":    "vi",    // Vietnamese
+	"zul":    "zu",    // Zulu
+}
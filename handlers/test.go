@@ -45,6 +45,7 @@ type ChannelHandleTestCase struct {
 	Date        *time.Time
 
 	MsgStatus *string
+	MsgCost   *courier.MsgCost
 
 	ChannelEvent      *string
 	ChannelEventExtra map[string]interface{}
@@ -101,9 +102,12 @@ type ChannelSendTestCase struct {
 	RequestBody string
 	Headers     map[string]string
 
-	Error      string
-	Status     string
-	ExternalID string
+	Error           string
+	Status          string
+	ErrorCode       courier.SendErrorCode
+	Cost            *courier.MsgCost
+	ExternalID      string
+	PartExternalIDs []string
 
 	Stopped bool
 
@@ -119,6 +123,9 @@ func Sp(str interface{}) *string { asStr := fmt.Sprintf("%s", str); return &asSt
 // Tp is utility method to get the pointer to the passed in time
 func Tp(tm time.Time) *time.Time { return &tm }
 
+// Bp is utility method to get the pointer to the passed in bool
+func Bp(b bool) *bool { return &b }
+
 // utility method to make sure the passed in host is up, prevents races with our test server
 func ensureTestServerUp(host string) {
 	for i := 0; i < 20; i++ {
@@ -323,11 +330,26 @@ func RunChannelSendTestCases(t *testing.T, channel courier.Channel, handler cour
 				require.Equal(testCase.ExternalID, status.ExternalID())
 			}
 
+			if testCase.PartExternalIDs != nil {
+				require.NotNil(status, "status should not be nil")
+				require.Equal(testCase.PartExternalIDs, status.PartExternalIDs())
+			}
+
 			if testCase.Status != "" {
 				require.NotNil(status, "status should not be nil")
 				require.Equal(testCase.Status, string(status.Status()))
 			}
 
+			if testCase.ErrorCode != "" {
+				require.NotNil(status, "status should not be nil")
+				require.Equal(testCase.ErrorCode, status.ErrorCode())
+			}
+
+			if testCase.Cost != nil {
+				require.NotNil(status, "status should not be nil")
+				require.Equal(testCase.Cost, status.Cost())
+			}
+
 			if testCase.Stopped {
 				evt, err := mb.GetLastChannelEvent()
 				require.NoError(err)
@@ -427,6 +449,10 @@ func RunChannelTestCases(t *testing.T, channels []courier.Channel, handler couri
 					require.NotNil(status)
 					require.Equal(*testCase.MsgStatus, string(status.Status()))
 				}
+				if testCase.MsgCost != nil {
+					require.NotNil(status)
+					require.Equal(testCase.MsgCost, status.Cost())
+				}
 				if testCase.ID != 0 {
 					if status != nil {
 						require.Equal(testCase.ID, int64(status.ID()))
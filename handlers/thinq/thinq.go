@@ -64,7 +64,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(form.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, form.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -183,6 +183,7 @@ func (h *handler) SendMsg(_ context.Context, msg courier.Msg) (courier.MsgStatus
 		}
 		status.SetStatus(courier.MsgWired)
 		status.SetExternalID(externalID)
+		status.AddPartExternalID(externalID)
 	}
 
 	// now send our text if we have any
@@ -220,6 +221,7 @@ func (h *handler) SendMsg(_ context.Context, msg courier.Msg) (courier.MsgStatus
 
 			status.SetStatus(courier.MsgWired)
 			status.SetExternalID(externalID)
+			status.AddPartExternalID(externalID)
 		}
 	}
 
@@ -10,12 +10,14 @@ import (
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/sirupsen/logrus"
@@ -46,6 +48,24 @@ var (
 // error code twilio returns when a contact has sent "stop"
 const errorStopped = 21610
 
+// mapping from the error codes twilio returns on sends and status callbacks to our send error codes
+var sendErrorCodes = map[int64]courier.SendErrorCode{
+	20003:        courier.SendErrorAuth,             // authentication error
+	20429:        courier.SendErrorRateLimited,      // too many requests
+	21211:        courier.SendErrorInvalidRecipient, // invalid 'To' number
+	21612:        courier.SendErrorInvalidRecipient, // 'To' number not reachable
+	21614:        courier.SendErrorInvalidRecipient, // 'To' number not a mobile number
+	errorStopped: courier.SendErrorOptedOut,         // contact has sent stop
+	21617:        courier.SendErrorContent,          // body exceeds the 1600 character limit
+	21620:        courier.SendErrorMedia,            // invalid media URL
+	11200:        courier.SendErrorMedia,            // unable to fetch media
+	30004:        courier.SendErrorOptedOut,         // message blocked by the recipient
+	30005:        courier.SendErrorInvalidRecipient, // unknown destination handset
+	30006:        courier.SendErrorInvalidRecipient, // landline or unreachable carrier
+	30007:        courier.SendErrorContent,          // filtered by the carrier
+	63016:        courier.SendErrorWindowClosed,     // whatsapp message outside the session window
+}
+
 type handler struct {
 	handlers.BaseHandler
 	validateSignatures bool
@@ -88,6 +108,8 @@ type statusForm struct {
 	MessageStatus string `validate:"required"`
 	ErrorCode     string
 	To            string
+	Price         string
+	PriceUnit     string
 }
 
 var statusMapping = map[string]courier.MsgStatusValue{
@@ -180,7 +202,10 @@ func (h *handler) receiveStatus(ctx context.Context, channel courier.Channel, w
 		status = h.Backend().NewMsgStatusForExternalID(channel, form.MessageSID, msgStatus)
 	}
 
+	status.SetCost(courier.NewMsgCost(form.Price, form.PriceUnit))
+
 	errorCode, _ := strconv.ParseInt(form.ErrorCode, 10, 64)
+	status.SetErrorCode(sendErrorCodes[errorCode])
 	if errorCode == errorStopped {
 		urn, err := h.parseURN(channel, form.To, "")
 		if err != nil {
@@ -199,6 +224,29 @@ func (h *handler) receiveStatus(ctx context.Context, channel courier.Channel, w
 	return handlers.WriteMsgStatusAndResponse(ctx, h, channel, status, w, r)
 }
 
+// contentVariables returns the body params of the passed in template as the JSON object of numbered variables that
+// Twilio expects
+func contentVariables(templating *courier.Templating) string {
+	variables := make(map[string]string)
+	for i, param := range templating.TextParams("body") {
+		variables[strconv.Itoa(i+1)] = param
+	}
+	encoded, _ := json.Marshal(variables)
+	return string(encoded)
+}
+
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		MaxTextLength:   maxMsgLength,
+		AttachmentTypes: []string{"image/*", "video/*", "audio/*", "application/*"},
+		MaxAttachments:  1,
+
+		// whatsapp templates can be sent by their content SID on Twilio channels with the whatsapp scheme
+		Templates: h.ChannelType() == "T" || h.ChannelType() == "TWA",
+	}
+}
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	// build our callback URL
@@ -219,6 +267,23 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 	status := h.Backend().NewMsgStatusForID(channel, msg.ID(), courier.MsgErrored)
 	parts := handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
+
+	// whatsapp templates are sent in a single request by their content SID, which we expect as their external ID
+	var templating *courier.Templating
+	if channel.IsScheme(urns.WhatsAppScheme) {
+		var err error
+		templating, err = courier.GetTemplating(msg)
+		if err == nil && templating != nil && templating.Template.ExternalID == "" {
+			err = courier.NewTemplateError(courier.TemplateUnsupported, "template %s has no content SID", templating.Template.Name)
+		}
+		if err != nil {
+			return handlers.FailedStatusForTemplateError(status, msg, time.Duration(0), err), nil
+		}
+		if templating != nil {
+			parts = parts[:1]
+		}
+	}
+
 	for i, part := range parts {
 		// build our request
 		form := url.Values{
@@ -227,6 +292,12 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			"StatusCallback": []string{callbackURL},
 		}
 
+		if templating != nil {
+			delete(form, "Body")
+			form["ContentSid"] = []string{templating.Template.ExternalID}
+			form["ContentVariables"] = []string{contentVariables(templating)}
+		}
+
 		// add any media URL to the first part
 		if len(msg.Attachments()) > 0 && i == 0 {
 			_, mediaURL := handlers.SplitAttachment(msg.Attachments()[0])
@@ -276,6 +347,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		if err != nil && rr.Body != nil {
 			errorCode, _ := jsonparser.GetInt([]byte(rr.Body), "code")
 			if errorCode != 0 {
+				status.SetErrorCode(sendErrorCodes[errorCode])
 				if errorCode == errorStopped {
 					status.SetStatus(courier.MsgFailed)
 
@@ -305,10 +377,16 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 
 		status.SetStatus(courier.MsgWired)
 
+		// record what twilio charged, this is usually only known for some message types at send time
+		price, _ := jsonparser.GetString(rr.Body, "price")
+		priceUnit, _ := jsonparser.GetString(rr.Body, "price_unit")
+		handlers.AddMsgCost(status, courier.NewMsgCost(price, priceUnit))
+
 		// only save the first external id
 		if i == 0 {
 			status.SetExternalID(externalID)
 		}
+		status.AddPartExternalID(externalID)
 	}
 
 	return status, nil
@@ -329,7 +407,7 @@ func (h *handler) parseURN(channel courier.Channel, text, country string) (urns.
 		return urns.NewWhatsAppURN(strings.TrimLeft(fromTel, "+"))
 	}
 
-	return urns.NewTelURNForCountry(text, country)
+	return handlers.NormalizeTelWithPolicy(channel, text, country, handlers.TelPolicyAlphanumeric)
 }
 
 func (h *handler) baseURL(c courier.Channel) string {
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/nyaruka/courier"
+	"github.com/nyaruka/courier/sms"
 	"github.com/nyaruka/courier/utils"
 	"github.com/nyaruka/gocommon/urns"
 )
@@ -95,6 +96,17 @@ func SplitMsgByChannel(channel courier.Channel, text string, maxLength int) []st
 	return SplitMsg(text, max)
 }
 
+// SplitSMSByChannel splits the passed in text into parts that each fit in the passed in number of SMS segments,
+// unless the channel has a max length configured in which case that is used instead
+func SplitSMSByChannel(channel courier.Channel, text string, maxSegments int) []string {
+	max := channel.IntConfigForKey(courier.ConfigMaxLength, 0)
+	if max > 0 {
+		return SplitMsg(text, max)
+	}
+
+	return sms.Split(text, maxSegments)
+}
+
 // SplitMsg splits the passed in string into segments that are at most max length
 func SplitMsg(text string, max int) []string {
 	// smaller than our max, just return it
@@ -143,3 +155,15 @@ func StrictTelForCountry(number string, country string) (urns.URN, error) {
 
 	return urn, nil
 }
+
+// AddMsgCost adds the passed in cost of a single message part to the cost of the passed in status, handlers which send
+// messages in several parts use this to report what the provider charged for all of them
+func AddMsgCost(status courier.MsgStatus, cost *courier.MsgCost) {
+	if cost == nil {
+		return
+	}
+	if status.Cost() != nil {
+		cost.Amount += status.Cost().Amount
+	}
+	status.SetCost(cost)
+}
@@ -5,6 +5,8 @@ import (
 	"html"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/nyaruka/courier"
 )
 
 // KeyboardButton is button on a keyboard, see https://developers.viber.com/docs/tools/keyboards/#buttons-parameters
@@ -60,6 +62,57 @@ func NewKeyboardFromReplies(replies []string, buttonConfig map[string]interface{
 	return &Keyboard{"keyboard", false, buttons}
 }
 
+// NewKeyboardFromInteractive creates a keyboard from the given interactive content, buttons and list rows reply with
+// their IDs and a call to action opens its URL
+func NewKeyboardFromInteractive(interactive *courier.Interactive, buttonConfig map[string]interface{}) *Keyboard {
+	ids := make([]string, 0)
+	titles := make([]string, 0)
+	for _, button := range interactive.Buttons {
+		ids = append(ids, button.ID)
+		titles = append(titles, button.Title)
+	}
+	if len(interactive.Buttons) == 0 && interactive.List != nil {
+		for _, row := range interactive.List.Rows() {
+			ids = append(ids, row.ID)
+			titles = append(titles, row.Title)
+		}
+	}
+
+	rows := StringsToRows(titles, maxColumns, maxRowRunes, paddingRunes)
+	buttons := []KeyboardButton{}
+
+	n := 0
+	for i := range rows {
+		for j := range rows[i] {
+			button := KeyboardButton{
+				ActionType: "reply",
+				TextSize:   "regular",
+				ActionBody: ids[n],
+				Text:       html.EscapeString(rows[i][j]),
+				Columns:    fmt.Sprint(6 / len(rows[i])),
+			}
+
+			button.ApplyConfig(buttonConfig)
+			buttons = append(buttons, button)
+			n++
+		}
+	}
+
+	if interactive.CTA != nil {
+		button := KeyboardButton{
+			ActionType: "open-url",
+			TextSize:   "regular",
+			ActionBody: interactive.CTA.URL,
+			Text:       html.EscapeString(interactive.CTA.Title),
+			Columns:    "6",
+		}
+		button.ApplyConfig(buttonConfig)
+		buttons = append(buttons, button)
+	}
+
+	return &Keyboard{"keyboard", false, buttons}
+}
+
 //ApplyConfig apply the configs from the channel to KeyboardButton
 func (b *KeyboardButton) ApplyConfig(buttonConfig map[string]interface{}) {
 	bgColor := strings.TrimSpace(fmt.Sprint(buttonConfig["bg_color"]))
@@ -3,6 +3,7 @@ package viber_test
 import (
 	"testing"
 
+	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers/viber"
 	"github.com/stretchr/testify/assert"
 )
@@ -136,3 +137,20 @@ func TestKeyboardFromReplies(t *testing.T) {
 		assert.Equal(t, tc.expected, kb, "keyboard mismatch for replies %v", tc.replies)
 	}
 }
+
+func TestKeyboardFromInteractive(t *testing.T) {
+	keyboard := viber.NewKeyboardFromInteractive(&courier.Interactive{
+		Buttons: []courier.InteractiveButton{{ID: "y", Title: "Yes"}, {ID: "n", Title: "No"}},
+		CTA:     &courier.InteractiveCTA{Title: "More", URL: "https://foo.bar"},
+	}, map[string]interface{}{})
+
+	assert.Equal(t, &viber.Keyboard{
+		"keyboard",
+		false,
+		[]viber.KeyboardButton{
+			{ActionType: "reply", TextSize: "regular", ActionBody: "y", Text: "Yes", Columns: "3"},
+			{ActionType: "reply", TextSize: "regular", ActionBody: "n", Text: "No", Columns: "3"},
+			{ActionType: "open-url", TextSize: "regular", ActionBody: "https://foo.bar", Text: "More", Columns: "6"},
+		},
+	}, keyboard)
+}
@@ -227,11 +227,23 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 			return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, fmt.Errorf("missing text or media in message in request body"))
 		}
 
+		// replies from interactive keyboards have the ID of the button as their text
+		replyID := ""
+		if messageType == "text" {
+			if title := interactiveReplyTitle(payload.Message.TrackingData, text); title != "" {
+				replyID = text
+				text = title
+			}
+		}
+
 		// build our msg
 		msg := h.Backend().NewIncomingMsg(channel, urn, text).WithExternalID(fmt.Sprintf("%d", payload.MessageToken)).WithContactName(contactName)
 		if mediaURL != "" {
 			msg.WithAttachment(mediaURL)
 		}
+		if replyID != "" {
+			msg.WithMetadata(courier.NewInteractiveReplyMetadata(replyID))
+		}
 		// and finally write our message
 		return handlers.WriteMsgsAndResponse(ctx, h, []courier.Msg{msg}, w, r)
 	}
@@ -309,6 +321,52 @@ type mtPayload struct {
 	Keyboard     *Keyboard         `json:"keyboard,omitempty"`
 }
 
+// interactiveTracking is the tracking data we send with interactive messages, Viber includes it in replies to them
+// which lets us map the button ID a contact replied with back to its title
+type interactiveTracking struct {
+	MsgID   string            `json:"msg_id"`
+	Replies map[string]string `json:"replies"`
+}
+
+// newInteractiveTrackingData builds the tracking data for an interactive message
+func newInteractiveTrackingData(msgID courier.MsgID, interactive *courier.Interactive) string {
+	tracking := interactiveTracking{MsgID: msgID.String(), Replies: make(map[string]string)}
+	for _, button := range interactive.Buttons {
+		tracking.Replies[button.ID] = button.Title
+	}
+	if len(interactive.Buttons) == 0 && interactive.List != nil {
+		for _, row := range interactive.List.Rows() {
+			tracking.Replies[row.ID] = row.Title
+		}
+	}
+
+	trackingData, _ := json.Marshal(tracking)
+	return string(trackingData)
+}
+
+// interactiveReplyTitle returns the title of the button with the passed in ID from the passed in tracking data, or
+// the empty string if this isn't a reply to an interactive message
+func interactiveReplyTitle(trackingData string, replyID string) string {
+	tracking := &interactiveTracking{}
+	if err := json.Unmarshal([]byte(trackingData), tracking); err != nil {
+		return ""
+	}
+	return tracking.Replies[replyID]
+}
+
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		MaxTextLength:   maxMsgLength,
+		AttachmentTypes: []string{"image/*", "video/*", "audio/*"},
+		MaxAttachments:  1,
+		QuickReplies:    true,
+	}
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
 // SendMsg sends the passed in message, returning any error
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	authToken := msg.Channel().StringConfigForKey(courier.ConfigAuthToken, "")
@@ -319,22 +377,29 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
 
 	// figure out whether we have a keyboard to send as well
+	text := msg.Text()
 	qrs := msg.QuickReplies()
+	trackingData := msg.ID().String()
 	var keyboard *Keyboard
 
-	if len(qrs) > 0 {
+	if interactive := msg.Interactive(); interactive != nil {
+		buttonLayout := msg.Channel().ConfigForKey("button_layout", map[string]interface{}{}).(map[string]interface{})
+		keyboard = NewKeyboardFromInteractive(interactive, buttonLayout)
+		text = interactive.WrapText(text)
+		trackingData = newInteractiveTrackingData(msg.ID(), interactive)
+	} else if len(qrs) > 0 {
 		buttonLayout := msg.Channel().ConfigForKey("button_layout", map[string]interface{}{}).(map[string]interface{})
 		keyboard = NewKeyboardFromReplies(qrs, buttonLayout)
 	}
-	parts := handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
+	parts := handlers.SplitMsgByChannel(msg.Channel(), text, maxMsgLength)
 
 	descriptionPart := ""
-	if len(msg.Attachments()) == 1 && len(msg.Text()) < descriptionMaxLength {
+	if len(msg.Attachments()) == 1 && len(text) < descriptionMaxLength {
 		mediaType, _ := handlers.SplitAttachment(msg.Attachments()[0])
 		isImage := strings.Split(mediaType, "/")[0] == "image"
 
 		if isImage {
-			descriptionPart = msg.Text()
+			descriptionPart = text
 			parts = []string{}
 		}
 
@@ -400,7 +465,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			Receiver:     msg.URN().Path(),
 			Text:         msgText,
 			Type:         msgType,
-			TrackingData: msg.ID().String(),
+			TrackingData: trackingData,
 			Media:        attURL,
 			FileName:     filename,
 			Keyboard:     keyboard,
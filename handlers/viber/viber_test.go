@@ -3,6 +3,7 @@ package viber
 import (
 	"bytes"
 	"crypto/hmac"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -85,6 +86,17 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		},
 		RequestBody: `{"auth_token":"Token","receiver":"xy5/5y6O81+/kbWHpLhBoA==","text":"Are you happy?","type":"text","tracking_data":"10","keyboard":{"Type":"keyboard","DefaultHeight":false,"Buttons":[{"ActionType":"reply","ActionBody":"Yes","Text":"Yes","TextSize":"regular","Columns":"3"},{"ActionType":"reply","ActionBody":"No","Text":"No","TextSize":"regular","Columns":"3"}]}}`,
 		SendPrep:    setSendURL},
+	{Label: "Interactive Buttons",
+		Text: "Are you happy?", URN: "viber:xy5/5y6O81+/kbWHpLhBoA==",
+		Metadata: json.RawMessage(`{"interactive":{"footer":"Thanks","buttons":[{"id":"y","title":"Yes"},{"id":"n","title":"No"}]}}`),
+		Status:   "W", ResponseStatus: 200,
+		ResponseBody: `{"status":0,"status_message":"ok","message_token":4987381194038857789}`,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		},
+		RequestBody: `{"auth_token":"Token","receiver":"xy5/5y6O81+/kbWHpLhBoA==","text":"Are you happy?\n\nThanks","type":"text","tracking_data":"{\"msg_id\":\"10\",\"replies\":{\"n\":\"No\",\"y\":\"Yes\"}}","keyboard":{"Type":"keyboard","DefaultHeight":false,"Buttons":[{"ActionType":"reply","ActionBody":"y","Text":"Yes","TextSize":"regular","Columns":"3"},{"ActionType":"reply","ActionBody":"n","Text":"No","TextSize":"regular","Columns":"3"}]}}`,
+		SendPrep:    setSendURL},
 	{Label: "Send Attachment",
 		Text: "My pic!", URN: "viber:xy5/5y6O81+/kbWHpLhBoA==", Attachments: []string{"image/jpeg:https://localhost/image.jpg"},
 		Status: "W", ResponseStatus: 200,
@@ -231,6 +243,21 @@ var (
 		}
 	}`
 
+	interactiveReplyMsg = `{
+		"event": "message",
+		"timestamp": 1481142112807,
+		"message_token": 4987381189870374000,
+		"sender": {
+			"id": "xy5/5y6O81+/kbWHpLhBoA==",
+			"name": "ET3"
+		},
+		"message": {
+			"text": "y",
+			"type": "text",
+			"tracking_data": "{\"msg_id\":\"10\",\"replies\":{\"n\":\"No\",\"y\":\"Yes\"}}"
+		}
+	}`
+
 	invalidURNMsg = `{
 		"event": "message",
 		"timestamp": 1481142112807,
@@ -464,6 +491,9 @@ var testCases = []ChannelHandleTestCase{
 	{Label: "Receive Valid", URL: receiveURL, Data: validMsg, Status: 200, Response: "Accepted",
 		Text: Sp("incoming msg"), URN: Sp("viber:xy5/5y6O81+/kbWHpLhBoA=="), ExternalID: Sp("4987381189870374000"),
 		PrepRequest: addValidSignature},
+	{Label: "Receive Interactive Reply", URL: receiveURL, Data: interactiveReplyMsg, Status: 200, Response: "Accepted",
+		Text: Sp("Yes"), URN: Sp("viber:xy5/5y6O81+/kbWHpLhBoA=="), ExternalID: Sp("4987381189870374000"),
+		PrepRequest: addValidSignature},
 	{Label: "Receive invalid signature", URL: receiveURL, Data: validMsg, Status: 400, Response: "invalid request signature",
 		PrepRequest: addInvalidSignature},
 	{Label: "Receive invalid JSON", URL: receiveURL, Data: invalidJSON, Status: 400, Response: "unable to parse request JSON",
@@ -1,6 +1,7 @@
 package vk
 
 import (
+	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/utils"
 	"github.com/nyaruka/gocommon/jsonx"
 )
@@ -13,13 +14,14 @@ type Keyboard struct {
 
 type ButtonPayload struct {
 	Action ButtonAction `json:"action"`
-	Color  string       `json:"color"`
+	Color  string       `json:"color,omitempty"`
 }
 
 type ButtonAction struct {
 	Type    string `json:"type"`
 	Label   string `json:"label"`
 	Payload string `json:"payload"`
+	Link    string `json:"link,omitempty"`
 }
 
 // NewKeyboardFromReplies creates a keyboard from the given quick replies
@@ -39,3 +41,42 @@ func NewKeyboardFromReplies(replies []string) *Keyboard {
 
 	return &Keyboard{One_Time: true, Buttons: buttons, Inline: false}
 }
+
+// NewKeyboardFromInteractive creates a keyboard from the given interactive content, the payload of each button or list
+// row includes its ID and a call to action opens its URL
+func NewKeyboardFromInteractive(interactive *courier.Interactive) *Keyboard {
+	ids := make([]string, 0)
+	titles := make([]string, 0)
+	for _, button := range interactive.Buttons {
+		ids = append(ids, button.ID)
+		titles = append(titles, button.Title)
+	}
+	if len(interactive.Buttons) == 0 && interactive.List != nil {
+		for _, row := range interactive.List.Rows() {
+			ids = append(ids, row.ID)
+			titles = append(titles, row.Title)
+		}
+	}
+
+	rows := utils.StringsToRows(titles, 10, 30, 2)
+	buttons := make([][]ButtonPayload, len(rows))
+
+	n := 0
+	for i := range rows {
+		buttons[i] = make([]ButtonPayload, len(rows[i]))
+		for j := range rows[i] {
+			buttons[i][j].Action.Label = rows[i][j]
+			buttons[i][j].Action.Type = "text"
+			buttons[i][j].Action.Payload = string(jsonx.MustMarshal(map[string]string{"id": ids[n]}))
+			buttons[i][j].Color = "primary"
+			n++
+		}
+	}
+
+	if interactive.CTA != nil {
+		button := ButtonPayload{Action: ButtonAction{Type: "open_link", Label: interactive.CTA.Title, Link: interactive.CTA.URL}}
+		buttons = append(buttons, []ButtonPayload{button})
+	}
+
+	return &Keyboard{One_Time: true, Buttons: buttons, Inline: false}
+}
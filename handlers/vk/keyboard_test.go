@@ -3,6 +3,7 @@ package vk_test
 import (
 	"testing"
 
+	"github.com/nyaruka/courier"
 	"github.com/nyaruka/courier/handlers/vk"
 	"github.com/stretchr/testify/assert"
 )
@@ -96,3 +97,23 @@ func TestKeyboardFromReplies(t *testing.T) {
 		assert.Equal(t, tc.expected, kb, "keyboard mismatch for replies %v", tc.replies)
 	}
 }
+
+func TestKeyboardFromInteractive(t *testing.T) {
+	keyboard := vk.NewKeyboardFromInteractive(&courier.Interactive{
+		Buttons: []courier.InteractiveButton{{ID: "y", Title: "Yes"}, {ID: "n", Title: "No"}},
+		CTA:     &courier.InteractiveCTA{Title: "More", URL: "https://foo.bar"},
+	})
+	assert.Equal(t, &vk.Keyboard{
+		true,
+		[][]vk.ButtonPayload{
+			{
+				{vk.ButtonAction{Type: "text", Label: "Yes", Payload: `{"id":"y"}`}, "primary"},
+				{vk.ButtonAction{Type: "text", Label: "No", Payload: `{"id":"n"}`}, "primary"},
+			},
+			{
+				{Action: vk.ButtonAction{Type: "open_link", Label: "More", Link: "https://foo.bar"}},
+			},
+		},
+		false,
+	}, keyboard)
+}
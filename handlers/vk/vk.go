@@ -257,19 +257,27 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	if attachment := takeFirstAttachmentUrl(*payload); attachment != "" {
 		event.WithAttachment(attachment)
 	}
+	// replies from interactive keyboards include the ID of the button in their payload
+	if replyID, _ := jsonparser.GetString([]byte(payload.Object.Message.Payload), "id"); replyID != "" {
+		event.WithMetadata(courier.NewInteractiveReplyMetadata(replyID))
+	}
 	// check for empty content
 	if event.Text() == "" && len(event.Attachments()) == 0 {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, errors.New("no text or attachment"))
 	}
-	// save message to our backend
-	if err := h.Backend().WriteMsg(ctx, event); err != nil {
+	// save message to our backend, messages dropped over our flood limits are still acknowledged
+	events := []courier.Event{event}
+	if err := h.Backend().WriteMsg(ctx, event); err == courier.ErrMsgDropped {
+		events = nil
+	} else if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
+	} else {
+		h.Backend().WriteExternalIDSeen(event)
 	}
-	h.Backend().WriteExternalIDSeen(event)
 	// write required response
 	_, err = fmt.Fprint(w, responseIncomingMessage)
 
-	return []courier.Event{event}, err
+	return events, err
 }
 
 // DescribeURN handles VK contact details
@@ -385,6 +393,17 @@ func takeFirstAttachmentUrl(payload moNewMessagePayload) string {
 	return ""
 }
 
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		AttachmentTypes: []string{"image/*"},
+		QuickReplies:    true,
+	}
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
 func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStatus, error) {
 	status := h.Backend().NewMsgStatusForID(msg.Channel(), msg.ID(), courier.MsgErrored)
 
@@ -396,7 +415,11 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	params.Set(paramMessage, text)
 	params.Set(paramAttachments, attachments)
 
-	if len(msg.QuickReplies()) != 0 {
+	if interactive := msg.Interactive(); interactive != nil {
+		keyboard := NewKeyboardFromInteractive(interactive)
+
+		params.Set(paramKeyboard, string(jsonx.MustMarshal(keyboard)))
+	} else if len(msg.QuickReplies()) != 0 {
 		qrs := msg.QuickReplies()
 		keyboard := NewKeyboardFromReplies(qrs)
 
@@ -433,8 +456,13 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 func buildTextAndAttachmentParams(msg courier.Msg, status courier.MsgStatus) (string, string) {
 	var msgAttachments []string
 
+	text := msg.Text()
+	if interactive := msg.Interactive(); interactive != nil {
+		text = interactive.WrapText(text)
+	}
+
 	textBuf := bytes.Buffer{}
-	textBuf.WriteString(msg.Text())
+	textBuf.WriteString(text)
 
 	for _, attachment := range msg.Attachments() {
 		start := time.Now()
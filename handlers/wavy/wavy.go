@@ -122,7 +122,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	date := time.Unix(0, int64(payload.Timestamp*1000000)).UTC()
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(payload.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, payload.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
@@ -133,7 +133,7 @@ func (h *handler) fetchAccessToken(ctx context.Context, channel courier.Channel)
 	rc := h.Backend().RedisPool().Get()
 	defer rc.Close()
 
-	cacheKey := fmt.Sprintf("wechat_channel_access_token:%s", channel.UUID().String())
+	cacheKey := utils.RedisKey("wechat_channel_access_token:%s", channel.UUID().String())
 	_, err = rc.Do("SET", cacheKey, accessToken, expiration)
 
 	if err != nil {
@@ -146,7 +146,7 @@ func (h *handler) getAccessToken(channel courier.Channel) (string, error) {
 	rc := h.Backend().RedisPool().Get()
 	defer rc.Close()
 
-	cacheKey := fmt.Sprintf("wechat_channel_access_token:%s", channel.UUID().String())
+	cacheKey := utils.RedisKey("wechat_channel_access_token:%s", channel.UUID().String())
 	accessToken, err := redis.String(rc.Do("GET", cacheKey))
 	if err != nil {
 		return "", err
@@ -207,6 +207,7 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 
 		text := ""
 		mediaURL := ""
+		replyID := ""
 
 		if msg.Type == "text" {
 			text = msg.Text.Body
@@ -223,8 +224,10 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 		} else if msg.Type == "interactive" {
 			if msg.Interactive.Type == "button_reply" {
 				text = msg.Interactive.ButtonReply.Title
+				replyID = msg.Interactive.ButtonReply.ID
 			} else {
 				text = msg.Interactive.ListReply.Title
+				replyID = msg.Interactive.ListReply.ID
 			}
 		} else if msg.Type == "location" && msg.Location != nil {
 			mediaURL = fmt.Sprintf("geo:%f,%f", msg.Location.Latitude, msg.Location.Longitude)
@@ -250,7 +253,14 @@ func (h *handler) receiveEvent(ctx context.Context, channel courier.Channel, w h
 			event.WithAttachment(mediaURL)
 		}
 
+		if replyID != "" {
+			event.WithMetadata(courier.NewInteractiveReplyMetadata(replyID))
+		}
+
 		err = h.Backend().WriteMsg(ctx, event)
+		if err == courier.ErrMsgDropped {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -370,28 +380,39 @@ type mtInteractivePayload struct {
 	To          string `json:"to" validate:"required"`
 	Type        string `json:"type" validate:"required"`
 	Interactive struct {
-		Type   string `json:"type" validate:"required"` //"text" | "image" | "video" | "document"
-		Header *struct {
-			Type     string `json:"type"`
-			Text     string `json:"text,omitempty"`
-			Video    string `json:"video,omitempty"`
-			Image    string `json:"image,omitempty"`
-			Document string `json:"document,omitempty"`
-		} `json:"header,omitempty"`
-		Body struct {
+		Type   string    `json:"type" validate:"required"` //"button" | "list" | "cta_url"
+		Header *mtHeader `json:"header,omitempty"`
+		Body   struct {
 			Text string `json:"text"`
 		} `json:"body" validate:"required"`
-		Footer *struct {
-			Text string `json:"text"`
-		} `json:"footer,omitempty"`
+		Footer *mtFooter `json:"footer,omitempty"`
 		Action struct {
-			Button   string      `json:"button,omitempty"`
-			Sections []mtSection `json:"sections,omitempty"`
-			Buttons  []mtButton  `json:"buttons,omitempty"`
+			Button     string        `json:"button,omitempty"`
+			Sections   []mtSection   `json:"sections,omitempty"`
+			Buttons    []mtButton    `json:"buttons,omitempty"`
+			Name       string        `json:"name,omitempty"`
+			Parameters *mtCTAPayload `json:"parameters,omitempty"`
 		} `json:"action" validate:"required"`
 	} `json:"interactive"`
 }
 
+type mtHeader struct {
+	Type     string `json:"type"` //"text" | "image" | "video" | "document"
+	Text     string `json:"text,omitempty"`
+	Video    string `json:"video,omitempty"`
+	Image    string `json:"image,omitempty"`
+	Document string `json:"document,omitempty"`
+}
+
+type mtFooter struct {
+	Text string `json:"text"`
+}
+
+type mtCTAPayload struct {
+	DisplayText string `json:"display_text"`
+	URL         string `json:"url"`
+}
+
 type mtSection struct {
 	Title string         `json:"title,omitempty"`
 	Rows  []mtSectionRow `json:"rows" validate:"required"`
@@ -423,15 +444,48 @@ type LocalizableParam struct {
 }
 
 type Param struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string       `json:"type"`
+	Text     string       `json:"text,omitempty"`
+	Image    *mediaObject `json:"image,omitempty"`
+	Video    *mediaObject `json:"video,omitempty"`
+	Document *mediaObject `json:"document,omitempty"`
 }
 
 type Component struct {
 	Type       string  `json:"type"`
+	SubType    string  `json:"sub_type,omitempty"`
+	Index      string  `json:"index,omitempty"`
 	Parameters []Param `json:"parameters"`
 }
 
+// newTemplateComponents renders the components of the passed in template, media params being sent as links
+func newTemplateComponents(templating *courier.Templating) []Component {
+	components := make([]Component, 0, len(templating.Components))
+	for _, tc := range templating.Components {
+		component := Component{Type: tc.Type, SubType: tc.SubType}
+		if tc.Type == "button" {
+			component.Index = strconv.Itoa(tc.Index)
+		}
+
+		for _, tp := range tc.Params {
+			param := Param{Type: tp.Type}
+			switch tp.Type {
+			case "image":
+				param.Image = &mediaObject{Link: tp.Value}
+			case "video":
+				param.Video = &mediaObject{Link: tp.Value}
+			case "document":
+				param.Document = &mediaObject{Link: tp.Value}
+			default:
+				param.Text = tp.Value
+			}
+			component.Parameters = append(component.Parameters, param)
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
 type templatePayload struct {
 	To       string `json:"to"`
 	Type     string `json:"type"`
@@ -519,10 +573,18 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	var wppID string
 	var logs []*courier.ChannelLog
 
-	payloads, logs, err := buildPayloads(msg, h)
+	options, failed := h.GetProviderOptions(msg, providerOptions)
+	if failed != nil {
+		return failed, nil
+	}
+
+	payloads, logs, err := buildPayloads(msg, h, options)
 
 	fail := payloads == nil && err != nil
 	if fail {
+		if _, isTemplateErr := err.(*courier.TemplateError); isTemplateErr {
+			return handlers.FailedStatusForTemplateError(status, msg, time.Since(start), err), nil
+		}
 		return nil, err
 	}
 	for _, log := range logs {
@@ -537,6 +599,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 			status.AddLog(log)
 		}
 		if err != nil {
+			status.SetErrorCode(courier.SendErrorCodeForError(err))
 			break
 		}
 
@@ -544,6 +607,7 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 		if i == 0 {
 			status.SetExternalID(externalID)
 		}
+		status.AddPartExternalID(externalID)
 	}
 
 	// we are wired it there were no errors
@@ -565,18 +629,47 @@ func (h *handler) SendMsg(ctx context.Context, msg courier.Msg) (courier.MsgStat
 	return status, nil
 }
 
-func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.ChannelLog, error) {
+// the provider options we support, preview_url overrides whether we preview links based on the text containing one
+var providerOptions = []courier.ProviderOption{
+	{Name: "preview_url", Type: courier.ProviderOptionBool},
+}
+
+// ProviderOptions is called by courier to find out which provider options we support
+func (h *handler) ProviderOptions() []courier.ProviderOption { return providerOptions }
+
+// newTextPayload returns the payload to send the passed in text part with, previewing any link it contains
+func newTextPayload(msg courier.Msg, part string, options courier.ProviderOptions) mtTextPayload {
+	payload := mtTextPayload{
+		To:         msg.URN().Path(),
+		Type:       "text",
+		PreviewURL: strings.Contains(part, "https://") || strings.Contains(part, "http://"),
+	}
+	if preview, found := options.Bool("preview_url"); found {
+		payload.PreviewURL = preview
+	}
+	payload.Text.Body = part
+	return payload
+}
+
+func buildPayloads(msg courier.Msg, h *handler, options courier.ProviderOptions) ([]interface{}, []*courier.ChannelLog, error) {
 	start := time.Now()
 	var payloads []interface{}
 	var logs []*courier.ChannelLog
 	var err error
 
-	parts := handlers.SplitMsgByChannel(msg.Channel(), msg.Text(), maxMsgLength)
-
+	text := msg.Text()
 	qrs := msg.QuickReplies()
+	interactive := msg.Interactive()
 	wppVersion := msg.Channel().ConfigForKey("version", "0").(string)
 	isInteractiveMsgCompatible := semver.Compare(wppVersion, interactiveMsgMinSupVersion)
-	isInteractiveMsg := (isInteractiveMsgCompatible >= 0) && (len(qrs) > 0)
+	isInteractiveMsg := (isInteractiveMsgCompatible >= 0) && (len(qrs) > 0 || interactive != nil)
+
+	// older versions can't send interactive messages so send any interactive content as text
+	if interactive != nil && isInteractiveMsgCompatible < 0 {
+		text = interactive.TextFallback(text)
+	}
+
+	parts := handlers.SplitMsgByChannel(msg.Channel(), text, maxMsgLength)
 
 	textAsCaption := false
 
@@ -609,7 +702,7 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 					Type: "document",
 				}
 				if attachmentCount == 0 && !isInteractiveMsg {
-					mediaPayload.Caption = msg.Text()
+					mediaPayload.Caption = text
 					textAsCaption = true
 				}
 				mediaPayload.Filename, err = utils.BasePathForURL(fileURL)
@@ -626,7 +719,7 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 					Type: "image",
 				}
 				if attachmentCount == 0 && !isInteractiveMsg {
-					mediaPayload.Caption = msg.Text()
+					mediaPayload.Caption = text
 					textAsCaption = true
 				}
 				payload.Image = mediaPayload
@@ -637,7 +730,7 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 					Type: "video",
 				}
 				if attachmentCount == 0 && !isInteractiveMsg {
-					mediaPayload.Caption = msg.Text()
+					mediaPayload.Caption = text
 					textAsCaption = true
 				}
 				payload.Video = mediaPayload
@@ -653,23 +746,7 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 
 		if !textAsCaption && !isInteractiveMsg {
 			for _, part := range parts {
-
-				//check if you have a link
-				var payload mtTextPayload
-				if strings.Contains(part, "https://") || strings.Contains(part, "http://") {
-					payload = mtTextPayload{
-						To:         msg.URN().Path(),
-						Type:       "text",
-						PreviewURL: true,
-					}
-				} else {
-					payload = mtTextPayload{
-						To:   msg.URN().Path(),
-						Type: "text",
-					}
-				}
-				payload.Text.Body = part
-				payloads = append(payloads, payload)
+				payloads = append(payloads, newTextPayload(msg, part, options))
 			}
 		}
 
@@ -684,61 +761,29 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 					payloads = append(payloads, payload)
 
 				} else {
-					payload := mtInteractivePayload{
-						To:   msg.URN().Path(),
-						Type: "interactive",
-					}
-
-					// up to 3 qrs the interactive message will be button type, otherwise it will be list
-					if len(qrs) <= 3 {
-						payload.Interactive.Type = "button"
-						payload.Interactive.Body.Text = part
-						btns := make([]mtButton, len(qrs))
-						for i, qr := range qrs {
-							btns[i] = mtButton{
-								Type: "reply",
-							}
-							btns[i].Reply.ID = fmt.Sprint(i)
-							btns[i].Reply.Title = qr
-						}
-						payload.Interactive.Action.Buttons = btns
-						payloads = append(payloads, payload)
-					} else {
-						payload.Interactive.Type = "list"
-						payload.Interactive.Body.Text = part
-						payload.Interactive.Action.Button = "Menu"
-						section := mtSection{
-							Rows: make([]mtSectionRow, len(qrs)),
-						}
-						for i, qr := range qrs {
-							section.Rows[i] = mtSectionRow{
-								ID:    fmt.Sprint(i),
-								Title: qr,
-							}
-						}
-						payload.Interactive.Action.Sections = []mtSection{
-							section,
-						}
-						payloads = append(payloads, payload)
-					}
+					payloads = append(payloads, buildInteractivePayload(msg, part, qrs))
 				}
 			}
 		}
 
 	} else {
 		// do we have a template?
-		var templating *MsgTemplating
-		templating, err := h.getTemplate(msg)
+		templating, err := courier.GetTemplating(msg)
 		if err != nil {
-			return nil, nil, errors.Wrapf(err, "unable to decode template: %s for channel: %s", string(msg.Metadata()), msg.Channel().UUID())
+			return nil, nil, err
 		}
 		if templating != nil {
+			language, err := handlers.WhatsAppLanguage(templating)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			namespace := templating.Namespace
 			if namespace == "" {
 				namespace = msg.Channel().StringConfigForKey(configNamespace, "")
 			}
 			if namespace == "" {
-				return nil, nil, errors.Errorf("cannot send template message without Facebook namespace for channel: %s", msg.Channel().UUID())
+				return nil, nil, courier.NewTemplateError(courier.TemplateMissingNamespace, "cannot send template message without Facebook namespace for channel: %s", msg.Channel().UUID())
 			}
 
 			if msg.Channel().BoolConfigForKey(configHSMSupport, false) {
@@ -749,13 +794,12 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 				payload.HSM.Namespace = namespace
 				payload.HSM.ElementName = templating.Template.Name
 				payload.HSM.Language.Policy = "deterministic"
-				payload.HSM.Language.Code = templating.Language
-				for _, v := range templating.Variables {
+				payload.HSM.Language.Code = language
+				for _, v := range templating.TextParams("body") {
 					payload.HSM.LocalizableParams = append(payload.HSM.LocalizableParams, LocalizableParam{Default: v})
 				}
 				payloads = append(payloads, payload)
 			} else {
-
 				payload := templatePayload{
 					To:   msg.URN().Path(),
 					Type: "template",
@@ -763,14 +807,8 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 				payload.Template.Namespace = namespace
 				payload.Template.Name = templating.Template.Name
 				payload.Template.Language.Policy = "deterministic"
-				payload.Template.Language.Code = templating.Language
-
-				component := &Component{Type: "body"}
-
-				for _, v := range templating.Variables {
-					component.Parameters = append(component.Parameters, Param{Type: "text", Text: v})
-				}
-				payload.Template.Components = append(payload.Template.Components, *component)
+				payload.Template.Language.Code = language
+				payload.Template.Components = newTemplateComponents(templating)
 
 				payloads = append(payloads, payload)
 			}
@@ -787,64 +825,12 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 						payloads = append(payloads, payload)
 
 					} else {
-						payload := mtInteractivePayload{
-							To:   msg.URN().Path(),
-							Type: "interactive",
-						}
-
-						// up to 3 qrs the interactive message will be button type, otherwise it will be list
-						if len(qrs) <= 3 {
-							payload.Interactive.Type = "button"
-							payload.Interactive.Body.Text = part
-							btns := make([]mtButton, len(qrs))
-							for i, qr := range qrs {
-								btns[i] = mtButton{
-									Type: "reply",
-								}
-								btns[i].Reply.ID = fmt.Sprint(i)
-								btns[i].Reply.Title = qr
-							}
-							payload.Interactive.Action.Buttons = btns
-							payloads = append(payloads, payload)
-						} else {
-							payload.Interactive.Type = "list"
-							payload.Interactive.Body.Text = part
-							payload.Interactive.Action.Button = "Menu"
-							section := mtSection{
-								Rows: make([]mtSectionRow, len(qrs)),
-							}
-							for i, qr := range qrs {
-								section.Rows[i] = mtSectionRow{
-									ID:    fmt.Sprint(i),
-									Title: qr,
-								}
-							}
-							payload.Interactive.Action.Sections = []mtSection{
-								section,
-							}
-							payloads = append(payloads, payload)
-						}
+						payloads = append(payloads, buildInteractivePayload(msg, part, qrs))
 					}
 				}
 			} else {
 				for _, part := range parts {
-
-					//check if you have a link
-					var payload mtTextPayload
-					if strings.Contains(part, "https://") || strings.Contains(part, "http://") {
-						payload = mtTextPayload{
-							To:         msg.URN().Path(),
-							Type:       "text",
-							PreviewURL: true,
-						}
-					} else {
-						payload = mtTextPayload{
-							To:   msg.URN().Path(),
-							Type: "text",
-						}
-					}
-					payload.Text.Body = part
-					payloads = append(payloads, payload)
+					payloads = append(payloads, newTextPayload(msg, part, options))
 				}
 			}
 		}
@@ -852,6 +838,106 @@ func buildPayloads(msg courier.Msg, h *handler) ([]interface{}, []*courier.Chann
 	return payloads, logs, err
 }
 
+// Capabilities is called by courier to find out what messages we can send
+func (h *handler) Capabilities() *courier.Capabilities {
+	return &courier.Capabilities{
+		MaxTextLength:   maxMsgLength,
+		AttachmentTypes: []string{"image/*", "video/*", "audio/*", "application/*"},
+		QuickReplies:    true,
+		MaxQuickReplies: 10,
+		Templates:       true,
+	}
+}
+
+// RendersInteractive is called by courier to check whether we can send interactive content natively
+func (h *handler) RendersInteractive() bool { return true }
+
+// buildInteractivePayload builds an interactive message with the passed in body, rendering the message's structured
+// interactive content if it has any, otherwise its quick replies as buttons or a list
+func buildInteractivePayload(msg courier.Msg, body string, qrs []string) mtInteractivePayload {
+	payload := mtInteractivePayload{
+		To:   msg.URN().Path(),
+		Type: "interactive",
+	}
+	payload.Interactive.Body.Text = body
+
+	interactive := msg.Interactive()
+	if interactive == nil {
+		// up to 3 qrs the interactive message will be button type, otherwise it will be list
+		if len(qrs) <= 3 {
+			payload.Interactive.Type = "button"
+			btns := make([]mtButton, len(qrs))
+			for i, qr := range qrs {
+				btns[i] = mtButton{
+					Type: "reply",
+				}
+				btns[i].Reply.ID = fmt.Sprint(i)
+				btns[i].Reply.Title = qr
+			}
+			payload.Interactive.Action.Buttons = btns
+		} else {
+			payload.Interactive.Type = "list"
+			payload.Interactive.Action.Button = "Menu"
+			section := mtSection{
+				Rows: make([]mtSectionRow, len(qrs)),
+			}
+			for i, qr := range qrs {
+				section.Rows[i] = mtSectionRow{
+					ID:    fmt.Sprint(i),
+					Title: qr,
+				}
+			}
+			payload.Interactive.Action.Sections = []mtSection{
+				section,
+			}
+		}
+		return payload
+	}
+
+	if interactive.Header != "" {
+		payload.Interactive.Header = &mtHeader{Type: "text", Text: interactive.Header}
+	}
+	if interactive.Footer != "" {
+		payload.Interactive.Footer = &mtFooter{Text: interactive.Footer}
+	}
+
+	if len(interactive.Buttons) > 0 {
+		payload.Interactive.Type = "button"
+		btns := make([]mtButton, len(interactive.Buttons))
+		for i, button := range interactive.Buttons {
+			btns[i] = mtButton{
+				Type: "reply",
+			}
+			btns[i].Reply.ID = button.ID
+			btns[i].Reply.Title = button.Title
+		}
+		payload.Interactive.Action.Buttons = btns
+	} else if interactive.List != nil {
+		payload.Interactive.Type = "list"
+		payload.Interactive.Action.Button = interactive.List.Button
+		sections := make([]mtSection, len(interactive.List.Sections))
+		for i, s := range interactive.List.Sections {
+			sections[i] = mtSection{
+				Title: s.Title,
+				Rows:  make([]mtSectionRow, len(s.Rows)),
+			}
+			for j, row := range s.Rows {
+				sections[i].Rows[j] = mtSectionRow{
+					ID:          row.ID,
+					Title:       row.Title,
+					Description: row.Description,
+				}
+			}
+		}
+		payload.Interactive.Action.Sections = sections
+	} else {
+		payload.Interactive.Type = "cta_url"
+		payload.Interactive.Action.Name = "cta_url"
+		payload.Interactive.Action.Parameters = &mtCTAPayload{DisplayText: interactive.CTA.Title, URL: interactive.CTA.URL}
+	}
+	return payload
+}
+
 // fetchMediaID tries to fetch the id for the uploaded media, setting the result in redis.
 func (h *handler) fetchMediaID(msg courier.Msg, mimeType, mediaURL string) (string, []*courier.ChannelLog, error) {
 	var logs []*courier.ChannelLog
@@ -860,7 +946,7 @@ func (h *handler) fetchMediaID(msg courier.Msg, mimeType, mediaURL string) (stri
 	rc := h.Backend().RedisPool().Get()
 	defer rc.Close()
 
-	cacheKey := fmt.Sprintf(mediaCacheKeyPattern, msg.Channel().UUID().String())
+	cacheKey := utils.RedisKey(mediaCacheKeyPattern, msg.Channel().UUID().String())
 	mediaCache := redisx.NewIntervalHash(cacheKey, time.Hour*24, 2)
 	mediaID, err := mediaCache.Get(rc, mediaURL)
 	if err != nil {
@@ -942,7 +1028,7 @@ func sendWhatsAppMsg(rc redis.Conn, msg courier.Msg, sendPath *url.URL, payload
 	rr, err := utils.MakeHTTPRequest(req)
 
 	if rr.StatusCode == 429 || rr.StatusCode == 503 {
-		rateLimitKey := fmt.Sprintf("rate_limit:%s", msg.Channel().UUID().String())
+		rateLimitKey := utils.RedisKey("rate_limit:%s", msg.Channel().UUID().String())
 		rc.Do("SET", rateLimitKey, "engaged")
 
 		// The rate limit is 50 requests per second
@@ -961,19 +1047,19 @@ func sendWhatsAppMsg(rc redis.Conn, msg courier.Msg, sendPath *url.URL, payload
 	// handle send msg errors
 	if err == nil && len(errPayload.Errors) > 0 {
 		if hasTiersError(*errPayload) {
-			rateLimitBulkKey := fmt.Sprintf("rate_limit_bulk:%s", msg.Channel().UUID().String())
+			rateLimitBulkKey := utils.RedisKey("rate_limit_bulk:%s", msg.Channel().UUID().String())
 			rc.Do("SET", rateLimitBulkKey, "engaged")
 
 			// The WA tiers spam rate limit hit
 			// We pause the bulk queue for 24 hours and 5min
 			rc.Do("EXPIRE", rateLimitBulkKey, (60*60*24)+(5*60))
 
-			err := errors.Errorf("received error from send endpoint: %s", errPayload.Errors[0].Title)
+			err := courier.NewSendError(sendErrorCode(*errPayload), "received error from send endpoint: %s", errPayload.Errors[0].Title)
 			return "", "", []*courier.ChannelLog{log}, err
 		}
 
 		if !hasWhatsAppContactError(*errPayload) {
-			err := errors.Errorf("received error from send endpoint: %s", errPayload.Errors[0].Title)
+			err := courier.NewSendError(sendErrorCode(*errPayload), "received error from send endpoint: %s", errPayload.Errors[0].Title)
 			return "", "", []*courier.ChannelLog{log}, err
 		}
 		// check contact
@@ -1092,6 +1178,28 @@ func hasTiersError(payload mtErrorPayload) bool {
 	return false
 }
 
+// mapping from the error codes whatsapp returns on sends to our send error codes
+var sendErrorCodes = map[int]courier.SendErrorCode{
+	470:  courier.SendErrorWindowClosed,     // more than 24 hours since the contact last replied
+	471:  courier.SendErrorRateLimited,      // spam rate limit for the current tier
+	1008: courier.SendErrorContent,          // required parameter missing
+	1009: courier.SendErrorContent,          // parameter value invalid
+	1013: courier.SendErrorInvalidRecipient, // user is not valid
+	1026: courier.SendErrorInvalidRecipient, // receiver incapable of receiving this message
+	2000: courier.SendErrorTemplate,         // number of template params doesn't match
+	2001: courier.SendErrorTemplate,         // template missing
+}
+
+// sendErrorCode returns the send error code for the first error in the passed in payload that we know of
+func sendErrorCode(payload mtErrorPayload) courier.SendErrorCode {
+	for _, err := range payload.Errors {
+		if code, found := sendErrorCodes[err.Code]; found {
+			return code
+		}
+	}
+	return courier.NilSendErrorCode
+}
+
 func hasWhatsAppContactError(payload mtErrorPayload) bool {
 	for _, err := range payload.Errors {
 		if err.Code == 1006 && err.Title == "Resource not found" && (err.Details == "unknown contact" || err.Details == "Could not retrieve phone number from contact store") {
@@ -1145,130 +1253,3 @@ func checkWhatsAppContact(channel courier.Channel, baseURL string, urn urns.URN)
 		return rr, err
 	}
 }
-
-func (h *handler) getTemplate(msg courier.Msg) (*MsgTemplating, error) {
-	mdJSON := msg.Metadata()
-	if len(mdJSON) == 0 {
-		return nil, nil
-	}
-	metadata := &TemplateMetadata{}
-	err := json.Unmarshal(mdJSON, metadata)
-	if err != nil {
-		return nil, err
-	}
-	templating := metadata.Templating
-	if templating == nil {
-		return nil, nil
-	}
-
-	// check our template is valid
-	err = handlers.Validate(templating)
-	if err != nil {
-		return nil, errors.Wrapf(err, "invalid templating definition")
-	}
-	// check country
-	if templating.Country != "" {
-		templating.Language = fmt.Sprintf("%s_%s", templating.Language, templating.Country)
-	}
-
-	// map our language from iso639-3_iso3166-2 to the WA country / iso638-2 pair
-	language, found := languageMap[templating.Language]
-	if !found {
-		return nil, fmt.Errorf("unable to find mapping for language: %s", templating.Language)
-	}
-	templating.Language = language
-
-	return templating, err
-}
-
-type TemplateMetadata struct {
-	Templating *MsgTemplating `json:"templating"`
-}
-
-type MsgTemplating struct {
-	Template struct {
-		Name string `json:"name" validate:"required"`
-		UUID string `json:"uuid" validate:"required"`
-	} `json:"template" validate:"required,dive"`
-	Language  string   `json:"language" validate:"required"`
-	Country   string   `json:"country"`
-	Namespace string   `json:"namespace"`
-	Variables []string `json:"variables"`
-}
-
-// mapping from iso639-3_iso3166-2 to WA language code
-var languageMap = map[string]string{
-	"afr":    "af",    // Afrikaans
-	"sqi":    "sq",    // Albanian
-	"ara":    "ar",    // Arabic
-	"aze":    "az",    // Azerbaijani
-	"ben":    "bn",    // Bengali
-	"bul":    "bg",    // Bulgarian
-	"cat":    "ca",    // Catalan
-	"zho":    "zh_CN", // Chinese
-	"zho_CN": "zh_CN", // Chinese (CHN)
-	"zho_HK": "zh_HK", // Chinese (HKG)
-	"zho_TW": "zh_TW", // Chinese (TAI)
-	"hrv":    "hr",    // Croatian
-	"ces":    "cs",    // Czech
-	"dah":    "da",    // Danish
-	"nld":    "nl",    // Dutch
-	"eng":    "en",    // English
-	"eng_GB": "en_GB", // English (UK)
-	"eng_US": "en_US", // English (US)
-	"est":    "et",    // Estonian
-	"fil":    "fil",   // Filipino
-	"fin":    "fi",    // Finnish
-	"fra":    "fr",    // French
-	"kat":    "ka",    // Georgian
-	"deu":    "de",    // German
-	"ell":    "el",    // Greek
-	"guj":    "gu",    // Gujarati
-	"hau":    "ha",    // Hausa
-	"enb":    "he",    // Hebrew
-	"hin":    "hi",    // Hindi
-	"hun":    "hu",    // Hungarian
-	"ind":    "id",    // Indonesian
-	"gle":    "ga",    // Irish
-	"ita":    "it",    // Italian
-	"jpn":    "ja",    // Japanese
-	"kan":    "kn",    // Kannada
-	"kaz":    "kk",    // Kazakh
-	"kin":    "rw_RW", // Kinyarwanda
-	"kor":    "ko",    // Korean
-	"kir":    "ky_KG", // Kyrgyzstan
-	"lao":    "lo",    // Lao
-	"lav":    "lv",    // Latvian
-	"lit":    "lt",    // Lithuanian
-	"mal":    "ml",    // Malayalam
-	"mkd":    "mk",    // Macedonian
-	"msa":    "ms",    // Malay
-	"mar":    "mr",    // Marathi
-	"nob":    "nb",    // Norwegian
-	"fas":    "fa",    // Persian
-	"pol":    "pl",    // Polish
-	"por":    "pt_PT", // Portuguese
-	"por_BR": "pt_BR", // Portuguese (BR)
-	"por_PT": "pt_PT", // Portuguese (POR)
-	"pan":    "pa",    // Punjabi
-	"ron":    "ro",    // Romanian
-	"rus":    "ru",    // Russian
-	"srp":    "sr",    // Serbian
-	"slk":    "sk",    // Slovak
-	"slv":    "sl",    // Slovenian
-	"spa":    "es",    // Spanish
-	"spa_AR": "es_AR", // Spanish (ARG)
-	"spa_ES": "es_ES", // Spanish (SPA)
-	"spa_MX": "es_MX", // Spanish (MEX)
-	"swa":    "sw",    // Swahili
-	"swe":    "sv",    // Swedish
-	"tam":    "ta",    // Tamil
-	"tel":    "te",    // Telugu
-	"tha":    "th",    // Thai
-	"tur":    "tr",    // Turkish
-	"ukr":    "uk",    // Ukrainian
-	"urd":    "ur",    // Urdu
-	"uzb":    "uz",    // Uzbek
-	"vie":    "vi",    // Vietnamese
-	"zul":    "zu",    // Zulu
-}
@@ -376,6 +376,13 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
 		RequestBody: `{"to":"250788123123","type":"text","preview_url":true,"text":{"body":"Link Sending https://link.com"}}`,
 		SendPrep:    setSendURL},
+	{Label: "Link Sending Without Preview",
+		Text: "Link Sending https://link.com", URN: "whatsapp:250788123123", Path: "/v1/messages",
+		Metadata: json.RawMessage(`{"provider_options": {"wa": {"preview_url": false}}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"to":"250788123123","type":"text","text":{"body":"Link Sending https://link.com"}}`,
+		SendPrep:    setSendURL},
 	{Label: "Plain Send",
 		Text: "Simple Message", URN: "whatsapp:250788123123", Path: "/v1/messages",
 		Status: "W", ExternalID: "157b5e14568e8",
@@ -412,6 +419,20 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		ResponseBody: `{ "errors": [{"title":"Error Sending"}] }`, ResponseStatus: 200,
 		RequestBody: `{"to":"250788123123","type":"text","text":{"body":"Error"}}`,
 		SendPrep:    setSendURL},
+	{Label: "Invalid User Error",
+		Text: "Error", URN: "whatsapp:250788123123",
+		Status:       "E",
+		ErrorCode:    courier.SendErrorInvalidRecipient,
+		ResponseBody: `{ "errors": [{"code":1013,"title":"User is not valid"}] }`, ResponseStatus: 400,
+		RequestBody: `{"to":"250788123123","type":"text","text":{"body":"Error"}}`,
+		SendPrep:    setSendURL},
+	{Label: "Window Closed Error",
+		Text: "Error", URN: "whatsapp:250788123123",
+		Status:       "E",
+		ErrorCode:    courier.SendErrorWindowClosed,
+		ResponseBody: `{ "errors": [{"code":470,"title":"Message failed to send because more than 24 hours have passed since the customer last replied to this number"}] }`, ResponseStatus: 400,
+		RequestBody: `{"to":"250788123123","type":"text","text":{"body":"Error"}}`,
+		SendPrep:    setSendURL},
 	{Label: "Audio Send",
 		Text:   "audio has no caption, sent as text",
 		URN:    "whatsapp:250788123123",
@@ -540,9 +561,23 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		RequestBody: `{"to":"250788123123","type":"template","template":{"namespace":"wa_template_namespace","name":"revive_issue","language":{"policy":"deterministic","code":"en_US"},"components":[{"type":"body","parameters":[{"type":"text","text":"Chef"},{"type":"text","text":"tomorrow"}]}]}}`,
 		SendPrep:    setSendURL,
 	},
+	{Label: "Template Components",
+		Text:   "templated message",
+		URN:    "whatsapp:250788123123",
+		Status: "W", ExternalID: "157b5e14568e8",
+		Metadata:     json.RawMessage(`{ "templating": { "template": { "name": "order_update", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3" }, "language": "eng", "components": [{"type": "header", "params": [{"type": "image", "value": "https://foo.bar/order.jpg"}]}, {"type": "body", "params": [{"type": "text", "value": "Chef"}]}, {"type": "button", "sub_type": "url", "index": 0, "params": [{"type": "text", "value": "1234"}]}]}}`),
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 200,
+		RequestBody: `{"to":"250788123123","type":"template","template":{"namespace":"waba_namespace","name":"order_update","language":{"policy":"deterministic","code":"en"},"components":[{"type":"header","parameters":[{"type":"image","image":{"link":"https://foo.bar/order.jpg"}}]},{"type":"body","parameters":[{"type":"text","text":"Chef"}]},{"type":"button","sub_type":"url","index":"0","parameters":[{"type":"text","text":"1234"}]}]}}`,
+		SendPrep:    setSendURL,
+	},
+	{Label: "Template Invalid Component",
+		Text: "templated message", URN: "whatsapp:250788123123",
+		Status:   "F",
+		Metadata: json.RawMessage(`{"templating": { "template": { "name": "revive_issue", "uuid": "8ca114b4-bee2-4d3b-aaf1-9aa6b48d41e8" }, "language": "eng", "components": [{"type": "footer", "params": []}]}}`),
+	},
 	{Label: "Template Invalid Language",
 		Text: "templated message", URN: "whatsapp:250788123123",
-		Error:    `unable to decode template: {"templating": { "template": { "name": "revive_issue", "uuid": "8ca114b4-bee2-4d3b-aaf1-9aa6b48d41e8" }, "language": "bnt", "variables": ["Chef", "tomorrow"]}} for channel: 8eb23e93-5ecb-45ba-b726-3b064e0c56ab: unable to find mapping for language: bnt`,
+		Status:   "F",
 		Metadata: json.RawMessage(`{"templating": { "template": { "name": "revive_issue", "uuid": "8ca114b4-bee2-4d3b-aaf1-9aa6b48d41e8" }, "language": "bnt", "variables": ["Chef", "tomorrow"]}}`),
 	},
 	{Label: "WhatsApp Contact Error",
@@ -676,6 +711,27 @@ var defaultSendTestCases = []ChannelSendTestCase{
 		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
 		RequestBody: `{"to":"250788123123","type":"interactive","interactive":{"type":"list","body":{"text":"Interactive List Msg"},"action":{"button":"Menu","sections":[{"rows":[{"id":"0","title":"ROW1"},{"id":"1","title":"ROW2"},{"id":"2","title":"ROW3"},{"id":"3","title":"ROW4"}]}]}}}`,
 		SendPrep:    setSendURL},
+	{Label: "Structured Interactive Buttons Send",
+		Text: "Pick one", URN: "whatsapp:250788123123",
+		Metadata: json.RawMessage(`{"interactive":{"header":"Choices","footer":"Reply now","buttons":[{"id":"yes","title":"Yes"},{"id":"no","title":"No"}]}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"to":"250788123123","type":"interactive","interactive":{"type":"button","header":{"type":"text","text":"Choices"},"body":{"text":"Pick one"},"footer":{"text":"Reply now"},"action":{"buttons":[{"type":"reply","reply":{"id":"yes","title":"Yes"}},{"type":"reply","reply":{"id":"no","title":"No"}}]}}}`,
+		SendPrep:    setSendURL},
+	{Label: "Structured Interactive List Send",
+		Text: "Pick a color", URN: "whatsapp:250788123123",
+		Metadata: json.RawMessage(`{"interactive":{"list":{"button":"Colors","sections":[{"title":"Warm","rows":[{"id":"r","title":"Red","description":"Like fire"}]},{"title":"Cool","rows":[{"id":"b","title":"Blue"}]}]}}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"to":"250788123123","type":"interactive","interactive":{"type":"list","body":{"text":"Pick a color"},"action":{"button":"Colors","sections":[{"title":"Warm","rows":[{"id":"r","title":"Red","description":"Like fire"}]},{"title":"Cool","rows":[{"id":"b","title":"Blue"}]}]}}}`,
+		SendPrep:    setSendURL},
+	{Label: "Structured Interactive CTA Send",
+		Text: "See our menu", URN: "whatsapp:250788123123",
+		Metadata: json.RawMessage(`{"interactive":{"cta":{"title":"Open","url":"https://foo.bar/menu"}}}`),
+		Status:   "W", ExternalID: "157b5e14568e8",
+		ResponseBody: `{ "messages": [{"id": "157b5e14568e8"}] }`, ResponseStatus: 201,
+		RequestBody: `{"to":"250788123123","type":"interactive","interactive":{"type":"cta_url","body":{"text":"See our menu"},"action":{"name":"cta_url","parameters":{"display_text":"Open","url":"https://foo.bar/menu"}}}}`,
+		SendPrep:    setSendURL},
 	{Label: "Interactive Button Message Send with attachment",
 		Text: "Interactive Button Msg", URN: "whatsapp:250788123123", QuickReplies: []string{"BUTTON1"},
 		Status: "W", ExternalID: "157b5e14568e8",
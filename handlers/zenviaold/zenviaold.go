@@ -132,7 +132,7 @@ func (h *handler) receiveMessage(ctx context.Context, channel courier.Channel, w
 	}
 
 	// create our URN
-	urn, err := handlers.StrictTelForCountry(payload.CallbackMORequest.From, channel.Country())
+	urn, err := handlers.NormalizeTelForChannel(channel, payload.CallbackMORequest.From)
 	if err != nil {
 		return nil, handlers.WriteAndLogRequestError(ctx, h, channel, w, r, err)
 	}
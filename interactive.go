@@ -0,0 +1,204 @@
+package courier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// MetadataInteractive is the key in outgoing message metadata which holds structured interactive content
+const MetadataInteractive = "interactive"
+
+// MetadataInteractiveReplyID is the key in incoming message metadata which holds the ID of the button or list row
+// the contact selected
+const MetadataInteractiveReplyID = "interactive_reply_id"
+
+// Interactive is structured interactive content for an outgoing message, it can have either reply buttons, a list of
+// rows grouped into sections or a single call to action URL button
+type Interactive struct {
+	Header  string              `json:"header,omitempty"`
+	Footer  string              `json:"footer,omitempty"`
+	Buttons []InteractiveButton `json:"buttons,omitempty"`
+	List    *InteractiveList    `json:"list,omitempty"`
+	CTA     *InteractiveCTA     `json:"cta,omitempty"`
+}
+
+// InteractiveButton is a reply button, the ID is what we get back when the contact selects it
+type InteractiveButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// InteractiveList is a list of rows grouped into sections, opened by a button with the given text
+type InteractiveList struct {
+	Button   string               `json:"button"`
+	Sections []InteractiveSection `json:"sections"`
+}
+
+// InteractiveSection is a titled group of rows in a list
+type InteractiveSection struct {
+	Title string           `json:"title,omitempty"`
+	Rows  []InteractiveRow `json:"rows"`
+}
+
+// InteractiveRow is a single selectable row in a list, the ID is what we get back when the contact selects it
+type InteractiveRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// InteractiveCTA is a button which opens the given URL
+type InteractiveCTA struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// InteractiveRenderer is the interface handlers which can render interactive content natively should satisfy, messages
+// with interactive content sent through other handlers have it rendered as text
+type InteractiveRenderer interface {
+	RendersInteractive() bool
+}
+
+// ParseInteractive parses the interactive content from the passed in message metadata, returning nil if there is none
+// or it isn't valid
+func ParseInteractive(metadata json.RawMessage) *Interactive {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	value, dataType, _, err := jsonparser.Get(metadata, MetadataInteractive)
+	if err != nil || dataType != jsonparser.Object {
+		return nil
+	}
+
+	interactive := &Interactive{}
+	if err := json.Unmarshal(value, interactive); err != nil {
+		return nil
+	}
+
+	if len(interactive.Buttons) == 0 && interactive.CTA == nil && (interactive.List == nil || len(interactive.List.Rows()) == 0) {
+		return nil
+	}
+	return interactive
+}
+
+// Rows returns all the rows of this list across all of its sections
+func (l *InteractiveList) Rows() []InteractiveRow {
+	rows := make([]InteractiveRow, 0)
+	for _, section := range l.Sections {
+		rows = append(rows, section.Rows...)
+	}
+	return rows
+}
+
+// Options returns the titles of the buttons or list rows the contact can choose from
+func (i *Interactive) Options() []string {
+	options := make([]string, 0)
+	if len(i.Buttons) > 0 {
+		for _, button := range i.Buttons {
+			options = append(options, button.Title)
+		}
+	} else if i.List != nil {
+		for _, row := range i.List.Rows() {
+			options = append(options, row.Title)
+		}
+	}
+	return options
+}
+
+// menuOptions returns the options the contact can reply to by number when this content is rendered as text, along
+// with the IDs of the buttons or list rows they are for
+func (i *Interactive) menuOptions() []NumberedMenuOption {
+	options := make([]NumberedMenuOption, 0)
+	if len(i.Buttons) > 0 {
+		for _, button := range i.Buttons {
+			options = append(options, NumberedMenuOption{Title: button.Title, ID: button.ID})
+		}
+	} else if i.List != nil {
+		for _, row := range i.List.Rows() {
+			options = append(options, NumberedMenuOption{Title: row.Title, ID: row.ID})
+		}
+	}
+	return options
+}
+
+// WrapText returns the passed in message text with this content's header and footer, for channels which can display
+// buttons natively but have nowhere else to put them
+func (i *Interactive) WrapText(text string) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{i.Header, text, i.Footer} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// TextFallback renders this interactive content along with the passed in message text as plain text, for channels
+// which can't display it natively
+func (i *Interactive) TextFallback(text string) string {
+	parts := make([]string, 0, 5)
+	if i.Header != "" {
+		parts = append(parts, i.Header)
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	options := i.Options()
+	if len(options) > 0 {
+		lines := make([]string, len(options))
+		for n, option := range options {
+			lines[n] = fmt.Sprintf("%d. %s", n+1, option)
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+
+	if i.CTA != nil {
+		if i.CTA.Title != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", i.CTA.Title, i.CTA.URL))
+		} else {
+			parts = append(parts, i.CTA.URL)
+		}
+	}
+
+	if i.Footer != "" {
+		parts = append(parts, i.Footer)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// NewInteractiveReplyMetadata returns incoming message metadata recording the ID of the button or list row selected
+func NewInteractiveReplyMetadata(replyID string) json.RawMessage {
+	metadata, _ := json.Marshal(map[string]string{MetadataInteractiveReplyID: replyID})
+	return metadata
+}
+
+// interactiveFallbackMsg wraps an outgoing message with interactive content so that handlers which can't render it
+// natively send its text fallback instead
+type interactiveFallbackMsg struct {
+	Msg
+	text    string
+	options []NumberedMenuOption
+}
+
+func (m *interactiveFallbackMsg) Text() string              { return m.text }
+func (m *interactiveFallbackMsg) QuickReplies() []string    { return nil }
+func (m *interactiveFallbackMsg) Interactive() *Interactive { return nil }
+
+// withInteractiveFallback returns the message to pass to the passed in handler, replacing any interactive content
+// with its text fallback if the handler can't render it natively
+func withInteractiveFallback(handler ChannelHandler, msg Msg) Msg {
+	interactive := msg.Interactive()
+	if interactive == nil {
+		return msg
+	}
+
+	if renderer, isRenderer := handler.(InteractiveRenderer); isRenderer && renderer.RendersInteractive() {
+		return msg
+	}
+	return &interactiveFallbackMsg{Msg: msg, text: interactive.TextFallback(msg.Text()), options: interactive.menuOptions()}
+}
@@ -0,0 +1,53 @@
+package courier
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInteractive(t *testing.T) {
+	assert.Nil(t, ParseInteractive(nil))
+	assert.Nil(t, ParseInteractive(json.RawMessage(`{"quick_replies":["Yes"]}`)))
+	assert.Nil(t, ParseInteractive(json.RawMessage(`{"interactive":"buttons"}`)))
+	assert.Nil(t, ParseInteractive(json.RawMessage(`{"interactive":{"header":"Nothing to pick"}}`)))
+	assert.Nil(t, ParseInteractive(json.RawMessage(`{"interactive":{"list":{"button":"Menu","sections":[]}}}`)))
+
+	interactive := ParseInteractive(json.RawMessage(`{"interactive":{"header":"Survey","footer":"Thanks","buttons":[{"id":"y","title":"Yes"},{"id":"n","title":"No"}]}}`))
+	assert.Equal(t, &Interactive{
+		Header:  "Survey",
+		Footer:  "Thanks",
+		Buttons: []InteractiveButton{{ID: "y", Title: "Yes"}, {ID: "n", Title: "No"}},
+	}, interactive)
+	assert.Equal(t, "Survey\n\nAre you happy?\n\nThanks", interactive.WrapText("Are you happy?"))
+	assert.Equal(t, "Survey\n\nAre you happy?\n\n1. Yes\n2. No\n\nThanks", interactive.TextFallback("Are you happy?"))
+
+	interactive = ParseInteractive(json.RawMessage(`{"interactive":{"list":{"button":"Colors","sections":[{"title":"Warm","rows":[{"id":"r","title":"Red"}]},{"title":"Cool","rows":[{"id":"b","title":"Blue"}]}]}}}`))
+	assert.Equal(t, []InteractiveRow{{ID: "r", Title: "Red"}, {ID: "b", Title: "Blue"}}, interactive.List.Rows())
+	assert.Equal(t, "Pick one\n\n1. Red\n2. Blue", interactive.TextFallback("Pick one"))
+
+	interactive = ParseInteractive(json.RawMessage(`{"interactive":{"cta":{"title":"Open","url":"https://foo.bar"}}}`))
+	assert.Equal(t, "See our menu\n\nOpen: https://foo.bar", interactive.TextFallback("See our menu"))
+}
+
+func TestInteractiveFallback(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "2020", "US", map[string]interface{}{})
+
+	// messages without interactive content are passed through as is
+	msg := &mockMsg{channel: channel, text: "Hello", quickReplies: []string{"Hi"}}
+	assert.Equal(t, msg, withInteractiveFallback(NewHandler(), msg))
+
+	// handlers which can't render interactive content get the text fallback instead
+	msg = &mockMsg{channel: channel, text: "Are you happy?", quickReplies: []string{"Yes"}, metadata: json.RawMessage(`{"interactive":{"buttons":[{"id":"y","title":"Yes"}]}}`)}
+	fallback := withInteractiveFallback(NewHandler(), msg)
+	assert.Equal(t, "Are you happy?\n\n1. Yes", fallback.Text())
+	assert.Nil(t, fallback.QuickReplies())
+	assert.Nil(t, fallback.Interactive())
+	assert.Equal(t, msg.ID(), fallback.ID())
+
+	// on channels with numbered menus, its options are remembered with their IDs rather than rendered again
+	menuMsg, offered := withNumberedMenu(fallback)
+	assert.Equal(t, fallback, menuMsg)
+	assert.Equal(t, []NumberedMenuOption{{Title: "Yes", ID: "y"}}, offered)
+}
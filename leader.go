@@ -0,0 +1,218 @@
+package courier
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/queue"
+	"github.com/nyaruka/courier/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// identifies this courier instance when it holds a leader lease, includes our hostname so it's clear who the leader is
+var leaderIdentity = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%s", host, queue.InstanceID())
+}()
+
+var luaLeaderAcquire = redis.NewScript(1, `-- KEYS: [Key] ARGV: [Identity, TTLMS]
+	-- take the lease if it's free, or extend it if we already hold it
+	local owner = redis.call("get", KEYS[1])
+	if owner == false or owner == ARGV[1] then
+		redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+		return 1
+	end
+	return 0
+`)
+
+var luaLeaderRenew = redis.NewScript(1, `-- KEYS: [Key] ARGV: [Identity, TTLMS]
+	-- only extend the lease if we still hold it
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		redis.call("pexpire", KEYS[1], ARGV[2])
+		return 1
+	end
+	return 0
+`)
+
+var luaLeaderRelease = redis.NewScript(1, `-- KEYS: [Key] ARGV: [Identity]
+	-- only release the lease if we hold it
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		redis.call("del", KEYS[1])
+	end
+	return 1
+`)
+
+// LeaderElection elects a single courier instance to run a singleton background job, such as resuming purges. The
+// leader holds a lease in Redis which it keeps renewing, if it fails to then it loses leadership and another instance
+// takes over once the lease expires.
+type LeaderElection struct {
+	pool      *redis.Pool
+	name      string
+	ttl       time.Duration
+	onElected func()
+	onLost    func()
+
+	leader      int32
+	lastRenewal time.Time
+}
+
+// the elections of this instance, by name
+var leaderElections = make(map[string]*LeaderElection)
+var leaderElectionsMutex sync.Mutex
+
+// NewLeaderElection creates a new election for the job with the passed in name, whose lease lasts for the passed in
+// TTL. The passed in callbacks, either of which may be nil, are called when this instance becomes and stops being leader.
+func NewLeaderElection(pool *redis.Pool, name string, ttl time.Duration, onElected func(), onLost func()) *LeaderElection {
+	e := &LeaderElection{pool: pool, name: name, ttl: ttl, onElected: onElected, onLost: onLost}
+
+	leaderElectionsMutex.Lock()
+	leaderElections[name] = e
+	leaderElectionsMutex.Unlock()
+
+	return e
+}
+
+// removeLeaderElection forgets the passed in election once this instance no longer takes part in it
+func removeLeaderElection(e *LeaderElection) {
+	leaderElectionsMutex.Lock()
+	defer leaderElectionsMutex.Unlock()
+
+	if leaderElections[e.name] == e {
+		delete(leaderElections, e.name)
+	}
+}
+
+func leaderKey(name string) string {
+	return utils.RedisKey("leader:%s", name)
+}
+
+// Name returns the name of the job this election is for
+func (e *LeaderElection) Name() string { return e.name }
+
+// IsLeader returns whether this instance is currently the leader
+func (e *LeaderElection) IsLeader() bool { return atomic.LoadInt32(&e.leader) == 1 }
+
+// Acquire tries to take the lease, returning whether we now hold it
+func (e *LeaderElection) Acquire() (bool, error) {
+	rc := e.pool.Get()
+	defer rc.Close()
+
+	return redis.Bool(luaLeaderAcquire.Do(rc, leaderKey(e.name), leaderIdentity, int64(e.ttl/time.Millisecond)))
+}
+
+// Renew extends the lease, returning false if we no longer hold it
+func (e *LeaderElection) Renew() (bool, error) {
+	rc := e.pool.Get()
+	defer rc.Close()
+
+	return redis.Bool(luaLeaderRenew.Do(rc, leaderKey(e.name), leaderIdentity, int64(e.ttl/time.Millisecond)))
+}
+
+// Release gives up the lease if we hold it so that another instance can take over straight away
+func (e *LeaderElection) Release() error {
+	rc := e.pool.Get()
+	defer rc.Close()
+
+	_, err := luaLeaderRelease.Do(rc, leaderKey(e.name), leaderIdentity)
+	return err
+}
+
+// Start starts a goroutine which campaigns to become leader, renewing our lease whilst we are. When the passed in
+// quitter chan is closed, the lease is released.
+func (e *LeaderElection) Start(quitter chan bool, wg *sync.WaitGroup) {
+	log := logrus.WithField("comp", "leader").WithField("election", e.name)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			e.campaign(log)
+
+			select {
+			case <-quitter:
+				if e.IsLeader() {
+					if err := e.Release(); err != nil {
+						log.WithError(err).Error("error releasing leader lease")
+					}
+					e.lose(log)
+				}
+				return
+
+			case <-time.After(e.ttl / 3):
+			}
+		}
+	}()
+}
+
+func (e *LeaderElection) campaign(log *logrus.Entry) {
+	if e.IsLeader() {
+		renewed, err := e.Renew()
+		if err != nil {
+			log.WithError(err).Error("error renewing leader lease")
+
+			// we can't tell whether we still hold our lease, so assume we don't once it would have expired
+			if time.Since(e.lastRenewal) < e.ttl {
+				return
+			}
+		}
+		if renewed {
+			e.lastRenewal = time.Now()
+		} else {
+			e.lose(log)
+		}
+		return
+	}
+
+	acquired, err := e.Acquire()
+	if err != nil {
+		log.WithError(err).Error("error acquiring leader lease")
+		return
+	}
+	if acquired {
+		e.lastRenewal = time.Now()
+		atomic.StoreInt32(&e.leader, 1)
+		log.WithField("identity", leaderIdentity).Info("elected leader")
+
+		if e.onElected != nil {
+			e.onElected()
+		}
+	}
+}
+
+func (e *LeaderElection) lose(log *logrus.Entry) {
+	atomic.StoreInt32(&e.leader, 0)
+	log.WithField("identity", leaderIdentity).Warn("no longer leader")
+
+	if e.onLost != nil {
+		e.onLost()
+	}
+}
+
+// GetLeader returns the identity of the instance currently leading the election with the passed in name, or an empty
+// string if there is no leader
+func GetLeader(rc redis.Conn, name string) (string, error) {
+	leader, err := redis.String(rc.Do("GET", leaderKey(name)))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+	return leader, err
+}
+
+// getLeaderElectionNames returns the names of the elections this instance takes part in, sorted
+func getLeaderElectionNames() []string {
+	leaderElectionsMutex.Lock()
+	defer leaderElectionsMutex.Unlock()
+
+	names := make([]string, 0, len(leaderElections))
+	for name := range leaderElections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,115 @@
+package courier
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/courier/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderElection(t *testing.T) {
+	mb := NewMockBackend()
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+	rc.Do("DEL", "leader:test")
+
+	elected, lost := 0, 0
+	election := NewLeaderElection(mb.RedisPool(), "test", time.Second*3, func() { elected++ }, func() { lost++ })
+
+	// we can take a free lease and extend it
+	acquired, err := election.Acquire()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	renewed, err := election.Renew()
+	assert.NoError(t, err)
+	assert.True(t, renewed)
+
+	leader, err := GetLeader(rc, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, leaderIdentity, leader)
+
+	// but not one held by another instance
+	rc.Do("SET", "leader:test", "other/1234")
+
+	acquired, err = election.Acquire()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	renewed, err = election.Renew()
+	assert.NoError(t, err)
+	assert.False(t, renewed)
+
+	// and releasing it doesn't take it away from them
+	assert.NoError(t, election.Release())
+
+	leader, err = GetLeader(rc, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "other/1234", leader)
+
+	// once it's free, campaigning makes us leader
+	rc.Do("DEL", "leader:test")
+
+	quitter := make(chan bool)
+	wg := &sync.WaitGroup{}
+	election.Start(quitter, wg)
+	time.Sleep(time.Millisecond * 100)
+
+	assert.True(t, election.IsLeader())
+	assert.Equal(t, 1, elected)
+
+	// if another instance takes the lease, we lose leadership on our next renewal
+	rc.Do("SET", "leader:test", "other/1234")
+	time.Sleep(time.Millisecond * 1100)
+
+	assert.False(t, election.IsLeader())
+	assert.Equal(t, 1, lost)
+
+	// and when they give it up, we take over again
+	rc.Do("DEL", "leader:test")
+	time.Sleep(time.Millisecond * 1100)
+
+	assert.True(t, election.IsLeader())
+	assert.Equal(t, 2, elected)
+
+	// stopping releases our lease
+	close(quitter)
+	wg.Wait()
+
+	assert.False(t, election.IsLeader())
+	assert.Equal(t, 2, lost)
+
+	leader, err = GetLeader(rc, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "", leader)
+}
+
+func TestScriptKeys(t *testing.T) {
+	utils.SetRedisKeyPrefix("test", true)
+	defer utils.SetRedisKeyPrefix("", false)
+
+	rec := &utils.RedisRecorder{}
+	pool := rec.Pool()
+
+	election := NewLeaderElection(pool, "test", time.Second, nil, nil)
+	election.Acquire()
+	election.Renew()
+	election.Release()
+
+	config := NewConfig()
+	for _, limit := range []string{ConfigInboundURNLimit, ConfigInboundChannelLimit} {
+		channel := NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", map[string]interface{}{limit: 5})
+		CheckInboundFlood(pool.Get(), config, &mockMsg{channel: channel, urn: "tel:+12065551212", text: "hi"})
+	}
+
+	// in a cluster every key a script is passed must be in the same slot as the rest of ours
+	assert.Equal(t, 5, len(rec.Keys))
+	for _, keys := range rec.Keys {
+		for _, key := range keys {
+			assert.True(t, strings.HasPrefix(key, "{test}:"), "key %s isn't prefixed", key)
+		}
+	}
+}
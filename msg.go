@@ -16,6 +16,9 @@ import (
 // ErrMsgNotFound is returned when trying to queue the status for a Msg that doesn't exit
 var ErrMsgNotFound = errors.New("message not found")
 
+// ErrMsgDropped is returned when writing an incoming Msg which was dropped for being over our flood limits
+var ErrMsgDropped = errors.New("message dropped")
+
 // ErrWrongIncomingMsgStatus use do ignore the status update if the DB raise this
 var ErrWrongIncomingMsgStatus = errors.New("Incoming messages can only be PENDING or HANDLED")
 
@@ -98,6 +101,7 @@ type Msg interface {
 	URNAuth() string
 	ContactName() string
 	QuickReplies() []string
+	Interactive() *Interactive
 	Topic() string
 	Metadata() json.RawMessage
 	ResponseToExternalID() string
@@ -114,6 +118,7 @@ type Msg interface {
 
 	HighPriority() bool
 
+	WithText(text string) Msg
 	WithContactName(name string) Msg
 	WithReceivedOn(date time.Time) Msg
 	WithExternalID(id string) Msg
@@ -0,0 +1,176 @@
+package courier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/sms"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataNumberedMenuReply is the key in incoming message metadata which holds the raw text of a numeric reply
+// which was mapped to one of the options of a numbered menu
+const MetadataNumberedMenuReply = "numbered_menu_reply"
+
+// NumberedMenuOption is an option offered in a numbered menu, along with the ID of the interactive button or list row
+// it was rendered from if it was
+type NumberedMenuOption struct {
+	Title string `json:"title"`
+	ID    string `json:"id,omitempty"`
+}
+
+// BuildNumberedMenu appends the passed in options to the passed in text as a numbered menu, adding as many options as
+// fit in the given number of SMS segments. It returns the new text and the options which were included.
+func BuildNumberedMenu(text string, options []string, maxSegments int) (string, []string) {
+	if sms.Segments(text) > maxSegments {
+		maxSegments = sms.Segments(text)
+	}
+
+	menu := text
+	offered := make([]string, 0, len(options))
+	for i, option := range options {
+		separator := "\n"
+		if i == 0 && text != "" {
+			separator = "\n\n"
+		} else if i == 0 {
+			separator = ""
+		}
+
+		withOption := fmt.Sprintf("%s%s%d. %s", menu, separator, i+1, option)
+		if sms.Segments(withOption) > maxSegments {
+			break
+		}
+
+		menu = withOption
+		offered = append(offered, option)
+	}
+	return menu, offered
+}
+
+// numberedMenuKey returns the key we store the options last offered to a URN on a channel under
+func numberedMenuKey(channel Channel, urn urns.URN) string {
+	return utils.RedisKey("numbered_menu:%s:%s", channel.UUID(), urn.Identity())
+}
+
+// RememberNumberedMenu stores the options offered to the passed in URN so that numeric replies can be mapped back to
+// them, an empty list of options forgets any previous menu
+func RememberNumberedMenu(rc redis.Conn, channel Channel, urn urns.URN, options []NumberedMenuOption, ttl time.Duration) error {
+	key := numberedMenuKey(channel, urn)
+	if len(options) == 0 {
+		_, err := rc.Do("DEL", key)
+		return err
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	_, err = rc.Do("SET", key, encoded, "EX", int64(ttl/time.Second))
+	return err
+}
+
+// ResolveNumberedMenuReply maps an incoming numeric reply to the option it picked from the last numbered menu sent to
+// the contact, keeping the raw text in the message metadata along with the ID of the interactive button or list row
+// the option was for. It returns whether the message was mapped.
+func ResolveNumberedMenuReply(rc redis.Conn, msg Msg) (bool, error) {
+	number, err := strconv.Atoi(strings.TrimRight(strings.TrimSpace(msg.Text()), "."))
+	if err != nil || number < 1 {
+		return false, nil
+	}
+
+	encoded, err := redis.Bytes(rc.Do("GET", numberedMenuKey(msg.Channel(), msg.URN())))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	options := make([]NumberedMenuOption, 0)
+	if err := json.Unmarshal(encoded, &options); err != nil {
+		return false, err
+	}
+	if number > len(options) {
+		return false, nil
+	}
+
+	metadata := msg.Metadata()
+	if len(metadata) == 0 {
+		metadata = json.RawMessage(`{}`)
+	}
+	raw, _ := json.Marshal(msg.Text())
+	metadata, err = jsonparser.Set(metadata, raw, MetadataNumberedMenuReply)
+	if err != nil {
+		return false, err
+	}
+
+	option := options[number-1]
+	if option.ID != "" {
+		replyID, _ := json.Marshal(option.ID)
+		metadata, err = jsonparser.Set(metadata, replyID, MetadataInteractiveReplyID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	msg.WithText(option.Title).WithMetadata(metadata)
+	return true, nil
+}
+
+// numberedMenuMsg wraps an outgoing message so that its quick replies are sent as a numbered menu in its text
+type numberedMenuMsg struct {
+	Msg
+	text string
+}
+
+func (m *numberedMenuMsg) Text() string           { return m.text }
+func (m *numberedMenuMsg) QuickReplies() []string { return nil }
+
+// withNumberedMenu returns the message to send on channels with numbered menus enabled, along with the options it
+// offers the contact. Interactive content rendered as text already has its options numbered.
+func withNumberedMenu(msg Msg) (Msg, []NumberedMenuOption) {
+	if fallback, isFallback := msg.(*interactiveFallbackMsg); isFallback {
+		return msg, fallback.options
+	}
+	if len(msg.QuickReplies()) == 0 {
+		return msg, nil
+	}
+
+	text, offered := BuildNumberedMenu(msg.Text(), msg.QuickReplies(), msg.Channel().IntConfigForKey(ConfigNumberedMenuSegments, 1))
+	options := make([]NumberedMenuOption, len(offered))
+	for i, title := range offered {
+		options[i] = NumberedMenuOption{Title: title}
+	}
+	return &numberedMenuMsg{Msg: msg, text: text}, options
+}
+
+// sendWithNumberedMenu sends the passed in message through the passed in handler, rendering its quick replies as a
+// numbered menu and remembering the options offered if the channel has numbered menus enabled
+func (s *server) sendWithNumberedMenu(ctx context.Context, handler ChannelHandler, msg Msg) (MsgStatus, error) {
+	if !msg.Channel().BoolConfigForKey(ConfigNumberedMenu, false) {
+		return handler.SendMsg(ctx, msg)
+	}
+
+	msg, offered := withNumberedMenu(msg)
+	status, err := handler.SendMsg(ctx, msg)
+	if err != nil || status == nil || status.Status() == MsgErrored || status.Status() == MsgFailed {
+		return status, err
+	}
+
+	rc := s.backend.RedisPool().Get()
+	defer rc.Close()
+
+	ttl := time.Duration(s.config.NumberedMenuTTL) * time.Second
+	if rerr := RememberNumberedMenu(rc, msg.Channel(), msg.URN(), offered, ttl); rerr != nil {
+		logrus.WithError(rerr).WithField("channel_uuid", msg.Channel().UUID()).Error("error remembering numbered menu")
+	}
+	return status, err
+}
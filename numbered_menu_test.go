@@ -0,0 +1,91 @@
+package courier
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nyaruka/gocommon/urns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildNumberedMenu(t *testing.T) {
+	text, offered := BuildNumberedMenu("Are you happy?", []string{"Yes", "No"}, 1)
+	assert.Equal(t, "Are you happy?\n\n1. Yes\n2. No", text)
+	assert.Equal(t, []string{"Yes", "No"}, offered)
+
+	text, offered = BuildNumberedMenu("", []string{"Yes", "No"}, 1)
+	assert.Equal(t, "1. Yes\n2. No", text)
+	assert.Equal(t, []string{"Yes", "No"}, offered)
+
+	// options which don't fit in our segments are left out
+	long := strings.Repeat("x", 140)
+	text, offered = BuildNumberedMenu(long, []string{"Yes", "Not really at all", "No"}, 1)
+	assert.Equal(t, long+"\n\n1. Yes", text)
+	assert.Equal(t, []string{"Yes"}, offered)
+
+	text, offered = BuildNumberedMenu(long, []string{"Yes", "Not really at all", "No"}, 2)
+	assert.Equal(t, long+"\n\n1. Yes\n2. Not really at all\n3. No", text)
+	assert.Equal(t, []string{"Yes", "Not really at all", "No"}, offered)
+
+	// text already over our segments can still have options that fit in the segments it uses
+	longer := strings.Repeat("x", 290)
+	text, offered = BuildNumberedMenu(longer, []string{"Yes"}, 1)
+	assert.Equal(t, longer+"\n\n1. Yes", text)
+	assert.Equal(t, []string{"Yes"}, offered)
+}
+
+func TestNumberedMenuReplies(t *testing.T) {
+	mb := NewMockBackend()
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+
+	channel := NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", map[string]interface{}{ConfigNumberedMenu: true})
+	urn := urns.URN("tel:+12065551212")
+
+	err := RememberNumberedMenu(rc, channel, urn, []NumberedMenuOption{{Title: "Yes"}, {Title: "No"}}, time.Minute)
+	assert.NoError(t, err)
+
+	// non-numeric and out of range replies are left alone
+	for _, text := range []string{"Yes", "0", "3", "2 please"} {
+		msg := mb.NewIncomingMsg(channel, urn, text)
+		mapped, err := ResolveNumberedMenuReply(rc, msg)
+		assert.NoError(t, err)
+		assert.False(t, mapped, "unexpected mapping for '%s'", text)
+		assert.Equal(t, text, msg.Text())
+	}
+
+	msg := mb.NewIncomingMsg(channel, urn, " 2. ")
+	mapped, err := ResolveNumberedMenuReply(rc, msg)
+	assert.NoError(t, err)
+	assert.True(t, mapped)
+	assert.Equal(t, "No", msg.Text())
+	assert.Equal(t, json.RawMessage(`{"numbered_menu_reply":" 2. "}`), msg.Metadata())
+
+	// options rendered from interactive content also record the ID of their button or row
+	err = RememberNumberedMenu(rc, channel, urn, []NumberedMenuOption{{Title: "Yes", ID: "y"}, {Title: "No", ID: "n"}}, time.Minute)
+	assert.NoError(t, err)
+
+	msg = mb.NewIncomingMsg(channel, urn, "1")
+	mapped, err = ResolveNumberedMenuReply(rc, msg)
+	assert.NoError(t, err)
+	assert.True(t, mapped)
+	assert.Equal(t, "Yes", msg.Text())
+	assert.Equal(t, json.RawMessage(`{"numbered_menu_reply":"1","interactive_reply_id":"y"}`), msg.Metadata())
+
+	// other contacts have no menu
+	msg = mb.NewIncomingMsg(channel, urns.URN("tel:+12065551313"), "1")
+	mapped, err = ResolveNumberedMenuReply(rc, msg)
+	assert.NoError(t, err)
+	assert.False(t, mapped)
+
+	// sending a message without a menu forgets it
+	err = RememberNumberedMenu(rc, channel, urn, nil, time.Minute)
+	assert.NoError(t, err)
+
+	msg = mb.NewIncomingMsg(channel, urn, "1")
+	mapped, err = ResolveNumberedMenuReply(rc, msg)
+	assert.NoError(t, err)
+	assert.False(t, mapped)
+}
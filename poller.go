@@ -0,0 +1,261 @@
+package courier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// how often we look for channels which need polling to be started, restarted or stopped
+	pollerRefreshInterval = time.Minute
+
+	// how long the lease on polling a channel lasts if not renewed, needs to be longer than a single poll
+	pollerLeaseTTL = 2 * time.Minute
+
+	// how long we wait before polling again if the last poll returned nothing
+	pollerIdleWait = time.Second
+
+	// limits for our backoff when polls fail
+	pollerMinBackoff = time.Second
+	pollerMaxBackoff = 5 * time.Minute
+)
+
+// configuredChannel is satisfied by channels which can return their whole config, which lets us restart a channel's
+// poller when its config changes
+type configuredChannel interface {
+	Config() map[string]interface{}
+}
+
+// channelPoller is a poller we've started, along with what it needs to be stopped and the config it was started with
+type channelPoller struct {
+	stop   chan bool
+	done   chan bool
+	config string
+}
+
+// startPollers starts a supervisor for each of our active handlers which support polling
+func startPollers(s *server) {
+	for _, handler := range activeHandlers {
+		if pollingHandler, isPolling := handler.(PollingChannelHandler); isPolling {
+			s.waitGroup.Add(1)
+			go superviseChannelPollers(s, pollingHandler)
+		}
+	}
+}
+
+// superviseChannelPollers periodically looks up the channels for the passed in handler, starting and stopping
+// pollers as polling is enabled or disabled on them, and restarting them when their config changes
+func superviseChannelPollers(s *server, handler PollingChannelHandler) {
+	defer s.waitGroup.Done()
+
+	log := logrus.WithField("comp", "poller").WithField("channel_type", handler.ChannelType())
+	log.WithField("state", "started").Info("poller supervisor started")
+
+	running := make(map[ChannelUUID]*channelPoller)
+	defer func() {
+		for _, poller := range running {
+			close(poller.stop)
+		}
+		log.WithField("state", "stopped").Info("poller supervisor stopped")
+	}()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+		channels, err := s.backend.GetChannelsByType(ctx, handler.ChannelType())
+		cancel()
+
+		if err != nil {
+			log.WithError(err).Error("error looking up channels to poll")
+		} else {
+			enabled := make(map[ChannelUUID]bool)
+			for _, channel := range channels {
+				if !channel.BoolConfigForKey(ConfigPolling, false) {
+					continue
+				}
+				enabled[channel.UUID()] = true
+				config := pollerConfig(channel)
+
+				// a channel whose config has changed has its poller stopped and a new one started once it has
+				var previous chan bool
+				if poller, found := running[channel.UUID()]; found {
+					if poller.config == config {
+						continue
+					}
+					log.WithField("channel_uuid", channel.UUID()).Info("restarting poller for changed channel config")
+					close(poller.stop)
+					previous = poller.done
+				}
+
+				poller := &channelPoller{stop: make(chan bool), done: make(chan bool), config: config}
+				running[channel.UUID()] = poller
+
+				s.waitGroup.Add(1)
+				go pollChannel(s, handler, channel, poller, previous)
+			}
+
+			for uuid, poller := range running {
+				if !enabled[uuid] {
+					close(poller.stop)
+					delete(running, uuid)
+				}
+			}
+		}
+
+		select {
+		case <-s.stopChan:
+			return
+		case <-time.After(pollerRefreshInterval):
+		}
+	}
+}
+
+// pollerConfig returns what a channel's poller depends on, so that we can tell when it has changed
+func pollerConfig(channel Channel) string {
+	config := map[string]interface{}{"address": channel.Address()}
+	if configured, isConfigured := channel.(configuredChannel); isConfigured {
+		config["config"] = configured.Config()
+	}
+	encoded, _ := json.Marshal(config)
+	return string(encoded)
+}
+
+// pollChannel polls the passed in channel until it is stopped, backing off when polls fail. Each channel is only
+// polled by one instance at a time, whichever is leader of the channel's election. If a previous poller of the
+// channel is passed in, we wait for it to stop first.
+func pollChannel(s *server, handler PollingChannelHandler, channel Channel, poller *channelPoller, previous chan bool) {
+	defer s.waitGroup.Done()
+	defer close(poller.done)
+
+	if previous != nil {
+		select {
+		case <-previous:
+		case <-s.stopChan:
+			return
+		}
+	}
+
+	log := logrus.WithField("comp", "poller").WithField("channel_uuid", channel.UUID()).WithField("channel_type", channel.ChannelType())
+	cursorKey := utils.RedisKey("poller:cursor:%s", channel.UUID())
+
+	election := NewLeaderElection(s.backend.RedisPool(), fmt.Sprintf("poller:%s", channel.UUID()), pollerLeaseTTL, nil, nil)
+	defer func() {
+		if err := election.Release(); err != nil {
+			log.WithError(err).Error("error releasing polling lease")
+		}
+		removeLeaderElection(election)
+	}()
+
+	var backoff time.Duration
+	for {
+		wait := pollerIdleWait
+
+		election.campaign(log)
+
+		if !election.IsLeader() {
+			// someone else is polling this channel, check back later in case they go away
+			wait = pollerLeaseTTL / 3
+		} else if cursor, err := getPollerCursor(s.backend.RedisPool(), cursorKey); err != nil {
+			log.WithError(err).Error("error getting polling cursor")
+		} else {
+			events, next, err := pollChannelOnce(s, handler, channel, cursor)
+
+			// save our cursor even if we failed part way through so that what we did handle isn't handled again
+			if next != "" && next != cursor {
+				rc := s.backend.RedisPool().Get()
+				_, serr := rc.Do("SET", cursorKey, next)
+				rc.Close()
+				if serr != nil {
+					log.WithError(serr).Error("error saving polling cursor")
+				}
+			}
+
+			if err != nil {
+				backoff = backoff * 2
+				if backoff < pollerMinBackoff {
+					backoff = pollerMinBackoff
+				} else if backoff > pollerMaxBackoff {
+					backoff = pollerMaxBackoff
+				}
+				log.WithError(err).WithField("backoff", backoff).Error("error polling channel")
+				wait = backoff
+			} else {
+				backoff = 0
+
+				// keep polling straight away if there may be more waiting
+				if len(events) > 0 {
+					wait = 0
+				}
+			}
+		}
+
+		select {
+		case <-poller.stop:
+			return
+		case <-s.stopChan:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// getPollerCursor returns the cursor the last poll of a channel left off at
+func getPollerCursor(pool *redis.Pool, cursorKey string) (string, error) {
+	rc := pool.Get()
+	defer rc.Close()
+
+	cursor, err := redis.String(rc.Do("GET", cursorKey))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// pollChannelOnce calls our handler to poll the passed in channel, writing the channel logs for what it did
+func pollChannelOnce(s *server, handler PollingChannelHandler, channel Channel, cursor string) (events []Event, next string, err error) {
+	var logs []*ChannelLog
+
+	// polling shouldn't take longer than our lease
+	ctx, cancel := context.WithTimeout(context.Background(), pollerLeaseTTL-time.Second*10)
+	defer cancel()
+
+	defer func() {
+		if panicLog := recover(); panicLog != nil {
+			err = fmt.Errorf("panic polling channel: %v", panicLog)
+		}
+
+		// we only log polls which did something or failed, otherwise there's a log for every poll
+		if len(events) > 0 || err != nil {
+			for _, log := range logs {
+				if err != nil && log.Error == "" {
+					log.Error = err.Error()
+				}
+			}
+
+			// like webhooks, each message received gets a log of the request it came from
+			pollLogs := logs
+			for _, event := range events {
+				if msg, isMsg := event.(Msg); isMsg {
+					for _, log := range pollLogs {
+						received := *log
+						received.Description = "Message Received"
+						received.MsgID = msg.ID()
+						logs = append(logs, &received)
+					}
+				}
+			}
+
+			if werr := s.backend.WriteChannelLogs(context.Background(), logs); werr != nil {
+				logrus.WithError(werr).WithField("channel_uuid", channel.UUID()).Error("error writing polling channel logs")
+			}
+		}
+	}()
+
+	events, logs, next, err = handler.PollChannel(ctx, channel, cursor)
+	return events, next, err
+}
@@ -0,0 +1,17 @@
+package courier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollerConfig(t *testing.T) {
+	channel := NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2020", "US", map[string]interface{}{ConfigPolling: true, "auth_token": "a123"})
+	config := pollerConfig(channel)
+	assert.Equal(t, config, pollerConfig(NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2020", "US", map[string]interface{}{"auth_token": "a123", ConfigPolling: true})))
+
+	// pollers are restarted when the config or address of their channel changes
+	assert.NotEqual(t, config, pollerConfig(NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2020", "US", map[string]interface{}{ConfigPolling: true, "auth_token": "b456"})))
+	assert.NotEqual(t, config, pollerConfig(NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "TG", "2021", "US", map[string]interface{}{ConfigPolling: true, "auth_token": "a123"})))
+}
@@ -0,0 +1,135 @@
+package courier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/nyaruka/courier/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataProviderOptions is the key in outgoing message metadata which holds options to pass through to the provider,
+// these are namespaced by the lowercase channel type, e.g. {"provider_options": {"tg": {"disable_notification": true}}}
+const MetadataProviderOptions = "provider_options"
+
+// ProviderOptionType is the type of value a provider option takes
+type ProviderOptionType string
+
+// the types of values provider options can take
+const (
+	ProviderOptionString ProviderOptionType = "string"
+	ProviderOptionBool   ProviderOptionType = "bool"
+	ProviderOptionInt    ProviderOptionType = "int"
+)
+
+// ProviderOption describes an option a handler can apply to its requests, string options can be limited to a set of
+// values
+type ProviderOption struct {
+	Name   string             `json:"name"`
+	Type   ProviderOptionType `json:"type"`
+	Values []string           `json:"values,omitempty"`
+}
+
+// ProviderOptionsDescriber is the interface handlers which support provider options should satisfy
+type ProviderOptionsDescriber interface {
+	ProviderOptions() []ProviderOption
+}
+
+// ProviderOptions are the validated provider options of an outgoing message
+type ProviderOptions map[string]interface{}
+
+// String returns the value of the passed in string option and whether it was set
+func (o ProviderOptions) String(name string) (string, bool) {
+	value, isString := o[name].(string)
+	return value, isString
+}
+
+// Bool returns the value of the passed in bool option and whether it was set
+func (o ProviderOptions) Bool(name string) (bool, bool) {
+	value, isBool := o[name].(bool)
+	return value, isBool
+}
+
+// Int returns the value of the passed in int option and whether it was set
+func (o ProviderOptions) Int(name string) (int, bool) {
+	value, isInt := o[name].(int)
+	return value, isInt
+}
+
+// GetProviderOptions parses the provider options for the channel type of the passed in message from its metadata,
+// validating them against the passed in supported options. Options which aren't supported are logged and ignored but
+// an error is returned if any supported option has an invalid value.
+func GetProviderOptions(msg Msg, supported []ProviderOption) (ProviderOptions, error) {
+	options := make(ProviderOptions)
+	if len(msg.Metadata()) == 0 {
+		return options, nil
+	}
+
+	namespace := strings.ToLower(string(msg.Channel().ChannelType()))
+	value, dataType, _, err := jsonparser.Get(msg.Metadata(), MetadataProviderOptions, namespace)
+	if err == jsonparser.KeyPathNotFoundError || dataType == jsonparser.Null {
+		return options, nil
+	}
+	if err != nil || dataType != jsonparser.Object {
+		return nil, fmt.Errorf("provider options for %s must be an object", namespace)
+	}
+
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, fmt.Errorf("unable to decode provider options: %s", err)
+	}
+
+	for name, rawValue := range raw {
+		var option *ProviderOption
+		for i := range supported {
+			if supported[i].Name == name {
+				option = &supported[i]
+				break
+			}
+		}
+
+		if option == nil {
+			logrus.WithField("channel_uuid", msg.Channel().UUID()).WithField("msg_id", msg.ID().String()).WithField("option", name).Warning("ignoring unsupported provider option")
+			continue
+		}
+
+		parsed, err := option.parse(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for provider option %s: %s", name, err)
+		}
+		options[name] = parsed
+	}
+
+	return options, nil
+}
+
+// parse parses and validates the passed in raw value for this option
+func (o *ProviderOption) parse(raw json.RawMessage) (interface{}, error) {
+	switch o.Type {
+	case ProviderOptionBool:
+		var value bool
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("expected a bool")
+		}
+		return value, nil
+
+	case ProviderOptionInt:
+		var value int
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		return value, nil
+
+	default:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("expected a string")
+		}
+		if len(o.Values) > 0 && !utils.StringArrayContains(o.Values, value) {
+			return nil, fmt.Errorf("expected one of %s", strings.Join(o.Values, ", "))
+		}
+		return value, nil
+	}
+}
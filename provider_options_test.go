@@ -0,0 +1,58 @@
+package courier
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProviderOptions(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "TG", "2020", "US", map[string]interface{}{})
+	supported := []ProviderOption{
+		{Name: "disable_notification", Type: ProviderOptionBool},
+		{Name: "parse_mode", Type: ProviderOptionString, Values: []string{"Markdown", "HTML"}},
+		{Name: "validity", Type: ProviderOptionInt},
+	}
+	msgWithMetadata := func(metadata string) Msg {
+		return &mockMsg{channel: channel, text: "Hello", metadata: json.RawMessage(metadata)}
+	}
+
+	options, err := GetProviderOptions(&mockMsg{channel: channel, text: "Hello"}, supported)
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderOptions{}, options)
+
+	// options for other channel types are ignored
+	options, err = GetProviderOptions(msgWithMetadata(`{"provider_options": {"kn": {"validity": 10}}}`), supported)
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderOptions{}, options)
+
+	// as are unsupported options
+	options, err = GetProviderOptions(msgWithMetadata(`{"provider_options": {"tg": {"disable_notification": true, "parse_mode": "HTML", "validity": 10, "protect_content": true}}}`), supported)
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderOptions{"disable_notification": true, "parse_mode": "HTML", "validity": 10}, options)
+
+	disable, found := options.Bool("disable_notification")
+	assert.True(t, found)
+	assert.True(t, disable)
+	parseMode, found := options.String("parse_mode")
+	assert.True(t, found)
+	assert.Equal(t, "HTML", parseMode)
+	validity, found := options.Int("validity")
+	assert.True(t, found)
+	assert.Equal(t, 10, validity)
+	_, found = options.String("protect_content")
+	assert.False(t, found)
+
+	// but supported options with invalid values are errors
+	for metadata, expected := range map[string]string{
+		`{"provider_options": {"tg": "silent"}}`:                             "provider options for tg must be an object",
+		`{"provider_options": {"tg": {"disable_notification": "yes"}}}`:      "invalid value for provider option disable_notification: expected a bool",
+		`{"provider_options": {"tg": {"parse_mode": "BBCode"}}}`:             "invalid value for provider option parse_mode: expected one of Markdown, HTML",
+		`{"provider_options": {"tg": {"validity": 1.5}}}`:                    "invalid value for provider option validity: expected an integer",
+		`{"provider_options": {"tg": {"parse_mode": ["Markdown", "HTML"]}}}`: "invalid value for provider option parse_mode: expected a string",
+	} {
+		_, err = GetProviderOptions(msgWithMetadata(metadata), supported)
+		assert.EqualError(t, err, expected, "error mismatch for %s", metadata)
+	}
+}
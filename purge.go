@@ -7,10 +7,23 @@ import (
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	// how long the lease of the instance processing purges lasts if not renewed
+	purgeLeaderTTL = time.Minute
+
+	// how often the leader checks for purges to process
+	purgeInterval = time.Second
+)
+
+// PurgeHandler handles requests to purge the outgoing queues of channels. Requests only record which queues are to be
+// purged, the purging itself is done by whichever instance is leader so that a queue is never purged concurrently.
 type PurgeHandler struct {
-	server Server
+	server   Server
+	election *LeaderElection
 }
 
 func NewPurgeHandler(s Server) *PurgeHandler {
@@ -58,7 +71,7 @@ func (p *PurgeHandler) PurgeChannel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		go p.PurgeRoutine(purgeQueues)
+		logrus.WithField("queues", purgeQueues).Debug("Queued purge")
 	}
 
 	// Even if courier has no queues, always call the channel's purge handler if it is available.
@@ -69,20 +82,46 @@ func (p *PurgeHandler) PurgeChannel(w http.ResponseWriter, r *http.Request) {
 	WriteDataResponse(context.Background(), w, http.StatusOK, "Ok", nil)
 }
 
-func (p PurgeHandler) ResumePurges() {
-	purgeQueues, err := p.server.Backend().GetActivePurges(context.Background())
+// Start starts a goroutine which, whilst this instance is leader, processes any purges which have been requested,
+// including those which were interrupted by a previous leader stopping
+func (p *PurgeHandler) Start(quitter chan bool, wg *sync.WaitGroup) {
+	p.election = NewLeaderElection(p.server.Backend().RedisPool(), "purges", purgeLeaderTTL, nil, nil)
+	p.election.Start(quitter, wg)
 
-	if err == nil && len(purgeQueues) == 0 {
-		logrus.Debug("No purges to resume")
-	} else if err != nil {
-		logrus.WithError(err).Error("Could not resume purges")
-	} else {
-		logrus.WithField("queues", purgeQueues).Debug("Resuming purge")
-		go p.PurgeRoutine(purgeQueues)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-quitter:
+				return
+
+			case <-time.After(purgeInterval):
+				if p.election.IsLeader() {
+					p.processPurges(p.election.IsLeader)
+				}
+			}
+		}
+	}()
+}
+
+// processPurges purges all the queues with active purges, stopping early if the passed in function returns false
+func (p *PurgeHandler) processPurges(shouldContinue func() bool) {
+	purgeQueues, err := p.server.Backend().GetActivePurges(context.Background())
+	if err != nil {
+		logrus.WithError(err).Error("Could not get active purges")
+		return
+	}
+	if len(purgeQueues) > 0 {
+		logrus.WithField("queues", purgeQueues).Debug("Processing purges")
+		p.purgeQueues(purgeQueues, shouldContinue)
 	}
 }
 
-func (p *PurgeHandler) PurgeRoutine(queueKeys []string) {
+// purgeQueues fails the messages in the passed in queues, stopping early if the passed in function returns false such
+// as when we are no longer the leader
+func (p *PurgeHandler) purgeQueues(queueKeys []string, shouldContinue func() bool) {
 	rc := p.server.Backend().RedisPool().Get()
 	defer rc.Close()
 
@@ -93,6 +132,11 @@ func (p *PurgeHandler) PurgeRoutine(queueKeys []string) {
 		hasMsg := true
 		// Iterate through messages until we're out of them.
 		for hasMsg == true {
+			if !shouldContinue() {
+				logrus.WithField("queue", v).Warn("stopping purge")
+				return
+			}
+
 			msgs, _ := p.server.Backend().PopMsgs(context.Background(), v, 10)
 
 			if len(msgs) == 0 {
@@ -1,6 +1,7 @@
 package courier
 
 import (
+	"context"
 	"fmt"
 	"github.com/nyaruka/courier/queue"
 	"github.com/nyaruka/courier/utils"
@@ -61,7 +62,17 @@ func TestPurgeHandler_PurgeChannel(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.StatusCode)
 	assert.JSONEq(t, `{"message":"Ok","data":null}`, string(rr.Body), "Incorrect response returned")
 
-	time.Sleep(time.Second * 1)
+	// purges are processed by the leader in the background, so the queue is renamed straight away
+	cnt, err = conn.Do("ZCOUNT", "msgs:e4bb1578-29da-4fa5-a214-9da19dd24230|50/0", "-inf", "+inf")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	time.Sleep(purgeInterval * 3)
+
+	// and is gone once the purge has been processed
+	purges, err := backend.GetActivePurges(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, purges, 0)
 
 	// Ensure there's no messages left
 	cnt, err = conn.Do("ZCOUNT", "msgs:e4bb1578-29da-4fa5-a214-9da19dd24230|50/0", "-inf", "+inf")
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/pkg/errors"
+)
+
+// the maximum number of dead letters we keep for a queue type, older ones are dropped
+const maxDeadLetters = 10000
+
+// DeadLetter is an entry popped from a queue which couldn't be processed, such as one which can't be decoded or is for
+// a channel which no longer exists. These are kept so they can be inspected and then replayed or discarded.
+type DeadLetter struct {
+	UUID      string    `json:"uuid"`
+	Queue     string    `json:"queue"`
+	Priority  Priority  `json:"priority"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// NewDeadLetter creates a new dead letter for the passed in value popped from the queue with the passed in worker token
+func NewDeadLetter(qType string, token WorkerToken, priority Priority, value string, reason string) *DeadLetter {
+	return &DeadLetter{
+		UUID:      string(uuids.New()),
+		Queue:     strings.TrimPrefix(token.Queue(), utils.RedisKey("%s:", qType)),
+		Priority:  priority,
+		Value:     value,
+		Reason:    reason,
+		CreatedOn: time.Now().In(time.UTC),
+	}
+}
+
+func deadLetterKey(qType string) string {
+	return utils.RedisKey("%s:dead_letter", qType)
+}
+
+// dead letters are also kept in a hash by UUID so they can be found without scanning the list
+func deadLetterIndexKey(qType string) string {
+	return utils.RedisKey("%s:dead_letter_index", qType)
+}
+
+var luaPushDeadLetter = redis.NewScript(2, `-- KEYS: [DeadLetterKey, IndexKey] ARGV: [UUID, Value, MaxLetters]
+	redis.call("LPUSH", KEYS[1], ARGV[2])
+	redis.call("HSET", KEYS[2], ARGV[1], ARGV[2])
+
+	-- drop our oldest letters from both the list and the index
+	while redis.call("LLEN", KEYS[1]) > tonumber(ARGV[3]) do
+		local oldest = cjson.decode(redis.call("RPOP", KEYS[1]))
+		redis.call("HDEL", KEYS[2], oldest["uuid"])
+	end
+`)
+
+// PushDeadLetter adds the passed in dead letter to the dead letter list for the passed in queue type
+func PushDeadLetter(conn redis.Conn, qType string, letter *DeadLetter) error {
+	encoded, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	_, err = luaPushDeadLetter.Do(conn, deadLetterKey(qType), deadLetterIndexKey(qType), letter.UUID, encoded, maxDeadLetters)
+	return err
+}
+
+// GetDeadLetters returns the dead letters for the passed in queue type, newest first
+func GetDeadLetters(conn redis.Conn, qType string) ([]*DeadLetter, error) {
+	values, err := redis.ByteSlices(conn.Do("LRANGE", deadLetterKey(qType), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]*DeadLetter, 0, len(values))
+	for _, value := range values {
+		letter := &DeadLetter{}
+		if err := json.Unmarshal(value, letter); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling dead letter")
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+var luaRemoveDeadLetter = redis.NewScript(2, `-- KEYS: [DeadLetterKey, IndexKey] ARGV: [UUID]
+	local value = redis.call("HGET", KEYS[2], ARGV[1])
+	if not value then
+		return nil
+	end
+
+	redis.call("LREM", KEYS[1], 1, value)
+	redis.call("HDEL", KEYS[2], ARGV[1])
+	return value
+`)
+
+// RemoveDeadLetter removes the dead letter with the passed in UUID, returning it or nil if it doesn't exist
+func RemoveDeadLetter(conn redis.Conn, qType string, uuid string) (*DeadLetter, error) {
+	value, err := redis.Bytes(luaRemoveDeadLetter.Do(conn, deadLetterKey(qType), deadLetterIndexKey(qType), uuid))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	letter := &DeadLetter{}
+	if err := json.Unmarshal(value, letter); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling dead letter")
+	}
+	return letter, nil
+}
+
+// ReplayDeadLetter removes the dead letter with the passed in UUID and pushes its value back onto the queue it was
+// popped from, returning it or nil if it doesn't exist. If it can't be pushed back, the dead letter is restored.
+func ReplayDeadLetter(conn redis.Conn, qType string, uuid string) (*DeadLetter, error) {
+	letter, err := RemoveDeadLetter(conn, qType, uuid)
+	if err != nil || letter == nil {
+		return nil, err
+	}
+
+	if err := replayDeadLetter(conn, qType, letter); err != nil {
+		if pushErr := PushDeadLetter(conn, qType, letter); pushErr != nil {
+			return nil, errors.Wrapf(err, "error restoring dead letter %s after failed replay: %s", letter.UUID, pushErr)
+		}
+		return nil, err
+	}
+	return letter, nil
+}
+
+func replayDeadLetter(conn redis.Conn, qType string, letter *DeadLetter) error {
+	// our queue is in the format uuid|tps
+	queue, tps, err := splitQueueName(letter.Queue)
+	if err != nil {
+		return err
+	}
+
+	// queued values are lists of entries
+	return PushOntoQueue(conn, qType, queue, tps, "["+letter.Value+"]", letter.Priority)
+}
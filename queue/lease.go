@@ -0,0 +1,208 @@
+package queue
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/uuids"
+	"github.com/sirupsen/logrus"
+)
+
+// LeaseTTL is how long a worker token is leased for. Workers which haven't marked their task as complete by then are
+// assumed to have died and have their worker reclaimed from the queue by the lease janitor.
+var LeaseTTL = time.Minute * 5
+
+// how often our lease janitor looks for expired leases
+var leaseJanitorInterval = time.Second * 30
+
+// every courier process tracks the leases it holds in its own set
+var instanceID = string(uuids.New())
+
+// InstanceID returns the ID this process tracks its worker leases under
+func InstanceID() string {
+	return instanceID
+}
+
+// Queue returns the name of the queue this worker token is for
+func (t WorkerToken) Queue() string {
+	return strings.SplitN(string(t), "#", 2)[0]
+}
+
+func leaseKey(qType string) string {
+	return utils.RedisKey("%s:leases:%s", qType, instanceID)
+}
+
+func leaseOwnersKey(qType string) string {
+	return utils.RedisKey("%s:lease_owners", qType)
+}
+
+func epochSeconds(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano()/int64(time.Microsecond))/float64(1000000), 'f', 6, 64)
+}
+
+// luaReleaseWorker defines a lua function which removes a worker from the passed in queue, which may be active or throttled
+const luaReleaseWorker = `
+	local function releaseWorker(qType, queue)
+		-- decrement throttled if present
+		local throttled = tonumber(redis.call("zadd", qType .. ":throttled", "XX", "CH", "INCR", -1, queue))
+
+		-- if we didn't decrement anything, do so to our active set
+		if not throttled or throttled == 0 then
+			local active = tonumber(redis.call("zincrby", qType .. ":active", -1, queue))
+
+			-- reset to zero if we somehow go below
+			if active < 0 then
+				redis.call("zadd", qType .. ":active", 0, queue)
+			end
+		end
+	end
+`
+
+var luaReclaimLeases = redis.NewScript(1, `-- KEYS: [QueueType] ARGV: [EpochSeconds]
+	`+luaReleaseWorker+`
+
+	local ownersKey = KEYS[1] .. ":lease_owners"
+	local owners = redis.call("smembers", ownersKey)
+	local reclaimed = 0
+	local corrected = 0
+	local live = {}
+
+	for _, leaseKey in ipairs(owners) do
+		-- release the workers of any expired leases
+		local expired = redis.call("zrangebyscore", leaseKey, "-inf", ARGV[1])
+		for _, lease in ipairs(expired) do
+			redis.call("zrem", leaseKey, lease)
+			local delim = string.find(lease, "#", 1, true)
+			releaseWorker(KEYS[1], string.sub(lease, 1, delim-1))
+			reclaimed = reclaimed + 1
+		end
+
+		-- count the leases which are still live for each queue
+		local leases = redis.call("zrange", leaseKey, 0, -1)
+		for _, lease in ipairs(leases) do
+			local delim = string.find(lease, "#", 1, true)
+			local queue = string.sub(lease, 1, delim-1)
+			live[queue] = (live[queue] or 0) + 1
+		end
+
+		if #leases == 0 then
+			redis.call("srem", ownersKey, leaseKey)
+		end
+	end
+
+	-- no queue should have more workers than it has live leases, correct any that do
+	for _, set in ipairs({KEYS[1] .. ":active", KEYS[1] .. ":throttled"}) do
+		local queues = redis.call("zrange", set, 0, -1, "WITHSCORES")
+		for i=1,#queues,2 do
+			local leases = live[queues[i]] or 0
+			if tonumber(queues[i+1]) > leases then
+				redis.call("zadd", set, leases, queues[i])
+				corrected = corrected + 1
+			end
+		end
+	end
+
+	return {reclaimed, corrected}
+`)
+
+// ReclaimExpiredLeases releases the workers of any expired leases for the passed in queue type and resets the worker
+// counts of any queues which have more workers than live leases. It returns the number of leases reclaimed and the
+// number of queues corrected.
+func ReclaimExpiredLeases(conn redis.Conn, qType string) (int, int, error) {
+	counts, err := redis.Ints(luaReclaimLeases.Do(conn, utils.RedisKey(qType), epochSeconds(time.Now())))
+	if err != nil {
+		return 0, 0, err
+	}
+	return counts[0], counts[1], nil
+}
+
+// GetLeaseCounts returns the number of live leases for each queue of the passed in queue type, across all instances
+func GetLeaseCounts(conn redis.Conn, qType string) (map[string]int, error) {
+	owners, err := redis.Strings(conn.Do("SMEMBERS", leaseOwnersKey(qType)))
+	if err != nil {
+		return nil, err
+	}
+
+	now := epochSeconds(time.Now())
+	counts := make(map[string]int)
+	for _, owner := range owners {
+		leases, err := redis.Strings(conn.Do("ZRANGEBYSCORE", owner, "("+now, "+inf"))
+		if err != nil {
+			return nil, err
+		}
+		for _, lease := range leases {
+			counts[WorkerToken(lease).Queue()]++
+		}
+	}
+	return counts, nil
+}
+
+// LeaseJanitor keeps track of the leases reclaimed and queues corrected by a running janitor
+type LeaseJanitor struct {
+	reclaimed int64
+	corrected int64
+}
+
+// Reclaimed returns the number of expired leases reclaimed since the janitor was started
+func (j *LeaseJanitor) Reclaimed() int64 {
+	return atomic.LoadInt64(&j.reclaimed)
+}
+
+// Corrected returns the number of queue worker counts corrected since the janitor was started
+func (j *LeaseJanitor) Corrected() int64 {
+	return atomic.LoadInt64(&j.corrected)
+}
+
+// StartLeaseJanitor starts a goroutine responsible for periodically reclaiming expired leases, such as those held by
+// an instance which died mid-send, and pruning the recorded orgs of queues which no longer exist. The passed in quitter
+// chan can be used to shut down the goroutine
+func StartLeaseJanitor(rp *redis.Pool, quitter chan bool, wg *sync.WaitGroup, qType string) *LeaseJanitor {
+	janitor := &LeaseJanitor{}
+	log := logrus.WithField("comp", "lease_janitor")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-quitter:
+				return
+
+			case <-time.After(leaseJanitorInterval):
+				conn := rp.Get()
+				reclaimed, corrected, err := ReclaimExpiredLeases(conn, qType)
+				conn.Close()
+
+				if err != nil {
+					log.WithError(err).Error("error reclaiming expired leases")
+					continue
+				}
+
+				atomic.AddInt64(&janitor.reclaimed, int64(reclaimed))
+				atomic.AddInt64(&janitor.corrected, int64(corrected))
+
+				if reclaimed > 0 || corrected > 0 {
+					log.WithField("reclaimed", reclaimed).WithField("corrected", corrected).Warn("reclaimed leaked queue workers")
+				}
+
+				conn = rp.Get()
+				pruned, err := PruneQueueOrgs(conn, qType)
+				conn.Close()
+
+				if err != nil {
+					log.WithError(err).Error("error pruning queue orgs")
+				} else if pruned > 0 {
+					log.WithField("pruned", pruned).Debug("pruned orgs of removed queues")
+				}
+			}
+		}
+	}()
+
+	return janitor
+}
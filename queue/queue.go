@@ -2,6 +2,7 @@ package queue
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -9,13 +10,16 @@ import (
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+	"github.com/nyaruka/gocommon/uuids"
 	"github.com/sirupsen/logrus"
 )
 
 // Priority represents the priority of an item in a queue
 type Priority int64
 
-// WorkerToken represents a token that a worker should return when a task is complete
+// WorkerToken represents a token that a worker should return when a task is complete. Tokens are leases on a worker
+// for a queue, in the format queue#lease, which expire after LeaseTTL if never returned.
 type WorkerToken string
 
 const (
@@ -35,27 +39,28 @@ const (
 	Retry = WorkerToken("retry")
 )
 
-var luaPush = redis.NewScript(6, `-- KEYS: [EpochMS, QueueType, QueueName, TPS, Priority, Value]
+var luaPush = redis.NewScript(1, `-- KEYS: [QueueType] ARGV: [EpochMS, QueueName, TPS, Priority, Value, Score]
 	-- first push onto our specific queue
 	-- our queue name is built from the type, name and tps, usually something like: "msgs:uuid1-uuid2-uuid3-uuid4|tps"
-	local queueKey = KEYS[2] .. ":" .. KEYS[3] .. "|" .. KEYS[4]
+	local queueKey = KEYS[1] .. ":" .. ARGV[2] .. "|" .. ARGV[3]
 
 	-- our priority queue name also includes the priority of the message (we have one queue for default and one for bulk)
-	local priorityQueueKey = queueKey .. "/" .. KEYS[5]
-	redis.call("zadd", priorityQueueKey, KEYS[1], KEYS[6])
+	local priorityQueueKey = queueKey .. "/" .. ARGV[4]
+	-- our score is when the value can be popped, usually now
+	redis.call("zadd", priorityQueueKey, ARGV[6], ARGV[5])
 
-	local tps = tonumber(KEYS[4])
+	local tps = tonumber(ARGV[3])
 
 	-- if we have a TPS, check whether we are currently throttled
 	local curr = -1
 	if tps > 0 then
-   	    local tpsKey = queueKey .. ":tps:" .. math.floor(KEYS[1])
+   	    local tpsKey = queueKey .. ":tps:" .. math.floor(ARGV[1])
 	    curr = tonumber(redis.call("get", tpsKey))
 	end
 
 	-- if we aren't then add to our active
 	if not curr or curr < tps then
-	  redis.call("zincrby", KEYS[2] .. ":active", 0, queueKey)
+	  redis.call("zincrby", KEYS[1] .. ":active", 0, queueKey)
 	  return 1
 	else 
 	  return 0
@@ -66,22 +71,61 @@ var luaPush = redis.NewScript(6, `-- KEYS: [EpochMS, QueueType, QueueName, TPS,
 // specified transactions per second are popped off at a time. A tps value of 0 means there is no
 // limit to the rate that messages can be consumed
 func PushOntoQueue(conn redis.Conn, qType string, queue string, tps int, value string, priority Priority) error {
-	epochMS := strconv.FormatFloat(float64(time.Now().UnixNano()/int64(time.Microsecond))/float64(1000000), 'f', 6, 64)
-	_, err := redis.Int(luaPush.Do(conn, epochMS, qType, queue, tps, priority, value))
+	return PushOntoQueueAt(conn, qType, queue, tps, value, priority, time.Now())
+}
+
+// PushOntoQueueAt pushes the passed in value to the passed in queue like PushOntoQueue, but it won't be popped
+// before the passed in time
+func PushOntoQueueAt(conn redis.Conn, qType string, queue string, tps int, value string, priority Priority, at time.Time) error {
+	epochMS := epochSeconds(time.Now())
+	score := epochSeconds(at)
+	_, err := redis.Int(luaPush.Do(conn, utils.RedisKey(qType), epochMS, queue, tps, priority, value, score))
 	return err
 }
 
-var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
-	-- get the first key off our active list
-	local result = redis.call("zrange", KEYS[2] .. ":active", 0, 0, "WITHSCORES")
-	local queue = result[1]
-	local workers = result[2]
+// PushBack pushes the passed in value back onto the queue the passed in worker token is for, so that it is popped
+// again at the passed in time. The caller should still mark the token as complete.
+func PushBack(conn redis.Conn, qType string, token WorkerToken, value string, priority Priority, at time.Time) error {
+	queue, tps, err := splitQueueName(strings.TrimPrefix(token.Queue(), utils.RedisKey("%s:", qType)))
+	if err != nil {
+		return err
+	}
+
+	// queued values are lists of entries
+	return PushOntoQueueAt(conn, qType, queue, tps, "["+value+"]", priority, at)
+}
+
+// splitQueueName splits a queue name in the format uuid|tps into its parts
+func splitQueueName(name string) (string, int, error) {
+	parts := strings.Split(name, "|")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid queue name '%s'", name)
+	}
+	tps, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid queue name '%s'", name)
+	}
+	return parts[0], tps, nil
+}
+
+var luaPop = redis.NewScript(3, `-- KEYS: [QueueType, KeyPrefix, LeaseKey] ARGV: [EpochMS, LeaseID, LeaseExpiry, OrgSample, HighWeight, BulkWeight, BulkMaxWait]
+	`+luaPickFairQueue+`
+
+	-- get the least busy keys off our active list
+	local candidates = redis.call("zrange", KEYS[1] .. ":active", 0, math.max(tonumber(ARGV[4]), 1) - 1, "WITHSCORES")
+	local queue = candidates[1]
+	local workers = candidates[2]
 
 	-- nothing? return nothing
 	if not queue then
 		return {"empty", ""}
 	end
 
+	-- if we are comparing several, use the least busy of the org with the fewest workers for its weight
+	if #candidates > 2 then
+		queue, workers = pickFairQueue(KEYS[1], candidates)
+	end
+
 	-- figure out our max transaction per second
 	local delim = string.find(queue, "|")
 	local tps = 0
@@ -90,46 +134,67 @@ var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
 	local queueName = ""
 
 	if delim then
-	    queueName = string.sub(queue, string.len(KEYS[2])+2, delim-1)
+	    queueName = string.sub(queue, string.len(KEYS[1])+2, delim-1)
 	    tps = tonumber(string.sub(queue, delim+1))
 	end
 
 	if queueName then
-		local rateLimitKey = "rate_limit:" .. queueName
+		local rateLimitKey = KEYS[2] .. "rate_limit:" .. queueName
 		local rateLimitEngaged = redis.call("get", rateLimitKey)
 		if rateLimitEngaged then
-			redis.call("zincrby", KEYS[2] .. ":throttled", workers, queue)
-			redis.call("zrem", KEYS[2] .. ":active", queue)
+			redis.call("zincrby", KEYS[1] .. ":throttled", workers, queue)
+			redis.call("zrem", KEYS[1] .. ":active", queue)
 			return {"retry", ""}
 		end
 	end
 
 	-- if we have a tps, then check whether we exceed it
 	if tps > 0 then
-	    tpsKey = queue .. ":tps:" .. math.floor(KEYS[1])
+	    tpsKey = queue .. ":tps:" .. math.floor(ARGV[1])
 	    local curr = redis.call("get", tpsKey)
 	    
 		-- we are at or above our tps, move to our throttled queue
 		if curr and tonumber(curr) >= tps then 
-			redis.call("zincrby", KEYS[2] .. ":throttled", workers, queue)
-			redis.call("zrem", KEYS[2] .. ":active", queue)
+			redis.call("zincrby", KEYS[1] .. ":throttled", workers, queue)
+			redis.call("zrem", KEYS[1] .. ":active", queue)
 			return {"retry", ""}
   	    end
 	end
 
+	-- check if we are rate limited for bulk queue
+	local rateLimitBulkKey = KEYS[2] .. "rate_limit_bulk:" .. queueName
+	local rateLimitBulk = redis.call("get", rateLimitBulkKey)
+
+	local highWeight = tonumber(ARGV[5])
+	local bulkMaxWait = tonumber(ARGV[7])
+
 	-- pop our next value out, first from our default queue
 	local resultQueue = queue .. "/1"
 	local result = redis.call("zrangebyscore", resultQueue, 0, "+inf", "WITHSCORES", "LIMIT", 0, 1)
 	
 	-- keep track as to whether this result is in the future (and therefore ineligible)
-	local isFutureResult = result[1] and tonumber(result[2]) > tonumber(KEYS[1])
+	local isFutureResult = result[1] and tonumber(result[2]) > tonumber(ARGV[1])
+
+	-- if we are sharing between priorities, bulk goes first if it is owed its share or has waited too long
+	if result[1] and not isFutureResult and not rateLimitBulk and (highWeight > 0 or bulkMaxWait > 0) then
+		local bulkResult = redis.call("zrangebyscore", queue .. "/0", 0, "+inf", "WITHSCORES", "LIMIT", 0, 1)
+		if bulkResult[1] and tonumber(bulkResult[2]) <= tonumber(ARGV[1]) then
+			local passes = redis.call("hmget", queue .. ":lanes", "1", "0")
+			local owed = highWeight > 0 and (tonumber(passes[2]) or 0) < (tonumber(passes[1]) or 0)
+			local aged = bulkMaxWait > 0 and tonumber(ARGV[1]) - tonumber(bulkResult[2]) >= bulkMaxWait
+			if owed or aged then
+				result = bulkResult
+				resultQueue = queue .. "/0"
+			end
+		end
+	end
 
 	-- if we didn't find one, try again from our bulk queue
 	if not result[1] or isFutureResult then
-		-- check if we are rate limited for bulk queue
-		local rateLimitBulkKey = "rate_limit_bulk:" .. queueName
-		local rateLimitBulk = redis.call("get", rateLimitBulkKey)
 		if rateLimitBulk then
+			-- move to our throttled queue so that we don't keep retrying this queue until we're dethrottled
+			redis.call("zincrby", KEYS[1] .. ":throttled", workers, queue)
+			redis.call("zrem", KEYS[1] .. ":active", queue)
 			return {"retry", ""}
 		end
 
@@ -140,7 +205,7 @@ var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
 		-- if we got a result
 		if bulkResult[1] then
 			-- if it is in the future, set ourselves as in the future
-			if tonumber(bulkResult[2]) > tonumber(KEYS[1]) then
+			if tonumber(bulkResult[2]) > tonumber(ARGV[1]) then
 				isFutureResult = true
 			
 			-- otherwise, this is a valid result
@@ -158,8 +223,30 @@ var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
 		-- then remove it from the queue
 		redis.call('zremrangebyrank', resultQueue, 0, 0)
 
-		-- and add a worker to this queue
-		redis.call("zincrby", KEYS[2] .. ":active", 1, queue)
+		-- and add a worker to this queue, leased to our instance
+		redis.call("zincrby", KEYS[1] .. ":active", 1, queue)
+		local lease = queue .. "#" .. ARGV[2]
+		redis.call("zadd", KEYS[3], ARGV[3], lease)
+		redis.call("sadd", KEYS[1] .. ":lease_owners", KEYS[3])
+
+		-- keep track of how much each priority has been popped for its weight, a priority with nothing to pop can only
+		-- build up one value's worth of credit to use later
+		if highWeight > 0 then
+			local lanesKey = queue .. ":lanes"
+			local lane = string.sub(resultQueue, -1)
+			local other = "0"
+			local weight = highWeight
+			if lane == "0" then
+				other = "1"
+				weight = math.max(tonumber(ARGV[6]), 1)
+			end
+			local pass = tonumber(redis.call("hincrbyfloat", lanesKey, lane, 1 / weight))
+			local otherPass = tonumber(redis.call("hget", lanesKey, other)) or 0
+			if otherPass < pass - 1 then
+				redis.call("hset", lanesKey, other, pass - 1)
+			end
+			redis.call("expire", lanesKey, 86400)
+		end
 
 		-- parse it as JSON to get the first element out
 		local valueList = cjson.decode(result[1])
@@ -177,21 +264,21 @@ var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
 		    local remaining = cjson.encode(valueList)
 	        
             -- schedule it in the future 3 seconds on our main queue
-            redis.call("zadd", queue .. "/1", tonumber(KEYS[1]) + 3, remaining)
-            redis.call("zincrby", KEYS[2] .. ":future", 0, queue)
+            redis.call("zadd", queue .. "/1", tonumber(ARGV[1]) + 3, remaining)
+            redis.call("zincrby", KEYS[1] .. ":future", 0, queue)
 		end
 
-		return {queue, popValue}
+		return {lease, popValue}
 
 	-- otherwise, the queue only contains future results, remove from active and add to future, have the caller retry
 	elseif isFutureResult then
-	    redis.call("zincrby", KEYS[2] .. ":future", 0, queue)
-	    redis.call("zrem", KEYS[2] .. ":active", queue)
+	    redis.call("zincrby", KEYS[1] .. ":future", 0, queue)
+	    redis.call("zrem", KEYS[1] .. ":active", queue)
 		return {"retry", ""}
 	
 	-- otherwise, the queue is empty, remove it from active
 	else
-		redis.call("zrem", KEYS[2] .. ":active", queue)
+		redis.call("zrem", KEYS[1] .. ":active", queue)
 		return {"retry", ""}
 	end
 `)
@@ -202,7 +289,10 @@ var luaPop = redis.NewScript(2, `-- KEYS: [EpochMS QueueType]
 // Otherwise the WorkerToken should be saved in order to mark the task as complete later.
 func PopFromQueue(conn redis.Conn, qType string) (WorkerToken, string, error) {
 	epochMS := strconv.FormatFloat(float64(time.Now().UnixNano()/int64(time.Microsecond))/float64(1000000), 'f', 6, 64)
-	values, err := redis.Strings(luaPop.Do(conn, epochMS, qType))
+	leaseExpiry := epochSeconds(time.Now().Add(LeaseTTL))
+	s := Scheduling
+	values, err := redis.Strings(luaPop.Do(conn, utils.RedisKey(qType), utils.RedisKeyPrefix(), leaseKey(qType), epochMS, string(uuids.New()), leaseExpiry,
+		s.OrgSample, s.HighPriorityWeight, s.bulkWeight(), int64(s.BulkMaxWait/time.Second)))
 	if err != nil {
 		logrus.Error(err)
 		return "", "", err
@@ -210,26 +300,28 @@ func PopFromQueue(conn redis.Conn, qType string) (WorkerToken, string, error) {
 	return WorkerToken(values[0]), values[1], nil
 }
 
-var luaComplete = redis.NewScript(2, `-- KEYS: [QueueType, Queue]
-	-- decrement throttled if present
-	local throttled = tonumber(redis.call("zadd", KEYS[1] .. ":throttled", "XX", "CH", "INCR", -1, KEYS[2]))
-
-	-- if we didn't decrement anything, do so to our active set
-	if not throttled or throttled == 0 then
-		local active = tonumber(redis.call("zincrby", KEYS[1] .. ":active", -1, KEYS[2]))
-		
-		-- reset to zero if we somehow go below
-		if active < 0 then
-			redis.call("zadd", KEYS[1] .. ":active", 0, KEYS[2])
+var luaComplete = redis.NewScript(2, `-- KEYS: [QueueType, LeaseKey] ARGV: [Token]
+	`+luaReleaseWorker+`
+
+	local queue = ARGV[1]
+	local delim = string.find(ARGV[1], "#", 1, true)
+	if delim then
+		queue = string.sub(ARGV[1], 1, delim-1)
+
+		-- if our lease has already been reclaimed by the janitor then so has our worker
+		if redis.call("zrem", KEYS[2], ARGV[1]) == 0 then
+			return
 		end
 	end
+
+	releaseWorker(KEYS[1], queue)
 `)
 
 // MarkComplete marks a task as complete for the passed in queue and queue result. It is
 // important for callers to call this so that workers are evenly spread across all
 // queues with jobs in them
 func MarkComplete(conn redis.Conn, qType string, token WorkerToken) error {
-	_, err := luaComplete.Do(conn, qType, token)
+	_, err := luaComplete.Do(conn, utils.RedisKey(qType), leaseKey(qType), token)
 	return err
 }
 
@@ -276,7 +368,7 @@ func StartDethrottler(redis *redis.Pool, quitter chan bool, wg *sync.WaitGroup,
 
 			case <-time.After(delay):
 				conn := redis.Get()
-				_, err := luaDethrottle.Do(conn, qType)
+				_, err := luaDethrottle.Do(conn, utils.RedisKey(qType))
 				if err != nil {
 					logrus.WithError(err).Error("error dethrottling")
 				}
@@ -289,11 +381,11 @@ func StartDethrottler(redis *redis.Pool, quitter chan bool, wg *sync.WaitGroup,
 }
 
 func GetActivePurges(conn redis.Conn) ([]string, error) {
-	return redis.Strings(conn.Do("LRANGE", "msgs:active_purge", "0", "-1"))
+	return redis.Strings(conn.Do("LRANGE", utils.RedisKey("msgs:active_purge"), "0", "-1"))
 }
 
 func GetAllChannelQueues(conn redis.Conn, channelID string) ([]string, error) {
-	ret, err := redis.StringMap(luaGetAllChannelQueues.Do(conn, channelID))
+	ret, err := redis.StringMap(luaGetAllChannelQueues.Do(conn, utils.RedisKey("msgs"), channelID))
 
 	if err != nil {
 		return nil, err
@@ -307,13 +399,13 @@ func GetAllChannelQueues(conn redis.Conn, channelID string) ([]string, error) {
 	return keys, err
 }
 
-var luaGetAllChannelQueues = redis.NewScript(1, `-- KEYS: [ChannelID]
+var luaGetAllChannelQueues = redis.NewScript(1, `-- KEYS: [QueueType] ARGV: [ChannelID]
 	local cursor = "0";
 	local list = {};
 	local val = "";
 	
 	repeat
-		local result = redis.call("ZSCAN", "msgs:active", cursor, "MATCH", "msgs:" .. KEYS[1] .. "*", "COUNT", "100");
+		local result = redis.call("ZSCAN", KEYS[1] .. ":active", cursor, "MATCH", KEYS[1] .. ":" .. ARGV[1] .. "*", "COUNT", "100");
 
 		cursor = result[1];
 
@@ -323,7 +415,7 @@ var luaGetAllChannelQueues = redis.NewScript(1, `-- KEYS: [ChannelID]
 	until cursor == "0";
 
 	repeat
-		local result = redis.call("ZSCAN", "msgs:throttled", cursor, "MATCH", "msgs:" .. KEYS[1] .. "*", "COUNT", "100");
+		local result = redis.call("ZSCAN", KEYS[1] .. ":throttled", cursor, "MATCH", KEYS[1] .. ":" .. ARGV[1] .. "*", "COUNT", "100");
 
 		cursor = result[1];
 
@@ -333,7 +425,7 @@ var luaGetAllChannelQueues = redis.NewScript(1, `-- KEYS: [ChannelID]
 	until cursor == "0";
 
 	repeat
-		local result = redis.call("ZSCAN", "msgs:future", cursor, "MATCH", "msgs:" .. KEYS[1] .. "*", "COUNT", "100");
+		local result = redis.call("ZSCAN", KEYS[1] .. ":future", cursor, "MATCH", KEYS[1] .. ":" .. ARGV[1] .. "*", "COUNT", "100");
 
 		cursor = result[1];
 
@@ -343,7 +435,7 @@ var luaGetAllChannelQueues = redis.NewScript(1, `-- KEYS: [ChannelID]
 	until cursor == "0";
 
 	repeat
-		local result = redis.call("ZSCAN", "msgs:active", cursor, "MATCH", "msgs:" .. KEYS[1] .. "*", "COUNT", "100");
+		local result = redis.call("ZSCAN", KEYS[1] .. ":active", cursor, "MATCH", KEYS[1] .. ":" .. ARGV[1] .. "*", "COUNT", "100");
 
 		cursor = result[1];
 
@@ -360,7 +452,7 @@ func PopWithoutChecks(conn redis.Conn, queue string, count int) (map[string]stri
 
 	// Remove entry from purge queue if message queue is empty
 	if err == nil && len(ret) == 0 {
-		redis.Int(conn.Do("LREM", "msgs:active_purge", "0", queue))
+		redis.Int(conn.Do("LREM", utils.RedisKey("msgs:active_purge"), "0", queue))
 	}
 
 	return ret, err
@@ -379,7 +471,7 @@ func PrepareQueueForPurge(conn redis.Conn, queue string) (string, error) {
 
 	rndAppend := strconv.FormatInt(rand.Int63n(9001), 10)
 
-	newName := "msgs:purge:" + queue + "|" + rndAppend
+	newName := utils.RedisKey("msgs:purge:%s|%s", strings.TrimPrefix(queue, utils.RedisKeyPrefix()), rndAppend)
 
 	rename, err := redis.String(conn.Do("RENAME", queue, newName))
 
@@ -391,7 +483,7 @@ func PrepareQueueForPurge(conn redis.Conn, queue string) (string, error) {
 		return "", errors.New("could not rename queue: " + rename)
 	}
 
-	_, err = redis.Int(conn.Do("LPUSH", "msgs:active_purge", newName))
+	_, err = redis.Int(conn.Do("LPUSH", utils.RedisKey("msgs:active_purge"), newName))
 
 	if err != nil {
 		return "", errors.New("could not add queue to active purge list: " + newName)
@@ -3,11 +3,13 @@ package queue
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,8 +72,14 @@ func TestLua(t *testing.T) {
 		t.Fatal("Should be paused")
 	}
 
-	// When the redis paused key is remove, we get the values from bulk queue/low priority
+	// we were moved to throttled rather than being retried
+	count, err := redis.Int(conn.Do("ZCARD", "msgs:throttled"))
+	assert.NoError(err)
+	assert.Equal(1, count, "Expected chan1 to be throttled while paused")
+
+	// When the redis paused key is remove, we get the values from bulk queue/low priority once dethrottled
 	conn.Do("DEL", "rate_limit_bulk:chan1")
+	time.Sleep(time.Second)
 
 	// pop 10 items off
 	for i := 0; i < 10; i++ {
@@ -89,7 +97,7 @@ func TestLua(t *testing.T) {
 	}
 
 	// check our redis state
-	count, err := redis.Int(conn.Do("ZCARD", "msgs:throttled"))
+	count, err = redis.Int(conn.Do("ZCARD", "msgs:throttled"))
 	assert.NoError(err)
 	assert.Equal(1, count, "Expected chan1 to be throttled")
 
@@ -125,7 +133,7 @@ func TestLua(t *testing.T) {
 
 	queue, value, err = PopFromQueue(conn, "msgs")
 	assert.NoError(err)
-	assert.Equal(WorkerToken("msgs:chan1|10"), queue)
+	assert.Equal("msgs:chan1|10", queue.Queue())
 	assert.Equal(`{"id":31}`, value)
 
 	// make sure paused is not present for more tests
@@ -145,7 +153,7 @@ func TestLua(t *testing.T) {
 
 	queue, value, err = PopFromQueue(conn, "msgs")
 	assert.NoError(err)
-	assert.Equal(WorkerToken("msgs:chan1|10"), queue)
+	assert.Equal("msgs:chan1|10", queue.Queue())
 	assert.Equal(`{"id":32}`, value)
 
 	// sleep a few seconds
@@ -179,7 +187,7 @@ func TestLua(t *testing.T) {
 
 	queue, value, err = PopFromQueue(conn, "msgs")
 	assert.NoError(err)
-	assert.Equal(WorkerToken("msgs:chan1|10"), queue)
+	assert.Equal("msgs:chan1|10", queue.Queue())
 	assert.Equal(`{"id":33}`, value)
 
 	// nothing should be left
@@ -312,10 +320,384 @@ func BenchmarkQueue(b *testing.B) {
 
 		queue, value, err := PopFromQueue(conn, "msgs")
 		assert.NoError(err)
-		assert.Equal(WorkerToken("msgs:chan1|0"), queue, "Mismatched queue")
+		assert.Equal("msgs:chan1|0", queue.Queue(), "Mismatched queue")
 		assert.Equal(insertValue, value, "Mismatched value")
 
 		err = MarkComplete(conn, "msgs", queue)
 		assert.NoError(err)
 	}
 }
+
+func TestDeadLetters(t *testing.T) {
+	pool := getPool()
+	conn := pool.Get()
+	defer conn.Close()
+
+	err := PushOntoQueue(conn, "msgs", "chan1", 10, `[{"id":1}]`, HighPriority)
+	assert.NoError(t, err)
+
+	token, value, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, value)
+	MarkComplete(conn, "msgs", token)
+
+	letter := NewDeadLetter("msgs", token, HighPriority, value, "channel not found")
+	assert.Equal(t, "chan1|10", letter.Queue)
+	assert.NoError(t, PushDeadLetter(conn, "msgs", letter))
+	assert.NoError(t, PushDeadLetter(conn, "msgs", NewDeadLetter("msgs", token, LowPriority, `{"id":`, "invalid JSON")))
+
+	letters, err := GetDeadLetters(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(letters))
+	assert.Equal(t, "invalid JSON", letters[0].Reason)
+	assert.Equal(t, "channel not found", letters[1].Reason)
+
+	// a letter whose queue we can't parse stays put when replayed
+	broken := NewDeadLetter("msgs", token, LowPriority, `{"id":2}`, "channel not found")
+	broken.Queue = "chan1"
+	assert.NoError(t, PushDeadLetter(conn, "msgs", broken))
+	_, err = ReplayDeadLetter(conn, "msgs", broken.UUID)
+	assert.EqualError(t, err, "invalid queue name 'chan1'")
+
+	letters, err = GetDeadLetters(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(letters))
+	assert.Equal(t, broken.UUID, letters[0].UUID)
+
+	removed, err := RemoveDeadLetter(conn, "msgs", broken.UUID)
+	assert.NoError(t, err)
+	assert.NotNil(t, removed)
+
+	// replaying puts the value back on its queue
+	replayed, err := ReplayDeadLetter(conn, "msgs", letter.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, letter.UUID, replayed.UUID)
+
+	_, value, err = PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, value)
+
+	// discarding removes it
+	removed, err = RemoveDeadLetter(conn, "msgs", letters[0].UUID)
+	assert.NoError(t, err)
+	assert.NotNil(t, removed)
+
+	removed, err = RemoveDeadLetter(conn, "msgs", letters[0].UUID)
+	assert.NoError(t, err)
+	assert.Nil(t, removed)
+
+	letters, err = GetDeadLetters(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(letters))
+}
+
+func TestLeases(t *testing.T) {
+	pool := getPool()
+	conn := pool.Get()
+	defer conn.Close()
+
+	for i := 0; i < 3; i++ {
+		err := PushOntoQueue(conn, "msgs", "chan1", 0, fmt.Sprintf(`[{"id":%d}]`, i), HighPriority)
+		assert.NoError(t, err)
+	}
+
+	// pop two values, each of which should get its own lease
+	token1, _, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	token2, _, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.NotEqual(t, token1, token2)
+	assert.Equal(t, "msgs:chan1|0", token1.Queue())
+
+	counts, err := GetLeaseCounts(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"msgs:chan1|0": 2}, counts)
+
+	workers, err := redis.Int(conn.Do("ZSCORE", "msgs:active", "msgs:chan1|0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, workers)
+
+	// completing releases the lease and the worker
+	assert.NoError(t, MarkComplete(conn, "msgs", token1))
+
+	workers, err = redis.Int(conn.Do("ZSCORE", "msgs:active", "msgs:chan1|0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, workers)
+
+	// nothing has expired yet so nothing to reclaim
+	reclaimed, corrected, err := ReclaimExpiredLeases(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reclaimed)
+	assert.Equal(t, 0, corrected)
+
+	// expire our remaining lease as if our worker died mid-send
+	_, err = conn.Do("ZADD", leaseKey("msgs"), 0, token2)
+	assert.NoError(t, err)
+
+	reclaimed, corrected, err = ReclaimExpiredLeases(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reclaimed)
+	assert.Equal(t, 0, corrected)
+
+	workers, err = redis.Int(conn.Do("ZSCORE", "msgs:active", "msgs:chan1|0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, workers)
+
+	// completing a reclaimed lease doesn't release the worker again
+	token3, _, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.NoError(t, MarkComplete(conn, "msgs", token2))
+
+	workers, err = redis.Int(conn.Do("ZSCORE", "msgs:active", "msgs:chan1|0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, workers)
+
+	// a leaked worker without any lease gets corrected
+	_, err = conn.Do("ZINCRBY", "msgs:active", 3, "msgs:chan1|0")
+	assert.NoError(t, err)
+
+	reclaimed, corrected, err = ReclaimExpiredLeases(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reclaimed)
+	assert.Equal(t, 1, corrected)
+
+	workers, err = redis.Int(conn.Do("ZSCORE", "msgs:active", "msgs:chan1|0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, workers)
+
+	assert.NoError(t, MarkComplete(conn, "msgs", token3))
+}
+
+func TestPushOntoQueueAt(t *testing.T) {
+	pool := getPool()
+	conn := pool.Get()
+	defer conn.Close()
+
+	err := PushOntoQueueAt(conn, "msgs", "chan1", 10, `[{"id":1}]`, HighPriority, time.Now().Add(time.Second*2))
+	assert.NoError(t, err)
+	err = PushOntoQueue(conn, "msgs", "chan1", 10, `[{"id":2}]`, HighPriority)
+	assert.NoError(t, err)
+
+	// our scheduled value isn't popped until its time
+	token, value, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":2}`, value)
+	assert.NoError(t, MarkComplete(conn, "msgs", token))
+
+	token = Retry
+	for token == Retry {
+		token, value, err = PopFromQueue(conn, "msgs")
+	}
+	assert.NoError(t, err)
+	assert.Equal(t, EmptyQueue, token)
+
+	// pushing it back onto the queue of a token works the same way
+	time.Sleep(time.Second * 3)
+	conn.Do("ZINCRBY", "msgs:active", 0, "msgs:chan1|10")
+
+	token, value, err = PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, value)
+
+	err = PushBack(conn, "msgs", token, value, HighPriority, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, MarkComplete(conn, "msgs", token))
+
+	count, err := redis.Int(conn.Do("ZCOUNT", "msgs:chan1|10/1", time.Now().Add(time.Minute).Unix(), "+inf"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestScheduling(t *testing.T) {
+	pool := getPool()
+	conn := pool.Get()
+	defer conn.Close()
+	defer func() { Scheduling = Scheduler{} }()
+
+	popAll := func(count int) []string {
+		values := make([]string, 0, count)
+		for len(values) < count {
+			token, value, err := PopFromQueue(conn, "msgs")
+			assert.NoError(t, err)
+			if token == Retry {
+				continue
+			}
+			if !assert.NotEqual(t, EmptyQueue, token) {
+				break
+			}
+			values = append(values, value)
+			assert.NoError(t, MarkComplete(conn, "msgs", token))
+		}
+		return values
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, PushOntoQueue(conn, "msgs", "chan1", 0, fmt.Sprintf(`[{"id":%d,"p":"high"}]`, i), HighPriority))
+		assert.NoError(t, PushOntoQueue(conn, "msgs", "chan1", 0, fmt.Sprintf(`[{"id":%d,"p":"bulk"}]`, i), LowPriority))
+	}
+
+	// by default high priority is always popped first
+	for _, value := range popAll(10) {
+		assert.Contains(t, value, "high")
+	}
+
+	// weighted 10:1, bulk gets one in every eleven pops
+	Scheduling = Scheduler{HighPriorityWeight: 10, BulkWeight: 1}
+	bulk := 0
+	for _, value := range popAll(66) {
+		if strings.Contains(value, "bulk") {
+			bulk++
+		}
+	}
+	assert.InDelta(t, 6, bulk, 1)
+	assert.Equal(t, "least busy queue, high:bulk weighted 10:1", Scheduling.String())
+
+	// bulk which has waited too long goes next even when high priority always goes first
+	conn.Do("FLUSHDB")
+	Scheduling = Scheduler{BulkMaxWait: time.Minute}
+	assert.NoError(t, PushOntoQueue(conn, "msgs", "chan1", 0, `[{"id":1,"p":"high"}]`, HighPriority))
+	assert.NoError(t, PushOntoQueueAt(conn, "msgs", "chan1", 0, `[{"id":2,"p":"bulk"}]`, LowPriority, time.Now().Add(-time.Minute*2)))
+	assert.NoError(t, PushOntoQueue(conn, "msgs", "chan1", 0, `[{"id":3,"p":"bulk"}]`, LowPriority))
+	assert.Equal(t, []string{`{"id":2,"p":"bulk"}`, `{"id":1,"p":"high"}`, `{"id":3,"p":"bulk"}`}, popAll(3))
+
+	// load test sharing senders between orgs, org1 has 10 channels, org2 has 2 and org3 has 1, each with lots of bulk
+	conn.Do("FLUSHDB")
+	Scheduling = Scheduler{OrgSample: 20}
+	channelOrgs := make(map[string]string)
+	for c := 0; c < 13; c++ {
+		org := "org1"
+		if c >= 10 {
+			org = "org2"
+		}
+		if c == 12 {
+			org = "org3"
+		}
+		channel := fmt.Sprintf("chan%02d", c)
+		channelOrgs[channel] = org
+		assert.NoError(t, SetQueueOrg(conn, "msgs", fmt.Sprintf("msgs:%s|0", channel), org, 1))
+
+		for i := 0; i < 500; i++ {
+			assert.NoError(t, PushOntoQueue(conn, "msgs", channel, 0, fmt.Sprintf(`[{"id":%d}]`, i), LowPriority))
+		}
+	}
+
+	// simulate 30 senders, each completing its oldest send before popping another
+	sent := make(map[string]int)
+	inFlight := make([]WorkerToken, 0, 30)
+	for i := 0; i < 1200; i++ {
+		if len(inFlight) == 30 {
+			assert.NoError(t, MarkComplete(conn, "msgs", inFlight[0]))
+			inFlight = inFlight[1:]
+		}
+		token, _, err := PopFromQueue(conn, "msgs")
+		assert.NoError(t, err)
+		for token == Retry {
+			token, _, err = PopFromQueue(conn, "msgs")
+		}
+		assert.NotEqual(t, EmptyQueue, token)
+
+		channel := strings.Split(strings.TrimPrefix(token.Queue(), "msgs:"), "|")[0]
+		sent[channelOrgs[channel]]++
+		inFlight = append(inFlight, token)
+	}
+
+	// each org gets about a third of sends, rather than by how many channels they have
+	for _, org := range []string{"org1", "org2", "org3"} {
+		assert.InDelta(t, 400, sent[org], 40, "unfair share for %s", org)
+	}
+
+	loads, err := GetOrgLoads(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(loads))
+	for _, load := range loads {
+		assert.InDelta(t, 10, load.Workers, 1)
+	}
+
+	// and weights shift that share
+	conn.Do("FLUSHDB")
+	assert.NoError(t, SetQueueOrg(conn, "msgs", "msgs:chan1|0", "org1", 2))
+	assert.NoError(t, SetQueueOrg(conn, "msgs", "msgs:chan2|0", "org1", 2))
+	assert.NoError(t, SetQueueOrg(conn, "msgs", "msgs:chan3|0", "org2", 1))
+	for _, channel := range []string{"chan1", "chan2", "chan3"} {
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, PushOntoQueue(conn, "msgs", channel, 0, fmt.Sprintf(`[{"id":%d}]`, i), LowPriority))
+		}
+	}
+	sent = make(map[string]int)
+	for i := 0; i < 30; i++ {
+		token, _, err := PopFromQueue(conn, "msgs")
+		assert.NoError(t, err)
+		sent[token.Queue()]++
+	}
+	assert.InDelta(t, 10, sent["msgs:chan3|0"], 1)
+	assert.Equal(t, "fair between orgs of 20 least busy queues, high priority first", Scheduling.String())
+}
+
+func TestPruneQueueOrgs(t *testing.T) {
+	pool := getPool()
+	conn := pool.Get()
+	defer conn.Close()
+
+	assert.NoError(t, SetQueueOrg(conn, "msgs", "msgs:chan1|0", "org1", 2))
+	assert.NoError(t, SetQueueOrg(conn, "msgs", "msgs:chan2|0", "org2", 1))
+	assert.NoError(t, PushOntoQueue(conn, "msgs", "chan1", 0, `[{"id":1}]`, HighPriority))
+	assert.NoError(t, PushOntoQueue(conn, "msgs", "chan2", 0, `[{"id":2}]`, HighPriority))
+
+	// nothing is pruned while both queues have values
+	pruned, err := PruneQueueOrgs(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	// empty the queue of chan2
+	token, _, err := PopFromQueue(conn, "msgs")
+	assert.NoError(t, err)
+	for token.Queue() != "msgs:chan2|0" {
+		token, _, err = PopFromQueue(conn, "msgs")
+		assert.NoError(t, err)
+	}
+
+	// it's still active while its value is being sent
+	pruned, err = PruneQueueOrgs(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+
+	// as a pop would once it finds the queue empty
+	assert.NoError(t, MarkComplete(conn, "msgs", token))
+	conn.Do("ZREM", "msgs:active", "msgs:chan2|0")
+
+	// once it's gone so is its org, and that org's weight
+	pruned, err = PruneQueueOrgs(conn, "msgs")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	queueOrgs, err := redis.StringMap(conn.Do("HGETALL", "msgs:queue_orgs"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"msgs:chan1|0": "org1"}, queueOrgs)
+
+	orgWeights, err := redis.StringMap(conn.Do("HGETALL", "msgs:org_weights"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"org1": "2"}, orgWeights)
+}
+
+func TestScriptKeys(t *testing.T) {
+	utils.SetRedisKeyPrefix("test", true)
+	defer utils.SetRedisKeyPrefix("", false)
+
+	rec := &utils.RedisRecorder{}
+	PushOntoQueue(rec, "msgs", "chan1", 10, `[{"id":1}]`, HighPriority)
+	PopFromQueue(rec, "msgs")
+	MarkComplete(rec, "msgs", WorkerToken("{test}:msgs:chan1|10#1234"))
+	GetAllChannelQueues(rec, "chan1")
+	ReclaimExpiredLeases(rec, "msgs")
+	PushDeadLetter(rec, "msgs", &DeadLetter{UUID: "5c7e0a0d-c0ee-4c4a-9f6c-bcbb5a5e6bd6"})
+	RemoveDeadLetter(rec, "msgs", "5c7e0a0d-c0ee-4c4a-9f6c-bcbb5a5e6bd6")
+	PruneQueueOrgs(rec, "msgs")
+
+	// in a cluster every key a script is passed must be in the same slot as the rest of ours
+	assert.Equal(t, 8, len(rec.Keys))
+	for _, keys := range rec.Keys {
+		for _, key := range keys {
+			assert.True(t, strings.HasPrefix(key, "{test}:"), "key %s isn't prefixed", key)
+		}
+	}
+}
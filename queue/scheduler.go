@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/nyaruka/courier/utils"
+)
+
+// Scheduler configures how PopFromQueue chooses which queue, and which priority of that queue, to pop from next
+type Scheduler struct {
+	// OrgSample is how many of the least busy queues are compared by the weighted number of workers of their orgs, so
+	// that an org with many busy channels can't crowd out others. 1 or less means orgs aren't considered.
+	OrgSample int
+
+	// HighPriorityWeight and BulkWeight are the shares of pops each priority gets when a queue has both, a high
+	// priority weight of 0 means bulk is only popped when there is no high priority
+	HighPriorityWeight int
+	BulkWeight         int
+
+	// BulkMaxWait is how long a bulk value can wait before it is popped ahead of high priority, 0 means forever
+	BulkMaxWait time.Duration
+}
+
+// Scheduling is the scheduler used by PopFromQueue, by default the least busy queue is popped from and its high
+// priority values are always popped first
+var Scheduling = Scheduler{}
+
+// String returns a description of this scheduler for display
+func (s Scheduler) String() string {
+	desc := "least busy queue"
+	if s.OrgSample > 1 {
+		desc = fmt.Sprintf("fair between orgs of %d least busy queues", s.OrgSample)
+	}
+	if s.HighPriorityWeight > 0 {
+		desc += fmt.Sprintf(", high:bulk weighted %d:%d", s.HighPriorityWeight, s.bulkWeight())
+	} else {
+		desc += ", high priority first"
+	}
+	if s.BulkMaxWait > 0 {
+		desc += fmt.Sprintf(", bulk waiting over %s goes next", s.BulkMaxWait)
+	}
+	return desc
+}
+
+func (s Scheduler) bulkWeight() int {
+	if s.BulkWeight < 1 {
+		return 1
+	}
+	return s.BulkWeight
+}
+
+// luaPickFairQueue is a Lua function which picks from candidate queues (name, workers pairs in order of workers) the
+// least busy queue of the org with the fewest workers for its weight
+const luaPickFairQueue = `
+local function pickFairQueue(qType, candidates)
+	local names = {}
+	for i=1,#candidates,2 do
+		names[#names+1] = candidates[i]
+	end
+	local orgs = redis.call("hmget", qType .. ":queue_orgs", unpack(names))
+
+	-- total up the workers of each org, queues we don't know the org of are lumped together
+	local loads = {}
+	local uniqueOrgs = {}
+	for i=1,#names do
+		local org = orgs[i] or ""
+		orgs[i] = org
+		if not loads[org] then
+			loads[org] = 0
+			uniqueOrgs[#uniqueOrgs+1] = org
+		end
+		loads[org] = loads[org] + tonumber(candidates[i*2])
+	end
+
+	-- and divide them by the weight of each org
+	local weights = redis.call("hmget", qType .. ":org_weights", unpack(uniqueOrgs))
+	for i=1,#uniqueOrgs do
+		local weight = tonumber(weights[i]) or 1
+		if weight <= 0 then
+			weight = 1
+		end
+		loads[uniqueOrgs[i]] = loads[uniqueOrgs[i]] / weight
+	end
+
+	-- candidates are ordered by workers, so the first we see of an org is its least busy queue
+	local best = 1
+	for i=2,#names do
+		if loads[orgs[i]] < loads[orgs[best]] then
+			best = i
+		end
+	end
+	return names[best], candidates[best*2]
+end
+`
+
+// SetQueueOrg records the org of the passed in queue, and the weight of that org when scheduling between orgs
+func SetQueueOrg(conn redis.Conn, qType string, queue string, org string, weight int) error {
+	conn.Send("HSET", utils.RedisKey("%s:queue_orgs", qType), queue, org)
+	conn.Send("HSET", utils.RedisKey("%s:org_weights", qType), org, weight)
+	_, err := conn.Do("")
+	return err
+}
+
+var luaPruneQueueOrgs = redis.NewScript(1, `-- KEYS: [QueueType]
+	local orgsKey = KEYS[1] .. ":queue_orgs"
+	local weightsKey = KEYS[1] .. ":org_weights"
+	local queueOrgs = redis.call("hgetall", orgsKey)
+	local orgs = {}
+	local pruned = 0
+
+	-- forget the org of any queue which is no longer active, throttled or waiting on future values
+	for i = 1, #queueOrgs, 2 do
+		local queue = queueOrgs[i]
+		local exists = redis.call("exists", queue .. "/1") == 1 or redis.call("exists", queue .. "/0") == 1
+		for _, set in ipairs({":active", ":throttled", ":future"}) do
+			exists = exists or redis.call("zscore", KEYS[1] .. set, queue)
+		end
+		if exists then
+			orgs[queueOrgs[i+1]] = true
+		else
+			redis.call("hdel", orgsKey, queue)
+			pruned = pruned + 1
+		end
+	end
+
+	-- and the weight of any org which no longer has queues
+	local weights = redis.call("hkeys", weightsKey)
+	for _, org in ipairs(weights) do
+		if not orgs[org] then
+			redis.call("hdel", weightsKey, org)
+		end
+	end
+
+	return pruned
+`)
+
+// PruneQueueOrgs removes the recorded orgs of queues which no longer exist, and the weights of orgs which no longer
+// have queues, returning the number of queues pruned
+func PruneQueueOrgs(conn redis.Conn, qType string) (int, error) {
+	return redis.Int(luaPruneQueueOrgs.Do(conn, utils.RedisKey(qType)))
+}
+
+// OrgLoad is the number of workers on the active queues of an org
+type OrgLoad struct {
+	Org     string
+	Weight  int
+	Queues  int
+	Workers int
+}
+
+// GetOrgLoads returns the load of each org with active queues, busiest first. Queues whose org isn't known are
+// grouped under an empty org.
+func GetOrgLoads(conn redis.Conn, qType string) ([]OrgLoad, error) {
+	active, err := redis.StringMap(conn.Do("ZRANGE", utils.RedisKey("%s:active", qType), 0, -1, "WITHSCORES"))
+	if err != nil {
+		return nil, err
+	}
+	queueOrgs, err := redis.StringMap(conn.Do("HGETALL", utils.RedisKey("%s:queue_orgs", qType)))
+	if err != nil {
+		return nil, err
+	}
+	orgWeights, err := redis.StringMap(conn.Do("HGETALL", utils.RedisKey("%s:org_weights", qType)))
+	if err != nil {
+		return nil, err
+	}
+
+	loads := make(map[string]*OrgLoad)
+	for queue, workers := range active {
+		org := queueOrgs[queue]
+		load := loads[org]
+		if load == nil {
+			weight, err := strconv.Atoi(orgWeights[org])
+			if err != nil || weight <= 0 {
+				weight = 1
+			}
+			load = &OrgLoad{Org: org, Weight: weight}
+			loads[org] = load
+		}
+		count, _ := strconv.ParseFloat(workers, 64)
+		load.Queues++
+		load.Workers += int(count)
+	}
+
+	sorted := make([]OrgLoad, 0, len(loads))
+	for _, load := range loads {
+		sorted = append(sorted, *load)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Workers != sorted[j].Workers {
+			return sorted[i].Workers > sorted[j].Workers
+		}
+		return sorted[i].Org < sorted[j].Org
+	})
+	return sorted, nil
+}
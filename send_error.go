@@ -0,0 +1,94 @@
+package courier
+
+import "fmt"
+
+// SendErrorCode is the standardized reason a message couldn't be sent, handlers map the errors of their providers to
+// these so that they can be reported and retried consistently across channel types
+type SendErrorCode string
+
+// the standardized reasons a message can fail to send
+const (
+	SendErrorAuth                SendErrorCode = "auth_failed"
+	SendErrorRateLimited         SendErrorCode = "rate_limited"
+	SendErrorInvalidRecipient    SendErrorCode = "invalid_recipient"
+	SendErrorOptedOut            SendErrorCode = "contact_opted_out"
+	SendErrorWindowClosed        SendErrorCode = "window_closed"
+	SendErrorTemplate            SendErrorCode = "template_invalid"
+	SendErrorMedia               SendErrorCode = "media_invalid"
+	SendErrorContent             SendErrorCode = "content_rejected"
+	SendErrorProviderUnavailable SendErrorCode = "provider_unavailable"
+	SendErrorConnection          SendErrorCode = "connection_failed"
+	SendErrorUnknown             SendErrorCode = "unknown"
+	NilSendErrorCode             SendErrorCode = ""
+)
+
+// Retryable returns whether a message which failed to send with this error might succeed if we try it again, errors
+// which are the fault of the message, its contact or our credentials fail the message straight away
+func (c SendErrorCode) Retryable() bool {
+	switch c {
+	case SendErrorRateLimited, SendErrorProviderUnavailable, SendErrorConnection, SendErrorUnknown, NilSendErrorCode:
+		return true
+	default:
+		return false
+	}
+}
+
+// SendError is returned by handlers when they've been able to map a provider error to a send error code
+type SendError struct {
+	Code    SendErrorCode
+	Message string
+}
+
+// NewSendError creates a new send error with the passed in code and message
+func NewSendError(code SendErrorCode, format string, args ...interface{}) *SendError {
+	return &SendError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// SendErrorCodeForError returns the send error code of the passed in error if it has been classified, template errors
+// always being template_invalid
+func SendErrorCodeForError(err error) SendErrorCode {
+	switch e := err.(type) {
+	case *SendError:
+		return e.Code
+	case *TemplateError:
+		return SendErrorTemplate
+	default:
+		return NilSendErrorCode
+	}
+}
+
+// SendErrorCodeForStatusCode returns the send error code implied by the HTTP status code of a provider response
+func SendErrorCodeForStatusCode(statusCode int) SendErrorCode {
+	switch {
+	case statusCode == 0:
+		return SendErrorConnection
+	case statusCode == 401 || statusCode == 403:
+		return SendErrorAuth
+	case statusCode == 429:
+		return SendErrorRateLimited
+	case statusCode >= 500:
+		return SendErrorProviderUnavailable
+	default:
+		return SendErrorUnknown
+	}
+}
+
+// SendErrorCodeForLogs infers the send error code of a message which a handler didn't classify from the last errored
+// request in the passed in logs
+func SendErrorCodeForLogs(logs []*ChannelLog) SendErrorCode {
+	for i := len(logs) - 1; i >= 0; i-- {
+		log := logs[i]
+		if log.Error == "" {
+			continue
+		}
+		if log.StatusCode == NilStatusCode {
+			return SendErrorUnknown
+		}
+		return SendErrorCodeForStatusCode(log.StatusCode)
+	}
+	return SendErrorUnknown
+}
@@ -0,0 +1,42 @@
+package courier
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendErrorCodes(t *testing.T) {
+	assert.True(t, SendErrorRateLimited.Retryable())
+	assert.True(t, SendErrorProviderUnavailable.Retryable())
+	assert.True(t, SendErrorConnection.Retryable())
+	assert.True(t, SendErrorUnknown.Retryable())
+	assert.False(t, SendErrorAuth.Retryable())
+	assert.False(t, SendErrorOptedOut.Retryable())
+	assert.False(t, SendErrorInvalidRecipient.Retryable())
+	assert.False(t, SendErrorTemplate.Retryable())
+
+	err := NewSendError(SendErrorOptedOut, "contact %s has blocked us", "12345")
+	assert.EqualError(t, err, "contact_opted_out: contact 12345 has blocked us")
+	assert.Equal(t, SendErrorOptedOut, SendErrorCodeForError(err))
+	assert.Equal(t, SendErrorTemplate, SendErrorCodeForError(NewTemplateError(TemplateUnsupportedLanguage, "no mapping for kin")))
+	assert.Equal(t, NilSendErrorCode, SendErrorCodeForError(errors.New("boom")))
+	assert.Equal(t, NilSendErrorCode, SendErrorCodeForError(nil))
+
+	assert.Equal(t, SendErrorConnection, SendErrorCodeForStatusCode(0))
+	assert.Equal(t, SendErrorAuth, SendErrorCodeForStatusCode(401))
+	assert.Equal(t, SendErrorRateLimited, SendErrorCodeForStatusCode(429))
+	assert.Equal(t, SendErrorProviderUnavailable, SendErrorCodeForStatusCode(503))
+	assert.Equal(t, SendErrorUnknown, SendErrorCodeForStatusCode(400))
+
+	// codes are inferred from the last errored request
+	logs := []*ChannelLog{
+		{StatusCode: 503, Error: "received non 200 status: 503"},
+		{StatusCode: 429, Error: "received non 200 status: 429"},
+		{StatusCode: 200},
+	}
+	assert.Equal(t, SendErrorRateLimited, SendErrorCodeForLogs(logs))
+	assert.Equal(t, SendErrorUnknown, SendErrorCodeForLogs([]*ChannelLog{{StatusCode: NilStatusCode, Error: "missing token"}}))
+	assert.Equal(t, SendErrorUnknown, SendErrorCodeForLogs(nil))
+}
@@ -0,0 +1,63 @@
+package courier
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// MetadataSendAt is the key in outgoing message metadata which holds the time the message should be sent at
+const MetadataSendAt = "send_at"
+
+// MetadataNotBefore is the key in outgoing message metadata which holds the earliest time the message can be sent,
+// it is the same as send_at which takes precedence if both are set
+const MetadataNotBefore = "not_before"
+
+// MetadataNotAfter is the key in outgoing message metadata which holds the latest time the message can be sent,
+// messages which haven't been sent by then are failed rather than sent late
+const MetadataNotAfter = "not_after"
+
+// SendWindow is when an outgoing message can be sent, either end may be zero meaning it is open
+type SendWindow struct {
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// ParseSendWindow parses the send window out of the passed in outgoing message metadata, times must be RFC3339 and
+// any which are missing or invalid are ignored
+func ParseSendWindow(metadata json.RawMessage) SendWindow {
+	window := SendWindow{}
+	if len(metadata) == 0 {
+		return window
+	}
+
+	window.NotBefore = parseMetadataTime(metadata, MetadataSendAt)
+	if window.NotBefore.IsZero() {
+		window.NotBefore = parseMetadataTime(metadata, MetadataNotBefore)
+	}
+	window.NotAfter = parseMetadataTime(metadata, MetadataNotAfter)
+	return window
+}
+
+func parseMetadataTime(metadata json.RawMessage, key string) time.Time {
+	value, err := jsonparser.GetString(metadata, key)
+	if err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// IsEarly returns whether it is too soon to send at the passed in time
+func (w SendWindow) IsEarly(now time.Time) bool {
+	return !w.NotBefore.IsZero() && now.Before(w.NotBefore)
+}
+
+// IsExpired returns whether it is too late to send at the passed in time
+func (w SendWindow) IsExpired(now time.Time) bool {
+	return !w.NotAfter.IsZero() && now.After(w.NotAfter)
+}
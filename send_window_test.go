@@ -0,0 +1,37 @@
+package courier
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSendWindow(t *testing.T) {
+	now := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	window := ParseSendWindow(nil)
+	assert.Equal(t, SendWindow{}, window)
+	assert.False(t, window.IsEarly(now))
+	assert.False(t, window.IsExpired(now))
+
+	// invalid times are ignored
+	assert.Equal(t, SendWindow{}, ParseSendWindow(json.RawMessage(`{"send_at":"tomorrow","not_after":12}`)))
+
+	window = ParseSendWindow(json.RawMessage(`{"send_at":"2022-03-04T13:00:00Z"}`))
+	assert.Equal(t, time.Date(2022, 3, 4, 13, 0, 0, 0, time.UTC), window.NotBefore.UTC())
+	assert.True(t, window.IsEarly(now))
+	assert.False(t, window.IsEarly(now.Add(time.Hour)))
+	assert.False(t, window.IsExpired(now.Add(time.Hour*24)))
+
+	// send_at takes precedence over not_before
+	window = ParseSendWindow(json.RawMessage(`{"send_at":"2022-03-04T13:00:00Z","not_before":"2022-03-04T14:00:00Z","not_after":"2022-03-04T15:30:00+02:00"}`))
+	assert.Equal(t, time.Date(2022, 3, 4, 13, 0, 0, 0, time.UTC), window.NotBefore.UTC())
+	assert.Equal(t, time.Date(2022, 3, 4, 13, 30, 0, 0, time.UTC), window.NotAfter.UTC())
+	assert.False(t, window.IsExpired(now))
+	assert.True(t, window.IsExpired(now.Add(time.Minute*91)))
+
+	window = ParseSendWindow(json.RawMessage(`{"not_before":"2022-03-04T11:00:00Z"}`))
+	assert.False(t, window.IsEarly(now))
+}
@@ -194,8 +194,25 @@ func (w *Sender) sendMessage(msg Msg) {
 		status = backend.NewMsgStatusForID(msg.Channel(), msg.ID(), MsgWired)
 		log.Warning("duplicate send, marking as wired")
 	} else {
+		// adapt our message to what its channel can send, failing it if that isn't possible
+		toSend, changes, adaptErr := msg, []string(nil), error(nil)
+		if caps := GetCapabilities(msg.Channel().ChannelType()); caps != nil {
+			toSend, changes, adaptErr = AdaptMsg(sendCTX, caps, msg)
+		}
+
 		// send our message
-		status, err = server.SendMsg(sendCTX, msg)
+		if adaptErr != nil {
+			log.WithError(adaptErr).Warning("msg rejected by channel capabilities")
+			status = backend.NewMsgStatusForID(msg.Channel(), msg.ID(), MsgFailed)
+			status.SetErrorCode(SendErrorCodeForError(adaptErr))
+			status.AddLog(NewChannelLogFromError("Message Rejected", msg.Channel(), msg.ID(), time.Now().Sub(start), adaptErr))
+			err = nil
+		} else {
+			status, err = server.SendMsg(sendCTX, toSend)
+			if status != nil && len(changes) > 0 {
+				status.AddLog(NewChannelLogForAdaptation(msg, changes))
+			}
+		}
 		duration := time.Now().Sub(start)
 		secondDuration := float64(duration) / float64(time.Second)
 
@@ -203,14 +220,26 @@ func (w *Sender) sendMessage(msg Msg) {
 			log.WithError(err).WithField("elapsed", duration).Error("error sending message")
 			if status == nil {
 				status = backend.NewMsgStatusForID(msg.Channel(), msg.ID(), MsgErrored)
+				status.SetErrorCode(SendErrorCodeForError(err))
 				status.AddLog(NewChannelLogFromError("Sending Error", msg.Channel(), msg.ID(), duration, err))
 			}
 		}
 
+		// classify errors the handler didn't, and fail messages whose errors aren't worth retrying
+		if status.Status() == MsgErrored || status.Status() == MsgFailed {
+			if status.ErrorCode() == NilSendErrorCode {
+				status.SetErrorCode(SendErrorCodeForLogs(status.Logs()))
+			}
+			if status.Status() == MsgErrored && !status.ErrorCode().Retryable() {
+				status.SetStatus(MsgFailed)
+			}
+		}
+
 		// report to librato and log locally
 		if status.Status() == MsgErrored || status.Status() == MsgFailed {
-			log.WithField("elapsed", duration).Warning("msg errored")
+			log.WithField("elapsed", duration).WithField("error_code", status.ErrorCode()).Warning("msg errored")
 			analytics.Gauge(fmt.Sprintf("courier.msg_send_error_%s", msg.Channel().ChannelType()), secondDuration)
+			analytics.Gauge(fmt.Sprintf("courier.msg_send_error_%s_%s", msg.Channel().ChannelType(), status.ErrorCode()), secondDuration)
 		} else {
 			log.WithField("elapsed", duration).Info("msg sent")
 			analytics.Gauge(fmt.Sprintf("courier.msg_send_%s", msg.Channel().ChannelType()), secondDuration)
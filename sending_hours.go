@@ -0,0 +1,183 @@
+package courier
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// SendingHours are the windows on each day of the week in which bulk messages can be sent, in a particular timezone
+type SendingHours struct {
+	Location *time.Location
+	days     [7][]sendingWindow
+}
+
+// a window of minutes since midnight, the end being exclusive
+type sendingWindow struct {
+	start int
+	end   int
+}
+
+var sendingHoursWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSendingHours parses sending hours from the passed in config value, an object of weekdays (mon, tue...) to lists
+// of windows like "08:00-17:30". The windows of the default key are used for days which aren't listed, and days with
+// neither have no windows so are held all day.
+func ParseSendingHours(config interface{}, location *time.Location) (*SendingHours, error) {
+	days := make(map[string][]string)
+	encoded, _ := json.Marshal(config)
+	if err := json.Unmarshal(encoded, &days); err != nil {
+		return nil, fmt.Errorf("sending hours must be an object of weekdays to lists of windows")
+	}
+
+	hours := &SendingHours{Location: location}
+
+	defaults, err := parseSendingWindows(days["default"])
+	if err != nil {
+		return nil, err
+	}
+	for day := range hours.days {
+		hours.days[day] = defaults
+	}
+
+	for key, windows := range days {
+		if key == "default" {
+			continue
+		}
+		weekday, found := sendingHoursWeekdays[strings.ToLower(key)]
+		if !found {
+			return nil, fmt.Errorf("unknown weekday '%s' in sending hours", key)
+		}
+		hours.days[weekday], err = parseSendingWindows(windows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hours, nil
+}
+
+func parseSendingWindows(windows []string) ([]sendingWindow, error) {
+	parsed := make([]sendingWindow, 0, len(windows))
+	for _, window := range windows {
+		parts := strings.Split(window, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid sending window '%s', must be like 08:00-17:30", window)
+		}
+		start, err := parseSendingMinute(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseSendingMinute(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("invalid sending window '%s', must end after it starts on the same day", window)
+		}
+		parsed = append(parsed, sendingWindow{start: start, end: end})
+	}
+
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].start < parsed[j].start })
+	return parsed, nil
+}
+
+func parseSendingMinute(value string) (int, error) {
+	var hour, minute int
+	_, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hour, &minute)
+	if err != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute > 0) {
+		return 0, fmt.Errorf("invalid time '%s' in sending window", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// IsOpen returns whether the passed in time falls in one of our windows
+func (h *SendingHours) IsOpen(t time.Time) bool {
+	local := t.In(h.Location)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, window := range h.days[local.Weekday()] {
+		if minute >= window.start && minute < window.end {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the passed in time if one of our windows is open then, otherwise the time the next one opens. If we
+// have no windows at all, the zero time is returned.
+func (h *SendingHours) NextOpen(t time.Time) time.Time {
+	if h.IsOpen(t) {
+		return t
+	}
+
+	local := t.In(h.Location)
+	minute := local.Hour()*60 + local.Minute()
+
+	for d := 0; d <= 7; d++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+d, 0, 0, 0, 0, h.Location)
+		for _, window := range h.days[day.Weekday()] {
+			if d == 0 && window.start <= minute {
+				continue
+			}
+			return time.Date(day.Year(), day.Month(), day.Day(), window.start/60, window.start%60, 0, 0, h.Location)
+		}
+	}
+	return time.Time{}
+}
+
+// GetSendingHours returns the bulk sending hours of the passed in channel, which are set on the channel or its org, or
+// nil if it doesn't have any
+func GetSendingHours(channel Channel) (*SendingHours, error) {
+	config := channel.ConfigForKey(ConfigBulkSendingHours, nil)
+	if config == nil {
+		config = channel.OrgConfigForKey(ConfigBulkSendingHours, nil)
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	location, err := sendingHoursLocation(channel)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSendingHours(config, location)
+}
+
+// sendingHoursLocation returns the configured timezone of the passed in channel's sending hours, or failing that the
+// timezone of its number or country
+func sendingHoursLocation(channel Channel) (*time.Location, error) {
+	timezone := channel.StringConfigForKey(ConfigBulkSendingTimezone, "")
+	if timezone == "" {
+		timezone, _ = channel.OrgConfigForKey(ConfigBulkSendingTimezone, "").(string)
+	}
+	if timezone != "" {
+		return time.LoadLocation(timezone)
+	}
+
+	if channel.Country() != "" {
+		number, err := phonenumbers.Parse(channel.Address(), channel.Country())
+		if err != nil || !phonenumbers.IsValidNumber(number) {
+			number = phonenumbers.GetExampleNumber(channel.Country())
+		}
+		if number != nil {
+			timezones, err := phonenumbers.GetTimezonesForNumber(number)
+			if err == nil && len(timezones) > 0 && timezones[0] != phonenumbers.UNKNOWN_TIMEZONE {
+				return time.LoadLocation(timezones[0])
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unable to determine timezone of sending hours, set %s", ConfigBulkSendingTimezone)
+}
@@ -0,0 +1,94 @@
+package courier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendingHours(t *testing.T) {
+	kigali, _ := time.LoadLocation("Africa/Kigali")
+
+	_, err := ParseSendingHours("9 to 5", kigali)
+	assert.EqualError(t, err, "sending hours must be an object of weekdays to lists of windows")
+
+	_, err = ParseSendingHours(map[string]interface{}{"someday": []string{"08:00-17:00"}}, kigali)
+	assert.EqualError(t, err, "unknown weekday 'someday' in sending hours")
+
+	_, err = ParseSendingHours(map[string]interface{}{"mon": []string{"22:00-02:00"}}, kigali)
+	assert.EqualError(t, err, "invalid sending window '22:00-02:00', must end after it starts on the same day")
+
+	_, err = ParseSendingHours(map[string]interface{}{"mon": []string{"08:00-25:00"}}, kigali)
+	assert.EqualError(t, err, "invalid time '25:00' in sending window")
+
+	// weekdays from 8 to 8 with a break at lunch, and a shorter saturday, nothing on sunday
+	hours, err := ParseSendingHours(map[string]interface{}{
+		"default": []interface{}{"13:00-20:00", "08:00-12:00"},
+		"sat":     []interface{}{"10:00-14:00"},
+		"sun":     []interface{}{},
+	}, kigali)
+	assert.NoError(t, err)
+
+	// 2022-03-04 is a friday
+	at := func(day, hour, minute int) time.Time { return time.Date(2022, 3, day, hour, minute, 0, 0, kigali) }
+
+	assert.False(t, hours.IsOpen(at(4, 7, 59)))
+	assert.True(t, hours.IsOpen(at(4, 8, 0)))
+	assert.False(t, hours.IsOpen(at(4, 12, 30)))
+	assert.True(t, hours.IsOpen(at(4, 19, 59)))
+	assert.False(t, hours.IsOpen(at(4, 20, 0)))
+	assert.True(t, hours.IsOpen(at(5, 11, 0)))
+	assert.False(t, hours.IsOpen(at(6, 11, 0)))
+
+	// times are converted to our timezone
+	assert.True(t, hours.IsOpen(time.Date(2022, 3, 4, 6, 30, 0, 0, time.UTC)))
+
+	assert.Equal(t, at(4, 9, 0), hours.NextOpen(at(4, 9, 0)))
+	assert.Equal(t, at(4, 13, 0), hours.NextOpen(at(4, 12, 30)))
+	assert.Equal(t, at(5, 10, 0), hours.NextOpen(at(4, 21, 0)))
+	assert.Equal(t, at(7, 8, 0), hours.NextOpen(at(5, 15, 0)))
+
+	closed, err := ParseSendingHours(map[string]interface{}{}, kigali)
+	assert.NoError(t, err)
+	assert.True(t, closed.NextOpen(at(4, 9, 0)).IsZero())
+}
+
+func TestGetSendingHours(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "+250788383383", "RW", map[string]interface{}{})
+
+	hours, err := GetSendingHours(channel)
+	assert.NoError(t, err)
+	assert.Nil(t, hours)
+
+	// hours can be set on the org, and the timezone comes from the channel's number
+	channel.SetOrgConfig(ConfigBulkSendingHours, map[string]interface{}{"default": []interface{}{"08:00-20:00"}})
+
+	hours, err = GetSendingHours(channel)
+	assert.NoError(t, err)
+	assert.Equal(t, "Africa/Kigali", hours.Location.String())
+
+	// or from its country if it doesn't have a number
+	channel = NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "courierbot", "EC", map[string]interface{}{
+		ConfigBulkSendingHours: map[string]interface{}{"default": []interface{}{"08:00-20:00"}},
+	})
+
+	hours, err = GetSendingHours(channel)
+	assert.NoError(t, err)
+	assert.Equal(t, "America/Guayaquil", hours.Location.String())
+
+	// a configured timezone takes precedence
+	channel.SetConfig(ConfigBulkSendingTimezone, "Europe/Paris")
+
+	hours, err = GetSendingHours(channel)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", hours.Location.String())
+
+	// and without one or a country we can't tell when to send
+	channel = NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "DM", "courierbot", "", map[string]interface{}{
+		ConfigBulkSendingHours: map[string]interface{}{"default": []interface{}{"08:00-20:00"}},
+	})
+
+	_, err = GetSendingHours(channel)
+	assert.EqualError(t, err, "unable to determine timezone of sending hours, set bulk_sending_timezone")
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/nyaruka/courier/utils"
 	"github.com/nyaruka/gocommon/analytics"
+	"github.com/nyaruka/gocommon/urns"
 	"github.com/sirupsen/logrus"
 )
 
@@ -109,15 +110,31 @@ func (s *server) Start() error {
 	s.router.MethodNotAllowed(s.handle405)
 	s.router.Get("/", s.handleIndex)
 	s.router.Get("/status", s.handleStatus)
+	s.router.Get("/flood/blocks", s.handleFloodBlocks)
+	s.router.Get("/dead_letters", s.handleDeadLetters)
 
+	// endpoints which change state are only available when they can be protected by our status credentials
+	if s.config.StatusUsername != "" {
+		s.router.Delete("/flood/blocks/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", s.handleClearFloodBlock)
+		s.router.Post("/dead_letters/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/replay", s.handleReplayDeadLetter)
+		s.router.Delete("/dead_letters/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", s.handleDiscardDeadLetter)
+	}
+
+	// requested purges are processed by one instance at a time
 	p := NewPurgeHandler(s)
-	p.ResumePurges()
+	p.Start(s.stopChan, s.waitGroup)
 
 	s.router.Post("/purge/{type:[a-zA-Z]+}/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", p.PurgeChannel)
 
 	// initialize our handlers
 	s.initializeChannelHandlers()
 
+	// publish the capabilities of each channel type
+	s.chanRouter.Get("/{type:[a-zA-Z0-9]+}/capabilities", s.handleCapabilities)
+
+	// start polling any channels which receive that way
+	startPollers(s)
+
 	// configure timeouts on our server
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.config.Address, s.config.Port),
@@ -214,8 +231,8 @@ func (s *server) SendMsg(ctx context.Context, msg Msg) (MsgStatus, error) {
 		return nil, fmt.Errorf("unable to find handler for channel type: %s", msg.Channel().ChannelType())
 	}
 
-	// have the handler send it
-	return handler.SendMsg(ctx, msg)
+	// have the handler send it, falling back to text for any interactive content it can't render
+	return s.sendWithNumberedMenu(ctx, handler, withInteractiveFallback(handler, msg))
 }
 
 func (s *server) WaitGroup() *sync.WaitGroup { return s.waitGroup }
@@ -303,25 +320,39 @@ func (s *server) channelHandleWrapper(handler ChannelHandler, handlerFunc Channe
 
 		logs := make([]*ChannelLog, 0, 1)
 
+		// some requests, such as webhook verifications, aren't for a channel
+		var channelUUID ChannelUUID
+		if channel != nil {
+			channelUUID = channel.UUID()
+		}
+
 		defer func() {
 			// catch any panics and recover
 			panicLog := recover()
 			if panicLog != nil {
 				debug.PrintStack()
-				logrus.WithError(err).WithField("channel_uuid", channel.UUID()).WithField("url", url).WithField("request", string(request)).WithField("trace", panicLog).Error("panic handling request")
+				logrus.WithError(err).WithField("channel_uuid", channelUUID).WithField("url", url).WithField("request", string(request)).WithField("trace", panicLog).Error("panic handling request")
 				writeAndLogRequestError(ctx, ww, r, channel, errors.New("panic handling msg"))
 			}
 		}()
 
-		events, err := handlerFunc(ctx, channel, ww, r)
-		duration := time.Now().Sub(start)
-		secondDuration := float64(duration) / float64(time.Second)
+		// reject any requests which don't pass the verification configured on the channel before our handler sees them
+		var events []Event
+		if status, verr := verifyRequest(channel, r); verr != nil {
+			err = verr
+			LogRequestError(r, channel, err)
+			WriteDataResponse(ctx, ww, status, http.StatusText(status), []interface{}{NewErrorData(err.Error())})
+		} else {
+			events, err = handlerFunc(ctx, channel, ww, r)
 
-		// if we received an error, write it out and report it
-		if err != nil {
-			logrus.WithError(err).WithField("channel_uuid", channel.UUID()).WithField("url", url).WithField("request", string(request)).Error("error handling request")
-			writeAndLogRequestError(ctx, ww, r, channel, err)
+			// if we received an error, write it out and report it
+			if err != nil {
+				logrus.WithError(err).WithField("channel_uuid", channelUUID).WithField("url", url).WithField("request", string(request)).Error("error handling request")
+				writeAndLogRequestError(ctx, ww, r, channel, err)
+			}
 		}
+		duration := time.Now().Sub(start)
+		secondDuration := float64(duration) / float64(time.Second)
 
 		// if we have a channel matched but no events were created we still want to log this to the channel, do so
 		if channel != nil && len(events) == 0 {
@@ -420,16 +451,25 @@ func (s *server) handle405(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+// checkStatusAuth checks the passed in request has the credentials needed for our status and admin endpoints, writing
+// an unauthorized response if it doesn't
+func (s *server) checkStatusAuth(w http.ResponseWriter, r *http.Request) bool {
 	if s.config.StatusUsername != "" {
 		user, pass, ok := r.BasicAuth()
 		if !ok || user != s.config.StatusUsername || pass != s.config.StatusPassword {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Authenticate"`)
 			w.WriteHeader(401)
 			w.Write([]byte("Unauthorised.\n"))
-			return
+			return false
 		}
 	}
+	return true
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
 
 	var buf bytes.Buffer
 	buf.WriteString("<title>courier</title><body><pre>\n")
@@ -439,10 +479,154 @@ func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	buf.WriteString("\n\n")
 	buf.WriteString(s.backend.Status())
 	buf.WriteString("\n\n")
+	buf.WriteString(s.leaderStatus())
+	buf.WriteString("\n\n")
 	buf.WriteString("</pre></body>")
 	w.Write(buf.Bytes())
 }
 
+// leaderStatus describes who is leading each of the elections this instance takes part in
+func (s *server) leaderStatus() string {
+	rc := s.backend.RedisPool().Get()
+	defer rc.Close()
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Instance: %s\n", leaderIdentity))
+
+	for _, name := range getLeaderElectionNames() {
+		leader, err := GetLeader(rc, name)
+		if err != nil {
+			buf.WriteString(fmt.Sprintf("Leader of %s: unable to read leader: %v\n", name, err))
+			continue
+		}
+		if leader == "" {
+			leader = "none"
+		} else if leader == leaderIdentity {
+			leader += " (this instance)"
+		}
+		buf.WriteString(fmt.Sprintf("Leader of %s: %s\n", name, leader))
+	}
+	return buf.String()
+}
+
+func (s *server) handleFloodBlocks(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
+
+	rc := s.backend.RedisPool().Get()
+	defer rc.Close()
+
+	blocks, err := GetFloodBlocks(rc)
+	if err != nil {
+		logrus.WithError(err).Error("error fetching flood blocks")
+		WriteDataResponse(r.Context(), w, http.StatusInternalServerError, "Error fetching blocks", nil)
+		return
+	}
+
+	data := make([]interface{}, len(blocks))
+	for i := range blocks {
+		data[i] = blocks[i]
+	}
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", data)
+}
+
+func (s *server) handleClearFloodBlock(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
+
+	channelUUID, err := NewChannelUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		WriteDataResponse(r.Context(), w, http.StatusBadRequest, "invalid channel UUID", nil)
+		return
+	}
+
+	urn, err := urns.Parse(r.URL.Query().Get("urn"))
+	if err != nil {
+		WriteDataResponse(r.Context(), w, http.StatusBadRequest, "invalid URN", nil)
+		return
+	}
+
+	rc := s.backend.RedisPool().Get()
+	defer rc.Close()
+
+	cleared, err := ClearFloodBlock(rc, channelUUID, urn)
+	if err != nil {
+		logrus.WithError(err).Error("error clearing flood block")
+		WriteDataResponse(r.Context(), w, http.StatusInternalServerError, "Error clearing block", nil)
+		return
+	}
+	if !cleared {
+		WriteDataResponse(r.Context(), w, http.StatusNotFound, "no such block", nil)
+		return
+	}
+
+	logrus.WithField("channel_uuid", channelUUID).WithField("urn", urn.Identity()).Info("cleared flood block")
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", nil)
+}
+
+func (s *server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
+
+	letters, err := s.backend.GetDeadLetters(r.Context())
+	if err != nil {
+		logrus.WithError(err).Error("error fetching dead letters")
+		WriteDataResponse(r.Context(), w, http.StatusInternalServerError, "Error fetching dead letters", nil)
+		return
+	}
+
+	data := make([]interface{}, len(letters))
+	for i := range letters {
+		data[i] = letters[i]
+	}
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", data)
+}
+
+func (s *server) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
+
+	uuid := chi.URLParam(r, "uuid")
+	letter, err := s.backend.ReplayDeadLetter(r.Context(), uuid)
+	if err != nil {
+		logrus.WithError(err).WithField("dead_letter", uuid).Error("error replaying dead letter")
+		WriteDataResponse(r.Context(), w, http.StatusInternalServerError, "Error replaying dead letter", nil)
+		return
+	}
+	if letter == nil {
+		WriteDataResponse(r.Context(), w, http.StatusNotFound, "no such dead letter", nil)
+		return
+	}
+
+	logrus.WithField("dead_letter", uuid).WithField("queue", letter.Queue).Info("replayed dead letter")
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", []interface{}{letter})
+}
+
+func (s *server) handleDiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !s.checkStatusAuth(w, r) {
+		return
+	}
+
+	uuid := chi.URLParam(r, "uuid")
+	letter, err := s.backend.DiscardDeadLetter(r.Context(), uuid)
+	if err != nil {
+		logrus.WithError(err).WithField("dead_letter", uuid).Error("error discarding dead letter")
+		WriteDataResponse(r.Context(), w, http.StatusInternalServerError, "Error discarding dead letter", nil)
+		return
+	}
+	if letter == nil {
+		WriteDataResponse(r.Context(), w, http.StatusNotFound, "no such dead letter", nil)
+		return
+	}
+
+	logrus.WithField("dead_letter", uuid).Info("discarded dead letter")
+	WriteDataResponse(r.Context(), w, http.StatusOK, "Ok", []interface{}{letter})
+}
+
 // for use in request.Context
 type contextKey int
 
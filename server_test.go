@@ -42,6 +42,12 @@ func TestServer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, string(rr.Body), "courier")
 
+	// endpoints which change state need auth too
+	req, _ = http.NewRequest("DELETE", "http://localhost:8080/dead_letters/5c7e0a0d-c0ee-4c4a-9f6c-bcbb5a5e6bd6", nil)
+	rr, err = utils.MakeHTTPRequest(req)
+	assert.Error(t, err)
+	assert.Equal(t, 401, rr.StatusCode)
+
 	// hit an invalid path
 	req, _ = http.NewRequest("GET", "http://localhost:8080/notthere", nil)
 	rr, err = utils.MakeHTTPRequest(req)
@@ -0,0 +1,227 @@
+package sms
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/gocommon/gsm7"
+)
+
+// Encoding is the character encoding an SMS will be sent with
+type Encoding string
+
+// Possible values for Encoding
+const (
+	EncodingGSM7 Encoding = "GSM7"
+	EncodingUCS2 Encoding = "UCS2"
+)
+
+const (
+	// ConfigEncoding is the channel config key which determines how text is encoded for sending
+	ConfigEncoding = "encoding"
+
+	// EncodingModeDefault sends text as is, letting the provider pick its encoding
+	EncodingModeDefault = "D"
+
+	// EncodingModeUnicode always sends text as unicode
+	EncodingModeUnicode = "U"
+
+	// EncodingModeSmart replaces non-GSM7 characters with GSM7 look-alikes where that makes the whole text GSM7
+	EncodingModeSmart = "S"
+)
+
+// per segment limits, in septets for GSM7 and in UTF-16 code units for UCS2. Concatenated messages lose
+// some of each segment to the user data header (UDH) which links the parts together
+const (
+	gsm7SingleLimit = 160
+	gsm7MultiLimit  = 153
+	ucs2SingleLimit = 70
+	ucs2MultiLimit  = 67
+)
+
+// extended GSM7 characters which take two septets as they need to be preceded by an escape
+var gsm7Extended = map[rune]bool{
+	'\f': true, '^': true, '{': true, '}': true, '\\': true, '[': true, '~': true, ']': true, '|': true, '€': true,
+}
+
+// look-alikes which aren't covered by gsm7.ReplaceSubstitutions
+var lookalikes = map[rune]string{
+	'—':      "-",
+	'―':      "-",
+	'‐':      "-",
+	'‑':      "-",
+	'−':      "-",
+	'‚':      ",",
+	'„':      "\"",
+	'«':      "\"",
+	'»':      "\"",
+	'′':      "'",
+	'″':      "\"",
+	'…':      "...",
+	'•':      "-",
+	'·':      ".",
+	'ë':      "e",
+	'ï':      "i",
+	'ō':      "o",
+	'š':      "s",
+	'ž':      "z",
+	'Š':      "S",
+	'Ž':      "Z",
+	'Ë':      "E",
+	'Ï':      "I",
+	'\u200b': "",
+	'\ufeff': "",
+}
+
+// DetectEncoding returns the encoding the passed in text needs to be sent with
+func DetectEncoding(text string) Encoding {
+	if gsm7.IsValid(text) {
+		return EncodingGSM7
+	}
+	return EncodingUCS2
+}
+
+// Length returns the length of the passed in text in the units of the given encoding, that is septets for
+// GSM7 (extended characters count as two) and UTF-16 code units for UCS2 (characters outside the BMP count as two)
+func Length(text string, encoding Encoding) int {
+	length := 0
+	for _, r := range text {
+		length += runeLength(r, encoding)
+	}
+	return length
+}
+
+func runeLength(r rune, encoding Encoding) int {
+	if encoding == EncodingGSM7 {
+		if gsm7Extended[r] {
+			return 2
+		}
+		return 1
+	}
+
+	// characters outside the basic multilingual plane are encoded as surrogate pairs
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
+
+// SegmentLimit returns the number of units that fit in a message sent as the given number of segments
+func SegmentLimit(encoding Encoding, segments int) int {
+	if segments <= 1 {
+		if encoding == EncodingGSM7 {
+			return gsm7SingleLimit
+		}
+		return ucs2SingleLimit
+	}
+
+	if encoding == EncodingGSM7 {
+		return gsm7MultiLimit * segments
+	}
+	return ucs2MultiLimit * segments
+}
+
+// Segments returns the number of SMS segments the passed in text will be sent as
+func Segments(text string) int {
+	return SegmentsFor(text, DetectEncoding(text))
+}
+
+// SegmentsFor returns the number of SMS segments the passed in text will be sent as with the given encoding, which
+// is needed when an encoding is forced rather than detected from the text
+func SegmentsFor(text string, encoding Encoding) int {
+	if Length(text, encoding) <= SegmentLimit(encoding, 1) {
+		return 1
+	}
+
+	// count segments one character at a time as a multi unit character can't be split across two segments
+	perSegment := SegmentLimit(encoding, 2) / 2
+	segments, size := 1, 0
+	for _, r := range text {
+		l := runeLength(r, encoding)
+		if size+l > perSegment {
+			segments++
+			size = 0
+		}
+		size += l
+	}
+	return segments
+}
+
+// ReplaceLookalikes replaces any characters which aren't part of the GSM7 charset with look-alikes that are
+func ReplaceLookalikes(text string) string {
+	text = gsm7.ReplaceSubstitutions(text)
+
+	var replaced strings.Builder
+	for _, r := range text {
+		if sub, found := lookalikes[r]; found {
+			replaced.WriteString(sub)
+		} else {
+			replaced.WriteRune(r)
+		}
+	}
+	return replaced.String()
+}
+
+// Encode prepares the passed in text for sending using the passed in encoding mode, returning the text that
+// should be sent and the encoding it will be sent with. For smart encoding, look-alike replacements are only
+// used if they make the whole text GSM7, otherwise the original text is sent as UCS2.
+func Encode(text string, mode string) (string, Encoding) {
+	if mode == EncodingModeUnicode {
+		return text, EncodingUCS2
+	}
+
+	if mode == EncodingModeSmart {
+		replaced := ReplaceLookalikes(text)
+		if gsm7.IsValid(replaced) {
+			return replaced, EncodingGSM7
+		}
+	}
+
+	return text, DetectEncoding(text)
+}
+
+// Split splits the passed in text into parts which each fit in the given number of segments, breaking on
+// whitespace where possible. Every part is sized using the encoding of the whole text so parts are never
+// larger than the provider will allow.
+func Split(text string, maxSegments int) []string {
+	encoding := DetectEncoding(text)
+	limit := SegmentLimit(encoding, maxSegments)
+
+	if Length(text, encoding) <= limit {
+		return []string{text}
+	}
+
+	parts := make([]string, 0, 2)
+	runes := []rune(text)
+
+	for len(runes) > 0 {
+		// find how many runes fit in this part
+		size, end := 0, 0
+		for end < len(runes) {
+			l := runeLength(runes[end], encoding)
+			if size+l > limit {
+				break
+			}
+			size += l
+			end++
+		}
+
+		// we didn't make it to the end, try to break on the last whitespace in this part
+		if end < len(runes) {
+			for i := end; i > 0; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+
+		part := strings.TrimSpace(string(runes[:end]))
+		if part != "" {
+			parts = append(parts, part)
+		}
+		runes = []rune(strings.TrimLeftFunc(string(runes[end:]), unicode.IsSpace))
+	}
+
+	return parts
+}
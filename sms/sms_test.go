@@ -0,0 +1,119 @@
+package sms_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/courier/sms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	assert.Equal(t, sms.EncodingGSM7, sms.DetectEncoding(""))
+	assert.Equal(t, sms.EncodingGSM7, sms.DetectEncoding("Hello World"))
+	assert.Equal(t, sms.EncodingGSM7, sms.DetectEncoding("Prices in € {not $}"))
+	assert.Equal(t, sms.EncodingUCS2, sms.DetectEncoding("Hello 😅"))
+	assert.Equal(t, sms.EncodingUCS2, sms.DetectEncoding("Olá"))
+}
+
+func TestLength(t *testing.T) {
+	assert.Equal(t, 5, sms.Length("Hello", sms.EncodingGSM7))
+	assert.Equal(t, 6, sms.Length("[€]", sms.EncodingGSM7))
+	assert.Equal(t, 3, sms.Length("[€]", sms.EncodingUCS2))
+	assert.Equal(t, 8, sms.Length("Hello 😅", sms.EncodingUCS2))
+}
+
+func TestSegments(t *testing.T) {
+	tcs := []struct {
+		text     string
+		segments int
+	}{
+		{"", 1},
+		{"hello", 1},
+		{strings.Repeat("a", 160), 1},
+		{strings.Repeat("a", 161), 2},
+		{strings.Repeat("a", 306), 2},
+		{strings.Repeat("a", 307), 3},
+		{strings.Repeat("{", 80), 1},
+		{strings.Repeat("{", 81), 2},
+		{strings.Repeat("a", 159) + "{", 2},
+		{strings.Repeat("a", 152) + "{" + strings.Repeat("a", 152), 3}, // escape can't be split across segments
+		{"😅", 1},
+		{strings.Repeat("😅", 35), 1},
+		{strings.Repeat("😅", 36), 2},
+		{strings.Repeat("ж", 70), 1},
+		{strings.Repeat("ж", 71), 2},
+		{strings.Repeat("ж", 134), 2},
+		{strings.Repeat("ж", 135), 3},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.segments, sms.Segments(tc.text), "segments mismatch for '%s'", tc.text)
+	}
+}
+
+func TestSegmentsFor(t *testing.T) {
+	// GSM7 text forced to be sent as UCS2 takes more segments
+	assert.Equal(t, 1, sms.SegmentsFor(strings.Repeat("a", 70), sms.EncodingUCS2))
+	assert.Equal(t, 2, sms.SegmentsFor(strings.Repeat("a", 71), sms.EncodingUCS2))
+	assert.Equal(t, 3, sms.SegmentsFor(strings.Repeat("a", 160), sms.EncodingUCS2))
+	assert.Equal(t, 1, sms.SegmentsFor(strings.Repeat("a", 160), sms.EncodingGSM7))
+}
+
+func TestReplaceLookalikes(t *testing.T) {
+	assert.Equal(t, "Hello World", sms.ReplaceLookalikes("Hello World"))
+	assert.Equal(t, "\"quoted\" - it's...", sms.ReplaceLookalikes("“quoted” — it’s…"))
+	assert.Equal(t, "Ola", sms.ReplaceLookalikes("Olá"))
+	assert.Equal(t, "Hello 😅", sms.ReplaceLookalikes("Hello 😅"))
+}
+
+func TestEncode(t *testing.T) {
+	tcs := []struct {
+		text     string
+		mode     string
+		output   string
+		encoding sms.Encoding
+	}{
+		{"Hello", sms.EncodingModeDefault, "Hello", sms.EncodingGSM7},
+		{"Olá", sms.EncodingModeDefault, "Olá", sms.EncodingUCS2},
+		{"Olá", sms.EncodingModeSmart, "Ola", sms.EncodingGSM7},
+		{"Olá 😅", sms.EncodingModeSmart, "Olá 😅", sms.EncodingUCS2},
+		{"Hello", sms.EncodingModeUnicode, "Hello", sms.EncodingUCS2},
+		{"“quoted”", "", "“quoted”", sms.EncodingUCS2},
+	}
+
+	for _, tc := range tcs {
+		output, encoding := sms.Encode(tc.text, tc.mode)
+		assert.Equal(t, tc.output, output, "output mismatch for '%s'", tc.text)
+		assert.Equal(t, tc.encoding, encoding, "encoding mismatch for '%s'", tc.text)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []string{""}, sms.Split("", 1))
+	assert.Equal(t, []string{"Simple message"}, sms.Split("Simple message", 1))
+
+	long := strings.Repeat("hello ", 30) // 180 chars
+	parts := sms.Split(long, 1)
+	assert.Equal(t, 2, len(parts))
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("hello ", 26)), parts[0])
+	assert.Equal(t, strings.TrimSpace(strings.Repeat("hello ", 4)), parts[1])
+
+	// fits in two concatenated segments so isn't split
+	assert.Equal(t, []string{strings.TrimSpace(long)}, sms.Split(strings.TrimSpace(long), 2))
+
+	// a single emoji makes the whole text UCS2 and shrinks our parts
+	parts = sms.Split("😅 "+strings.Repeat("hello ", 20), 1)
+	assert.Equal(t, 2, len(parts))
+	for _, p := range parts {
+		assert.Equal(t, 1, sms.Segments(p))
+	}
+
+	// no whitespace, so we split mid word
+	parts = sms.Split(strings.Repeat("a", 200), 1)
+	assert.Equal(t, []string{strings.Repeat("a", 160), strings.Repeat("a", 40)}, parts)
+
+	// extended characters count double
+	parts = sms.Split(strings.Repeat("{", 100), 1)
+	assert.Equal(t, []string{strings.Repeat("{", 80), strings.Repeat("{", 20)}, parts)
+}
@@ -1,6 +1,13 @@
 package courier
 
-import "github.com/nyaruka/gocommon/urns"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/nyaruka/gocommon/urns"
+)
 
 // MsgStatusValue is the status of a message
 type MsgStatusValue string
@@ -35,9 +42,50 @@ type MsgStatus interface {
 	ExternalID() string
 	SetExternalID(string)
 
+	// messages sent as several requests to a provider get an external ID for each part, all of which are recorded
+	// so that delivery reports for any part can be matched to the message
+	PartExternalIDs() []string
+	AddPartExternalID(string)
+
 	Status() MsgStatusValue
 	SetStatus(MsgStatusValue)
 
+	ErrorCode() SendErrorCode
+	SetErrorCode(SendErrorCode)
+
+	Cost() *MsgCost
+	SetCost(*MsgCost)
+
 	Logs() []*ChannelLog
 	AddLog(log *ChannelLog)
 }
+
+// MsgCost is what a provider reported charging for a message, either when it was sent or in a later status callback.
+// Providers which bill by conversation rather than by message may only report how the message was categorized.
+type MsgCost struct {
+	Amount         float64 `json:"amount,omitempty"`
+	Currency       string  `json:"currency,omitempty"`
+	Billable       *bool   `json:"billable,omitempty"`
+	PricingModel   string  `json:"pricing_model,omitempty"`
+	Category       string  `json:"category,omitempty"`
+	ConversationID string  `json:"conversation_id,omitempty"`
+}
+
+// NewMsgCost creates a new cost from the passed in amount as reported by a provider, returning nil if the amount is
+// empty or not a number. Some providers report what they charged as a negative amount so we always store its absolute.
+func NewMsgCost(amount string, currency string) *MsgCost {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return nil
+	}
+	return &MsgCost{Amount: math.Abs(value), Currency: currency}
+}
+
+// Value lets costs be written to the database as JSON
+func (c MsgCost) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
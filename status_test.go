@@ -0,0 +1,23 @@
+package courier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsgCost(t *testing.T) {
+	assert.Equal(t, &MsgCost{Amount: 0.0075, Currency: "USD"}, NewMsgCost("-0.00750", "USD"))
+	assert.Equal(t, &MsgCost{Amount: 0.0333, Currency: "EUR"}, NewMsgCost("0.03330000", "EUR"))
+	assert.Nil(t, NewMsgCost("", "USD"))
+	assert.Nil(t, NewMsgCost("free", "USD"))
+
+	value, err := MsgCost{Amount: 0.0075, Currency: "USD"}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":0.0075,"currency":"USD"}`, value)
+
+	billable := false
+	value, err = MsgCost{Billable: &billable, PricingModel: "CBP", Category: "service", ConversationID: "1234"}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"billable":false,"pricing_model":"CBP","category":"service","conversation_id":"1234"}`, value)
+}
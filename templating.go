@@ -0,0 +1,144 @@
+package courier
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// MetadataTemplating is the key in outgoing message metadata which holds the template the message should be sent with
+const MetadataTemplating = "templating"
+
+// TemplateFailureReason is the standardized reason a message couldn't be sent with its template
+type TemplateFailureReason string
+
+// the reasons a message can't be sent with its template
+const (
+	TemplateInvalid             TemplateFailureReason = "template_invalid"
+	TemplateMissingNamespace    TemplateFailureReason = "template_missing_namespace"
+	TemplateUnsupportedLanguage TemplateFailureReason = "template_unsupported_language"
+	TemplateUnsupported         TemplateFailureReason = "template_unsupported"
+)
+
+// TemplateError is returned by handlers when a message can't be sent with its template, these aren't retried
+type TemplateError struct {
+	Reason  TemplateFailureReason
+	Message string
+}
+
+// NewTemplateError creates a new template error with the passed in reason and message
+func NewTemplateError(reason TemplateFailureReason, format string, args ...interface{}) *TemplateError {
+	return &TemplateError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// Templating is the template an outgoing message should be sent with, along with the params for each of its components
+type Templating struct {
+	Template   TemplateReference   `json:"template" validate:"required"`
+	Namespace  string              `json:"namespace"`
+	Language   string              `json:"language" validate:"required"`
+	Country    string              `json:"country"`
+	Variables  []string            `json:"variables"`
+	Components []TemplateComponent `json:"components" validate:"dive"`
+}
+
+// TemplateReference identifies a template, the external ID being how the provider knows it if that isn't its name
+type TemplateReference struct {
+	UUID       string `json:"uuid" validate:"required"`
+	Name       string `json:"name" validate:"required"`
+	ExternalID string `json:"external_id"`
+}
+
+// TemplateComponent holds the params of a single component of a template, buttons are identified by their index and
+// have a sub type of either quick_reply or url
+type TemplateComponent struct {
+	Type    string          `json:"type" validate:"required,oneof=header body button"`
+	SubType string          `json:"sub_type" validate:"omitempty,oneof=quick_reply url"`
+	Index   int             `json:"index"`
+	Params  []TemplateParam `json:"params" validate:"dive"`
+}
+
+// TemplateParam is a single param of a template component, either text or the URL of a media file
+type TemplateParam struct {
+	Type  string `json:"type" validate:"required,oneof=text image video document"`
+	Value string `json:"value"`
+}
+
+var templateValidator = validator.New()
+
+// GetTemplating parses and validates the template from the metadata of the passed in message, returning nil if it
+// doesn't have one. Legacy variables are turned into the params of a body component.
+func GetTemplating(msg Msg) (*Templating, error) {
+	if len(msg.Metadata()) == 0 {
+		return nil, nil
+	}
+
+	value, dataType, _, err := jsonparser.Get(msg.Metadata(), MetadataTemplating)
+	if err == jsonparser.KeyPathNotFoundError || dataType == jsonparser.Null {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewTemplateError(TemplateInvalid, "unable to read templating: %s", err)
+	}
+
+	templating := &Templating{}
+	if err := json.Unmarshal(value, templating); err != nil {
+		return nil, NewTemplateError(TemplateInvalid, "unable to decode templating: %s", err)
+	}
+	if err := templateValidator.Struct(templating); err != nil {
+		return nil, NewTemplateError(TemplateInvalid, "invalid templating definition: %s", err)
+	}
+
+	for _, component := range templating.Components {
+		if component.Type == "button" && component.SubType == "" {
+			return nil, NewTemplateError(TemplateInvalid, "button component %d has no sub type", component.Index)
+		}
+	}
+
+	if len(templating.Components) == 0 && len(templating.Variables) > 0 {
+		body := TemplateComponent{Type: "body", Params: make([]TemplateParam, len(templating.Variables))}
+		for i, v := range templating.Variables {
+			body.Params[i] = TemplateParam{Type: "text", Value: v}
+		}
+		templating.Components = []TemplateComponent{body}
+	}
+
+	return templating, nil
+}
+
+// Locale returns the language of this template, qualified by its country if it has one, e.g. eng or eng_US
+func (t *Templating) Locale() string {
+	if t.Country != "" {
+		return fmt.Sprintf("%s_%s", t.Language, t.Country)
+	}
+	return t.Language
+}
+
+// ComponentsOfType returns the components of this template of the passed in type
+func (t *Templating) ComponentsOfType(componentType string) []TemplateComponent {
+	components := make([]TemplateComponent, 0)
+	for _, component := range t.Components {
+		if component.Type == componentType {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// TextParams returns the values of the text params of all this template's components of the passed in type
+func (t *Templating) TextParams(componentType string) []string {
+	values := make([]string, 0)
+	for _, component := range t.ComponentsOfType(componentType) {
+		for _, param := range component.Params {
+			if param.Type == "text" {
+				values = append(values, param.Value)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,53 @@
+package courier
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTemplating(t *testing.T) {
+	channel := NewMockChannel("e4bb1578-29da-4fa5-a214-9da19dd24230", "WA", "2020", "US", map[string]interface{}{})
+	msgWithMetadata := func(metadata string) Msg {
+		return &mockMsg{channel: channel, text: "Hello", metadata: json.RawMessage(metadata)}
+	}
+
+	templating, err := GetTemplating(&mockMsg{channel: channel, text: "Hello"})
+	assert.NoError(t, err)
+	assert.Nil(t, templating)
+
+	templating, err = GetTemplating(msgWithMetadata(`{"topic": "event"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, templating)
+
+	// legacy variables become the params of the body
+	templating, err = GetTemplating(msgWithMetadata(`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "country": "US", "variables": ["Chef", "tomorrow"]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "revive_issue", templating.Template.Name)
+	assert.Equal(t, "eng_US", templating.Locale())
+	assert.Equal(t, []TemplateComponent{{Type: "body", Params: []TemplateParam{{Type: "text", Value: "Chef"}, {Type: "text", Value: "tomorrow"}}}}, templating.Components)
+	assert.Equal(t, []string{"Chef", "tomorrow"}, templating.TextParams("body"))
+
+	templating, err = GetTemplating(msgWithMetadata(`{"templating": {"template": {"name": "order_update", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "components": [{"type": "header", "params": [{"type": "image", "value": "https://foo.bar/order.jpg"}]}, {"type": "button", "sub_type": "url", "index": 1, "params": [{"type": "text", "value": "1234"}]}]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "eng", templating.Locale())
+	assert.Equal(t, []string{}, templating.TextParams("header"))
+	assert.Equal(t, []TemplateComponent{{Type: "button", SubType: "url", Index: 1, Params: []TemplateParam{{Type: "text", Value: "1234"}}}}, templating.ComponentsOfType("button"))
+
+	// invalid templates return template errors
+	for _, metadata := range []string{
+		`{"templating": "revive_issue"}`,
+		`{"templating": {"template": {"name": "revive_issue"}, "language": "eng"}}`,
+		`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}}}`,
+		`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "components": [{"type": "footer"}]}}`,
+		`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "components": [{"type": "button", "params": []}]}}`,
+		`{"templating": {"template": {"name": "revive_issue", "uuid": "171f8a4d-f725-46d7-85a6-11aceff0bfe3"}, "language": "eng", "components": [{"type": "body", "params": [{"type": "audio", "value": "x"}]}]}}`,
+	} {
+		templating, err = GetTemplating(msgWithMetadata(metadata))
+		assert.Nil(t, templating, "unexpected templating for %s", metadata)
+		if assert.IsType(t, &TemplateError{}, err, "expected template error for %s", metadata) {
+			assert.Equal(t, TemplateInvalid, err.(*TemplateError).Reason)
+		}
+	}
+}
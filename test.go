@@ -32,6 +32,7 @@ type MockBackend struct {
 	contacts          map[urns.URN]Contact
 	queueMsgs         []Msg
 	errorOnQueue      bool
+	dropMsgs          bool
 
 	mutex           sync.RWMutex
 	outgoingMsgs    []Msg
@@ -131,6 +132,14 @@ func (mb *MockBackend) NewOutgoingMsg(channel Channel, id MsgID, urn urns.URN, t
 	return &mockMsg{channel: channel, id: id, urn: urn, text: text, highPriority: highPriority, quickReplies: quickReplies, topic: topic, responseToExternalID: responseToExternalID}
 }
 
+// QueueOutgoingReply creates a new high priority outgoing message from the given params and adds it to our queue of
+// messages to send
+func (mb *MockBackend) QueueOutgoingReply(ctx context.Context, channel Channel, urn urns.URN, text string) (Msg, error) {
+	msg := &mockMsg{channel: channel, urn: urn, text: text, highPriority: true}
+	mb.PushOutgoingMsg(msg)
+	return msg, nil
+}
+
 // PushOutgoingMsg is a test method to add a message to our queue of messages to send
 func (mb *MockBackend) PushOutgoingMsg(msg Msg) {
 	mb.mutex.Lock()
@@ -197,6 +206,11 @@ func (mb *MockBackend) SetErrorOnQueue(shouldError bool) {
 	mb.errorOnQueue = shouldError
 }
 
+// SetDropMsgs is a mock method which makes WriteMsg drop messages as if they were over our flood limits
+func (mb *MockBackend) SetDropMsgs(drop bool) {
+	mb.dropMsgs = drop
+}
+
 // WriteMsg queues the passed in message internally
 func (mb *MockBackend) WriteMsg(ctx context.Context, m Msg) error {
 	mock := m.(*mockMsg)
@@ -210,6 +224,10 @@ func (mb *MockBackend) WriteMsg(ctx context.Context, m Msg) error {
 		return errors.New("unable to queue message")
 	}
 
+	if mb.dropMsgs {
+		return ErrMsgDropped
+	}
+
 	mb.queueMsgs = append(mb.queueMsgs, m)
 	mb.lastContactName = m.(*mockMsg).contactName
 	return nil
@@ -272,6 +290,17 @@ func (mb *MockBackend) GetChannel(ctx context.Context, cType ChannelType, uuid C
 	return channel, nil
 }
 
+// GetChannelsByType returns all the channels with the passed in type
+func (mb *MockBackend) GetChannelsByType(ctx context.Context, cType ChannelType) ([]Channel, error) {
+	channels := make([]Channel, 0)
+	for _, channel := range mb.channels {
+		if channel.ChannelType() == cType {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
 // GetChannelByAddress returns the channel with the passed in type and channel address
 func (mb *MockBackend) GetChannelByAddress(ctx context.Context, cType ChannelType, address ChannelAddress) (Channel, error) {
 	channel, found := mb.channelsByAddress[address]
@@ -385,6 +414,30 @@ func (mb *MockBackend) PurgeOutgoingQueue(channelID string) error {
 	return nil
 }
 
+// GetDeadLetters returns the dead letters of our outgoing queue
+func (mb *MockBackend) GetDeadLetters(ctx context.Context) ([]*queue.DeadLetter, error) {
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+
+	return queue.GetDeadLetters(rc, "msgs")
+}
+
+// ReplayDeadLetter puts the dead letter with the passed in UUID back onto its queue
+func (mb *MockBackend) ReplayDeadLetter(ctx context.Context, uuid string) (*queue.DeadLetter, error) {
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+
+	return queue.ReplayDeadLetter(rc, "msgs", uuid)
+}
+
+// DiscardDeadLetter removes the dead letter with the passed in UUID
+func (mb *MockBackend) DiscardDeadLetter(ctx context.Context, uuid string) (*queue.DeadLetter, error) {
+	rc := mb.RedisPool().Get()
+	defer rc.Close()
+
+	return queue.RemoveDeadLetter(rc, "msgs", uuid)
+}
+
 func (mb *MockBackend) GetActivePurges(ctx context.Context) ([]string, error) {
 	rc := mb.RedisPool().Get()
 	defer rc.Close()
@@ -541,6 +594,9 @@ func (c *MockChannel) CallbackDomain(fallbackDomain string) string {
 	return value.(string)
 }
 
+// Config returns the whole config of this channel
+func (c *MockChannel) Config() map[string]interface{} { return c.config }
+
 // ConfigForKey returns the config value for the passed in key
 func (c *MockChannel) ConfigForKey(key string, defaultValue interface{}) interface{} {
 	value, found := c.config[key]
@@ -596,6 +652,11 @@ func (c *MockChannel) IntConfigForKey(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// SetOrgConfig sets the passed in org config value for the passed in key
+func (c *MockChannel) SetOrgConfig(key string, value interface{}) {
+	c.orgConfig[key] = value
+}
+
 // OrgConfigForKey returns the org config value for the passed in key
 func (c *MockChannel) OrgConfigForKey(key string, defaultValue interface{}) interface{} {
 	value, found := c.orgConfig[key]
@@ -708,12 +769,14 @@ func (m *mockMsg) QuickReplies() []string       { return m.quickReplies }
 func (m *mockMsg) Topic() string                { return m.topic }
 func (m *mockMsg) ResponseToExternalID() string { return m.responseToExternalID }
 func (m *mockMsg) Metadata() json.RawMessage    { return m.metadata }
+func (m *mockMsg) Interactive() *Interactive    { return ParseInteractive(m.metadata) }
 func (m *mockMsg) IsResend() bool               { return m.isResend }
 
 func (m *mockMsg) ReceivedOn() *time.Time { return m.receivedOn }
 func (m *mockMsg) SentOn() *time.Time     { return m.sentOn }
 func (m *mockMsg) WiredOn() *time.Time    { return m.wiredOn }
 
+func (m *mockMsg) WithText(text string) Msg          { m.text = text; return m }
 func (m *mockMsg) WithContactName(name string) Msg   { m.contactName = name; return m }
 func (m *mockMsg) WithURNAuth(auth string) Msg       { m.urnAuth = auth; return m }
 func (m *mockMsg) WithReceivedOn(date time.Time) Msg { m.receivedOn = &date; return m }
@@ -739,6 +802,9 @@ type mockMsgStatus struct {
 	newURN     urns.URN
 	externalID string
 	status     MsgStatusValue
+	errorCode  SendErrorCode
+	cost       *MsgCost
+	partIDs    []string
 	createdOn  time.Time
 
 	logs []*ChannelLog
@@ -766,9 +832,18 @@ func (m *mockMsgStatus) HasUpdatedURN() bool {
 func (m *mockMsgStatus) ExternalID() string      { return m.externalID }
 func (m *mockMsgStatus) SetExternalID(id string) { m.externalID = id }
 
+func (m *mockMsgStatus) PartExternalIDs() []string   { return m.partIDs }
+func (m *mockMsgStatus) AddPartExternalID(id string) { m.partIDs = append(m.partIDs, id) }
+
 func (m *mockMsgStatus) Status() MsgStatusValue          { return m.status }
 func (m *mockMsgStatus) SetStatus(status MsgStatusValue) { m.status = status }
 
+func (m *mockMsgStatus) ErrorCode() SendErrorCode        { return m.errorCode }
+func (m *mockMsgStatus) SetErrorCode(code SendErrorCode) { m.errorCode = code }
+
+func (m *mockMsgStatus) Cost() *MsgCost        { return m.cost }
+func (m *mockMsgStatus) SetCost(cost *MsgCost) { m.cost = cost }
+
 func (m *mockMsgStatus) Logs() []*ChannelLog    { return m.logs }
 func (m *mockMsgStatus) AddLog(log *ChannelLog) { m.logs = append(m.logs, log) }
 
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// the URL schemes we support for connecting to Redis, each also has a TLS variant prefixed with rediss
+const (
+	redisSchemeStandalone = "redis"
+	redisSchemeSentinel   = "redis-sentinel"
+	redisSchemeCluster    = "redis-cluster"
+)
+
+// how long a pooled connection can sit idle before we check it still points at the right node when borrowing it
+const redisCheckIdleAfter = time.Second
+
+var redisKeyPrefix = ""
+var redisClusterTag = ""
+
+// SetRedisKeyPrefix sets the prefix applied to every key we read or write in Redis. In cluster mode the prefix is
+// made the hash tag of every key so that they all land in the same slot and our multi-key Lua scripts stay valid. As
+// RapidPro has to use the same keys, a prefix is never assumed and one must be configured in cluster mode.
+func SetRedisKeyPrefix(prefix string, cluster bool) error {
+	prefix = strings.TrimSuffix(prefix, ":")
+
+	if cluster {
+		if strings.Trim(prefix, "{}") == "" {
+			return errors.New("a Redis key prefix must be configured to use Redis Cluster")
+		}
+		redisKeyPrefix = "{" + strings.Trim(prefix, "{}") + "}:"
+		redisClusterTag = redisKeyPrefix
+	} else if prefix != "" {
+		redisKeyPrefix = prefix + ":"
+		redisClusterTag = ""
+	} else {
+		redisKeyPrefix = ""
+		redisClusterTag = ""
+	}
+	return nil
+}
+
+// RedisKeyPrefix returns the prefix applied to all our Redis keys, which is empty if none is configured
+func RedisKeyPrefix() string {
+	return redisKeyPrefix
+}
+
+// RedisKey builds a Redis key from the passed in format and args with our key prefix applied
+func RedisKey(format string, args ...interface{}) string {
+	return redisKeyPrefix + fmt.Sprintf(format, args...)
+}
+
+// RedisSharedKey builds the Redis key of a queue owned by mailroom or RapidPro, such as mailroom's task queues, which
+// our key prefix isn't applied to. In cluster mode we are only connected to the node which owns the slot of our hash
+// tag, so the key is given that same tag, and mailroom and RapidPro have to be configured to use it too.
+func RedisSharedKey(format string, args ...interface{}) string {
+	return redisClusterTag + fmt.Sprintf(format, args...)
+}
+
+// RedisURL is a parsed Redis connection URL, which can be one of:
+//
+//	redis[s]://[:password@]host:port/db
+//	redis[s]-sentinel://[:password@]sentinel1:26379,sentinel2:26379/master-name/db
+//	redis[s]-cluster://[:password@]node1:6379,node2:6379
+type RedisURL struct {
+	Hosts    []string
+	Password string
+	DB       int
+	Master   string
+	TLS      bool
+	Sentinel bool
+	Cluster  bool
+
+	hostsMutex sync.Mutex
+}
+
+// ParseRedisURL parses the passed in Redis connection URL
+func ParseRedisURL(rawURL string) (*RedisURL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RedisURL{}
+
+	scheme := parsed.Scheme
+	if strings.HasPrefix(scheme, "rediss") {
+		r.TLS = true
+		scheme = "redis" + strings.TrimPrefix(scheme, "rediss")
+	}
+
+	switch scheme {
+	case redisSchemeStandalone:
+	case redisSchemeSentinel:
+		r.Sentinel = true
+	case redisSchemeCluster:
+		r.Cluster = true
+	default:
+		return nil, fmt.Errorf("unsupported Redis URL scheme '%s'", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return nil, errors.New("missing Redis host")
+	}
+	for _, host := range strings.Split(parsed.Host, ",") {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, r.defaultPort())
+		}
+		r.Hosts = append(r.Hosts, host)
+	}
+
+	if parsed.User != nil {
+		r.Password, _ = parsed.User.Password()
+	}
+
+	path := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if r.Sentinel {
+		if path[0] == "" {
+			return nil, errors.New("missing Redis Sentinel master name")
+		}
+		r.Master = path[0]
+		path = path[1:]
+	}
+	if len(path) > 0 && path[0] != "" {
+		if r.Cluster {
+			return nil, errors.New("Redis Cluster doesn't support selecting a database")
+		}
+		r.DB, err = strconv.Atoi(path[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis database '%s'", path[0])
+		}
+	}
+
+	return r, nil
+}
+
+func (r *RedisURL) defaultPort() string {
+	if r.Sentinel {
+		return "26379"
+	}
+	return "6379"
+}
+
+// NewRedisPool creates a new Redis pool for the passed in connection URL. Connections made through Sentinel are to
+// the current master, and connections made to a cluster are to the node which owns the slot of our key prefix, in
+// both cases connections are rechecked when borrowed so that we follow failovers.
+func NewRedisPool(rawURL string, maxActive int, maxIdle int) (*redis.Pool, error) {
+	r, err := ParseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &redis.Pool{
+		Wait:        true,              // makes callers wait for a connection
+		MaxActive:   maxActive,         // only open this many concurrent connections at once
+		MaxIdle:     maxIdle,           // only keep up to this many idle
+		IdleTimeout: 240 * time.Second, // how long to wait before reaping a connection
+	}
+
+	switch {
+	case r.Sentinel:
+		pool.Dial = r.dialSentinelMaster
+		pool.TestOnBorrow = testRedisIdle(func(conn redis.Conn) error {
+			role, err := redis.Values(conn.Do("ROLE"))
+			if err != nil {
+				return err
+			}
+			if len(role) == 0 {
+				return errors.New("empty reply to ROLE")
+			}
+			if name, _ := redis.String(role[0], nil); name != "master" {
+				return errors.New("connection is no longer to the Redis master")
+			}
+			return nil
+		})
+	case r.Cluster:
+		pool.Dial = r.dialClusterNode
+		pool.TestOnBorrow = testRedisIdle(func(conn redis.Conn) error {
+			// errors with MOVED if this node no longer owns the slot of our keys
+			_, err := conn.Do("EXISTS", redisKeyPrefix)
+			return err
+		})
+	default:
+		pool.Dial = func() (redis.Conn, error) { return r.dial(r.Hosts[0], true) }
+	}
+
+	return pool, nil
+}
+
+// testRedisIdle returns a borrow test which only runs the passed in check on connections that have been idle a while
+func testRedisIdle(check func(redis.Conn) error) func(redis.Conn, time.Time) error {
+	return func(conn redis.Conn, lastUsed time.Time) error {
+		if time.Since(lastUsed) < redisCheckIdleAfter {
+			return nil
+		}
+		return check(conn)
+	}
+}
+
+// dial connects to the passed in Redis node, authenticating and selecting our database
+func (r *RedisURL) dial(address string, selectDB bool) (redis.Conn, error) {
+	options := []redis.DialOption{
+		redis.DialConnectTimeout(5 * time.Second),
+		redis.DialUseTLS(r.TLS),
+		redis.DialPassword(r.Password),
+	}
+	if selectDB {
+		options = append(options, redis.DialDatabase(r.DB))
+	}
+	return redis.Dial("tcp", address, options...)
+}
+
+// dialSentinelMaster asks each of our sentinels in turn for the address of the current master and connects to it
+func (r *RedisURL) dialSentinelMaster() (redis.Conn, error) {
+	var lastErr error
+
+	for _, sentinel := range r.orderedHosts() {
+		address, err := r.getSentinelMaster(sentinel)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// prefer this sentinel next time as we know it is up
+		r.preferHost(sentinel)
+
+		return r.dial(address, true)
+	}
+
+	return nil, fmt.Errorf("unable to get address of Redis master '%s' from any sentinel: %v", r.Master, lastErr)
+}
+
+// getSentinelMaster asks the passed in sentinel for the address of our master
+func (r *RedisURL) getSentinelMaster(sentinel string) (string, error) {
+	conn, err := redis.Dial("tcp", sentinel, redis.DialConnectTimeout(time.Second), redis.DialUseTLS(r.TLS))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	addr, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", r.Master))
+	if err != nil {
+		return "", err
+	}
+	if len(addr) != 2 {
+		return "", fmt.Errorf("sentinel %s doesn't know master '%s'", sentinel, r.Master)
+	}
+	return net.JoinHostPort(addr[0], addr[1]), nil
+}
+
+// dialClusterNode connects to the cluster node which owns the slot all our keys hash to
+func (r *RedisURL) dialClusterNode() (redis.Conn, error) {
+	var lastErr error
+
+	for _, node := range r.orderedHosts() {
+		address, err := r.getClusterSlotOwner(node)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		r.preferHost(node)
+
+		return r.dial(address, false)
+	}
+
+	return nil, fmt.Errorf("unable to find Redis cluster node for keys prefixed '%s': %v", redisKeyPrefix, lastErr)
+}
+
+// getClusterSlotOwner asks the passed in cluster node for the address of the master which owns our slot
+func (r *RedisURL) getClusterSlotOwner(node string) (string, error) {
+	conn, err := r.dial(node, false)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	slot, err := redis.Int64(conn.Do("CLUSTER", "KEYSLOT", redisKeyPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	ranges, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return "", err
+	}
+
+	// each range is [start, end, [host, port, ...] of master, ...replicas]
+	for _, rng := range ranges {
+		fields, err := redis.Values(rng, nil)
+		if err != nil || len(fields) < 3 {
+			continue
+		}
+		start, _ := redis.Int64(fields[0], nil)
+		end, _ := redis.Int64(fields[1], nil)
+		if slot < start || slot > end {
+			continue
+		}
+
+		master, err := redis.Values(fields[2], nil)
+		if err != nil || len(master) < 2 {
+			break
+		}
+		host, _ := redis.String(master[0], nil)
+		port, _ := redis.Int64(master[1], nil)
+		if host == "" {
+			host, _, _ = net.SplitHostPort(node)
+		}
+		return net.JoinHostPort(host, strconv.FormatInt(port, 10)), nil
+	}
+
+	return "", fmt.Errorf("no cluster node owns slot %d", slot)
+}
+
+// orderedHosts returns a copy of our hosts, in the order we should try them
+func (r *RedisURL) orderedHosts() []string {
+	r.hostsMutex.Lock()
+	defer r.hostsMutex.Unlock()
+
+	return append([]string(nil), r.Hosts...)
+}
+
+// preferHost moves the passed in host to the front of our hosts
+func (r *RedisURL) preferHost(host string) {
+	r.hostsMutex.Lock()
+	defer r.hostsMutex.Unlock()
+
+	hosts := []string{host}
+	for _, h := range r.Hosts {
+		if h != host {
+			hosts = append(hosts, h)
+		}
+	}
+	r.Hosts = hosts
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisRecorder is a fake Redis connection for tests which records the commands run on it, and the keys passed to the
+// Lua scripts run on it, rather than running them, every command gets a nil reply
+type RedisRecorder struct {
+	Commands [][]string
+	Keys     [][]string
+}
+
+// Do records the passed in command, and the keys of any script being run
+func (r *RedisRecorder) Do(cmd string, args ...interface{}) (interface{}, error) {
+	// an empty command just flushes what was sent
+	if cmd == "" {
+		return nil, nil
+	}
+
+	command := []string{strings.ToUpper(cmd)}
+	for _, arg := range args {
+		command = append(command, fmt.Sprint(arg))
+	}
+	r.Commands = append(r.Commands, command)
+
+	if (command[0] == "EVALSHA" || command[0] == "EVAL") && len(args) >= 2 {
+		count, _ := strconv.Atoi(fmt.Sprint(args[1]))
+		keys := make([]string, 0, count)
+		for _, key := range args[2 : 2+count] {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		r.Keys = append(r.Keys, keys)
+	}
+	return nil, nil
+}
+
+// Send records the passed in command
+func (r *RedisRecorder) Send(cmd string, args ...interface{}) error {
+	_, err := r.Do(cmd, args...)
+	return err
+}
+
+// Flush does nothing
+func (r *RedisRecorder) Flush() error { return nil }
+
+// Receive returns a nil reply
+func (r *RedisRecorder) Receive() (interface{}, error) { return nil, nil }
+
+// Close does nothing
+func (r *RedisRecorder) Close() error { return nil }
+
+// Err returns nil as we never fail
+func (r *RedisRecorder) Err() error { return nil }
+
+// Pool returns a pool whose connections are all this recorder
+func (r *RedisRecorder) Pool() *redis.Pool {
+	return &redis.Pool{Dial: func() (redis.Conn, error) { return r, nil }}
+}
@@ -0,0 +1,78 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/nyaruka/courier/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRedisURL(t *testing.T) {
+	r, err := utils.ParseRedisURL("redis://localhost:6379/15")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"localhost:6379"}, r.Hosts)
+	assert.Equal(t, 15, r.DB)
+	assert.False(t, r.TLS)
+	assert.False(t, r.Sentinel)
+	assert.False(t, r.Cluster)
+
+	r, err = utils.ParseRedisURL("rediss://:sesame@redis.example.com/2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"redis.example.com:6379"}, r.Hosts)
+	assert.Equal(t, "sesame", r.Password)
+	assert.Equal(t, 2, r.DB)
+	assert.True(t, r.TLS)
+
+	r, err = utils.ParseRedisURL("redis-sentinel://:sesame@sentinel1,sentinel2:26380/mymaster/15")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sentinel1:26379", "sentinel2:26380"}, r.Hosts)
+	assert.Equal(t, "mymaster", r.Master)
+	assert.Equal(t, 15, r.DB)
+	assert.True(t, r.Sentinel)
+	assert.False(t, r.TLS)
+
+	r, err = utils.ParseRedisURL("rediss-cluster://node1:7000,node2:7001")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node1:7000", "node2:7001"}, r.Hosts)
+	assert.True(t, r.Cluster)
+	assert.True(t, r.TLS)
+
+	_, err = utils.ParseRedisURL("memcached://localhost:11211")
+	assert.EqualError(t, err, "unsupported Redis URL scheme 'memcached'")
+
+	_, err = utils.ParseRedisURL("redis-sentinel://sentinel1:26379")
+	assert.EqualError(t, err, "missing Redis Sentinel master name")
+
+	_, err = utils.ParseRedisURL("redis-cluster://node1:7000/15")
+	assert.EqualError(t, err, "Redis Cluster doesn't support selecting a database")
+
+	_, err = utils.ParseRedisURL("redis://localhost:6379/foo")
+	assert.EqualError(t, err, "invalid Redis database 'foo'")
+}
+
+func TestRedisKey(t *testing.T) {
+	defer utils.SetRedisKeyPrefix("", false)
+
+	utils.SetRedisKeyPrefix("", false)
+	assert.Equal(t, "", utils.RedisKeyPrefix())
+	assert.Equal(t, "msgs:active", utils.RedisKey("msgs:active"))
+
+	utils.SetRedisKeyPrefix("staging", false)
+	assert.Equal(t, "staging:", utils.RedisKeyPrefix())
+	assert.Equal(t, "staging:rate_limit:1234", utils.RedisKey("rate_limit:%s", "1234"))
+
+	// the queues of mailroom and RapidPro aren't prefixed
+	assert.Equal(t, "handler:1", utils.RedisSharedKey("handler:%d", 1))
+
+	// in cluster mode the prefix is a hash tag so all keys land in one slot
+	utils.SetRedisKeyPrefix("staging:", true)
+	assert.Equal(t, "{staging}:", utils.RedisKeyPrefix())
+	assert.Equal(t, "{staging}:msgs:active", utils.RedisKey("msgs:active"))
+
+	// and the queues of mailroom and RapidPro get the same hash tag so that they're on the node we're connected to
+	assert.Equal(t, "{staging}:handler:1", utils.RedisSharedKey("handler:%d", 1))
+
+	// but we don't assume a prefix as RapidPro has to use the same keys
+	err := utils.SetRedisKeyPrefix("", true)
+	assert.EqualError(t, err, "a Redis key prefix must be configured to use Redis Cluster")
+}
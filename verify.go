@@ -0,0 +1,189 @@
+package courier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultVerifySecretHeader = "X-Webhook-Secret"
+	defaultVerifyHMACHeader   = "X-Signature"
+	defaultVerifyHMACAlgo     = "sha256"
+)
+
+var verifyHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// verifyRequest checks the passed in request against whatever verification is configured on the channel, returning
+// the status code that should be used to reject the request if it fails. Channels without any verification
+// configured accept all requests, as do requests without a channel such as webhook verifications which handlers check
+// themselves.
+func verifyRequest(channel Channel, r *http.Request) (int, error) {
+	if channel == nil {
+		return 0, nil
+	}
+
+	allowed := configStrings(channel.ConfigForKey(ConfigVerifyAllowedIPs, nil))
+	if len(allowed) > 0 {
+		ip := requestIP(r)
+		if !ipAllowed(ip, allowed) {
+			return http.StatusForbidden, fmt.Errorf("requests from '%s' are not allowed", ip)
+		}
+	}
+
+	secret := channel.StringConfigForKey(ConfigVerifySecret, "")
+	if secret != "" {
+		var actual string
+		param := channel.StringConfigForKey(ConfigVerifySecretParam, "")
+		if param != "" {
+			actual = r.URL.Query().Get(param)
+		} else {
+			actual = r.Header.Get(channel.StringConfigForKey(ConfigVerifySecretHeader, defaultVerifySecretHeader))
+		}
+
+		if subtle.ConstantTimeCompare([]byte(actual), []byte(secret)) != 1 {
+			return http.StatusUnauthorized, errors.New("missing or invalid verification secret")
+		}
+	}
+
+	username := channel.StringConfigForKey(ConfigVerifyUsername, "")
+	password := channel.StringConfigForKey(ConfigVerifyPassword, "")
+	if username != "" || password != "" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 || subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			return http.StatusUnauthorized, errors.New("missing or invalid basic auth credentials")
+		}
+	}
+
+	key := channel.StringConfigForKey(ConfigVerifyHMACKey, "")
+	if key != "" {
+		algo := strings.ToLower(channel.StringConfigForKey(ConfigVerifyHMACAlgorithm, defaultVerifyHMACAlgo))
+		newHash, found := verifyHashes[algo]
+		if !found {
+			return http.StatusUnauthorized, fmt.Errorf("unknown signature algorithm '%s'", algo)
+		}
+
+		body, err := readBody(r)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("unable to read request body: %s", err)
+		}
+
+		signature := r.Header.Get(channel.StringConfigForKey(ConfigVerifyHMACHeader, defaultVerifyHMACHeader))
+		if !validSignature(newHash, key, body, signature) {
+			return http.StatusUnauthorized, errors.New("missing or invalid request signature")
+		}
+	}
+
+	return 0, nil
+}
+
+// validSignature checks the passed in signature against the HMAC of the body, the signature can be hex or base64
+// encoded and optionally prefixed with the algorithm, e.g. sha256=...
+func validSignature(newHash func() hash.Hash, key string, body []byte, signature string) bool {
+	if idx := strings.Index(signature, "="); idx > 0 && idx < len(signature)-1 {
+		if _, isAlgo := verifyHashes[strings.ToLower(signature[:idx])]; isAlgo {
+			signature = signature[idx+1:]
+		}
+	}
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	if actual, err := hex.DecodeString(signature); err == nil && hmac.Equal(actual, expected) {
+		return true
+	}
+	if actual, err := base64.StdEncoding.DecodeString(signature); err == nil && hmac.Equal(actual, expected) {
+		return true
+	}
+	return false
+}
+
+// readBody reads the body of the passed in request, replacing it so that it can be read again by the handler
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return []byte{}, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// requestIP returns the IP of the passed in request, our RealIP middleware has already taken care of any proxies
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ipAllowed returns whether the passed in IP matches any of the passed in IPs or CIDRs
+func ipAllowed(ip string, allowed []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, a := range allowed {
+		if strings.Contains(a, "/") {
+			_, network, err := net.ParseCIDR(a)
+			if err == nil && network.Contains(parsed) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(a); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// configStrings converts a config value which may be a list or a comma separated string to a list of strings
+func configStrings(value interface{}) []string {
+	var values []string
+
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case []interface{}:
+		for _, i := range v {
+			if s, isString := i.(string); isString {
+				values = append(values, s)
+			}
+		}
+	case string:
+		values = strings.Split(v, ",")
+	}
+
+	cleaned := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	return cleaned
+}
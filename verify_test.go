@@ -0,0 +1,74 @@
+package courier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nyaruka/courier/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"text":"hello"}`
+	signature := utils.SignHMAC256("sesame", body)
+
+	tcs := []struct {
+		label   string
+		config  map[string]interface{}
+		prep    func(r *http.Request)
+		status  int
+		errText string
+	}{
+		{"no verification", nil, nil, 0, ""},
+
+		{"secret header", map[string]interface{}{ConfigVerifySecret: "sesame"}, func(r *http.Request) { r.Header.Set("X-Webhook-Secret", "sesame") }, 0, ""},
+		{"secret custom header", map[string]interface{}{ConfigVerifySecret: "sesame", ConfigVerifySecretHeader: "X-Token"}, func(r *http.Request) { r.Header.Set("X-Token", "sesame") }, 0, ""},
+		{"secret missing", map[string]interface{}{ConfigVerifySecret: "sesame"}, nil, 401, "missing or invalid verification secret"},
+		{"secret wrong", map[string]interface{}{ConfigVerifySecret: "sesame"}, func(r *http.Request) { r.Header.Set("X-Webhook-Secret", "sesam") }, 401, "missing or invalid verification secret"},
+		{"secret param", map[string]interface{}{ConfigVerifySecret: "sesame", ConfigVerifySecretParam: "token"}, func(r *http.Request) { r.URL.RawQuery = "token=sesame" }, 0, ""},
+		{"secret param wrong", map[string]interface{}{ConfigVerifySecret: "sesame", ConfigVerifySecretParam: "token"}, func(r *http.Request) { r.Header.Set("X-Webhook-Secret", "sesame") }, 401, "missing or invalid verification secret"},
+
+		{"basic auth", map[string]interface{}{ConfigVerifyUsername: "bob", ConfigVerifyPassword: "pass"}, func(r *http.Request) { r.SetBasicAuth("bob", "pass") }, 0, ""},
+		{"basic auth wrong", map[string]interface{}{ConfigVerifyUsername: "bob", ConfigVerifyPassword: "pass"}, func(r *http.Request) { r.SetBasicAuth("bob", "word") }, 401, "missing or invalid basic auth credentials"},
+		{"basic auth missing", map[string]interface{}{ConfigVerifyUsername: "bob", ConfigVerifyPassword: "pass"}, nil, 401, "missing or invalid basic auth credentials"},
+
+		{"hmac hex", map[string]interface{}{ConfigVerifyHMACKey: "sesame"}, func(r *http.Request) { r.Header.Set("X-Signature", signature) }, 0, ""},
+		{"hmac prefixed", map[string]interface{}{ConfigVerifyHMACKey: "sesame", ConfigVerifyHMACHeader: "X-Hub-Signature-256"}, func(r *http.Request) { r.Header.Set("X-Hub-Signature-256", "sha256="+signature) }, 0, ""},
+		{"hmac wrong algorithm", map[string]interface{}{ConfigVerifyHMACKey: "sesame", ConfigVerifyHMACAlgorithm: "sha1"}, func(r *http.Request) { r.Header.Set("X-Signature", signature) }, 401, "missing or invalid request signature"},
+		{"hmac unknown algorithm", map[string]interface{}{ConfigVerifyHMACKey: "sesame", ConfigVerifyHMACAlgorithm: "md5"}, nil, 401, "unknown signature algorithm 'md5'"},
+		{"hmac missing", map[string]interface{}{ConfigVerifyHMACKey: "sesame"}, nil, 401, "missing or invalid request signature"},
+
+		{"ip allowed", map[string]interface{}{ConfigVerifyAllowedIPs: []interface{}{"10.0.0.1", "192.168.1.0/24"}}, func(r *http.Request) { r.RemoteAddr = "192.168.1.20:5000" }, 0, ""},
+		{"ip allowed string", map[string]interface{}{ConfigVerifyAllowedIPs: "10.0.0.1, 192.168.1.0/24"}, func(r *http.Request) { r.RemoteAddr = "10.0.0.1" }, 0, ""},
+		{"ip not allowed", map[string]interface{}{ConfigVerifyAllowedIPs: []interface{}{"10.0.0.1", "192.168.1.0/24"}}, func(r *http.Request) { r.RemoteAddr = "192.168.2.20:5000" }, 403, "requests from '192.168.2.20' are not allowed"},
+	}
+
+	for _, tc := range tcs {
+		channel := NewMockChannel("8eb23e93-5ecb-45ba-b726-3b064e0c56ab", "KN", "2020", "US", tc.config)
+		r := httptest.NewRequest(http.MethodPost, "/c/kn/8eb23e93-5ecb-45ba-b726-3b064e0c56ab/receive", strings.NewReader(body))
+		if tc.prep != nil {
+			tc.prep(r)
+		}
+
+		status, err := verifyRequest(channel, r)
+		assert.Equal(t, tc.status, status, "status mismatch in %s", tc.label)
+		if tc.errText != "" {
+			assert.EqualError(t, err, tc.errText, "error mismatch in %s", tc.label)
+		} else {
+			assert.NoError(t, err, "unexpected error in %s", tc.label)
+
+			// body should still be readable by our handler
+			read, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, body, string(read), "body mismatch in %s", tc.label)
+		}
+	}
+
+	// requests which handlers don't have a channel for are left to them to verify
+	r := httptest.NewRequest(http.MethodGet, "/c/fba/receive?hub.mode=subscribe", nil)
+	status, err := verifyRequest(nil, r)
+	assert.Equal(t, 0, status)
+	assert.NoError(t, err)
+}